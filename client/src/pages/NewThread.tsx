@@ -4,11 +4,12 @@ import {
   CircularProgress,
   Container,
   FormControl,
+  MenuItem,
   TextField,
   Typography,
 } from "@mui/material";
-import { Thread, ThreadData } from "../types/shibespaceAPI";
-import { useRef, useState } from "react";
+import { Category, Thread, ThreadData } from "../types/shibespaceAPI";
+import { useEffect, useRef, useState } from "react";
 
 import { ErrorResponse } from "../types/shibespaceAPI";
 import { StatusCodes } from "http-status-codes";
@@ -22,15 +23,48 @@ const NewThread: React.FC = () => {
   const [defaultContent, setDefaultContent] = useState<string>("");
   const [isValidTitle, setIsValidTitle] = useState<boolean>(true);
   const [tags, setTags] = useState<string[]>([]); // The input verification is done in TagField
+  const [categories, setCategories] = useState<Category[]>([]);
+  const [categoryID, setCategoryID] = useState<number | "">("");
   const [loading, setLoading] = useState<boolean>(false);
   const submitButton = useRef<HTMLButtonElement | null>(null);
   const navigate = useNavigate();
 
+  useEffect(() => {
+    const fetchCategories = async (): Promise<void> => {
+      try {
+        const response = await fetch(
+          import.meta.env.VITE_SHIBESPACEAPI_BASEURL + "/categories"
+        );
+
+        if (!response.ok) {
+          const errorResponse = (await response.json()) as ErrorResponse;
+          throw new Error(errorResponse.error);
+        } else {
+          const categories = (await response.json()) as Category[];
+          setCategories(categories);
+          // Threads are placed in the general category unless another one is chosen
+          const general = categories.find((c) => c.slug === "general");
+          setCategoryID(general ? general.id : categories[0]?.id ?? "");
+        }
+      } catch (error: unknown) {
+        setErrorText("Failed to load categories, please try again later");
+        if (error instanceof Error) {
+          console.error("Error fetching data:", error.message);
+        } else {
+          console.error("An unknown error occured:", error);
+        }
+      }
+    };
+
+    fetchCategories();
+  }, []);
+
   const handleNewThread = async (formData: FormData): Promise<void> => {
     const threadData: ThreadData = {
       title: formData.get("title") as string,
       content: formData.get("content") as string,
       tags: tags, // Is always valid at the point of submission
+      category_id: Number(categoryID),
     };
 
     // Prevent fields from being cleared
@@ -127,6 +161,22 @@ const NewThread: React.FC = () => {
             rows={10}
             multiline
           ></TextField>
+          <TextField
+            name="category"
+            label="Category"
+            margin="normal"
+            value={categoryID}
+            onChange={(event) => setCategoryID(Number(event.target.value))}
+            disabled={loading || categories.length === 0}
+            required
+            select
+          >
+            {categories.map((c) => (
+              <MenuItem key={c.id} value={c.id}>
+                {c.name}
+              </MenuItem>
+            ))}
+          </TextField>
           {/* Don't show this button as we are using the one outside the form */}
           <Button
             type="submit"
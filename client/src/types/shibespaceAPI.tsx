@@ -30,6 +30,19 @@ export interface ThreadData {
   title: string;
   content: string;
   tags: string[];
+  category_id: number;
+}
+
+export interface Category {
+  id: number;
+  slug: string;
+  name: string;
+  description: string;
+  position: number;
+  parent_id: number | null;
+  post_role: string;
+  required_tags: string[];
+  created_timestamp: string;
 }
 
 export interface ThreadContent {
@@ -0,0 +1,277 @@
+package automod
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ActionReject  = "reject"
+	ActionHold    = "hold"
+	ActionReplace = "replace"
+	ActionReport  = "report"
+)
+
+var Actions = []string{ActionReject, ActionHold, ActionReplace, ActionReport}
+
+// Rules are compiled into regular expressions, so their size is limited to keep them cheap to compile and match
+const (
+	MaxPatternLength = 500
+	MaxWords         = 100
+	MaxWordLength    = 50
+)
+
+// Counts the links in the content, including those that are autolinked without a scheme
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+/*
+A rule matches content when all of its conditions match, and at least one condition is required:
+the pattern is a regular expression that matches the content, the words are matched as whole words ignoring case,
+the content has more links than MaxLinks, or the account of the author is younger than MaxAccountAge.
+*/
+type Rule struct {
+	Name          string
+	Pattern       string
+	Words         []string
+	MaxLinks      *int
+	MaxAccountAge *time.Duration
+	Action        string
+	Message       string
+	Replacement   string
+}
+
+/*
+A compiled rule keeps the regular expressions of its pattern and words, so that they are only compiled once.
+*/
+type CompiledRule struct {
+	Rule
+	matchers []*regexp.Regexp
+}
+
+/*
+The cache keeps the compiled enabled rules in memory, so that they are only loaded and compiled again after they change.
+They are also reloaded once they are older than the TTL, so that changes made through other servers are picked up.
+*/
+type Cache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	rules    []CompiledRule
+	loadedAt time.Time
+	version  uint64
+}
+
+/*
+The title is empty for comments.
+*/
+type Content struct {
+	Title      string
+	Body       string
+	AccountAge time.Duration
+}
+
+/*
+The title and body are the content after every replacement. Rejected content is not checked against the rest of the rules,
+so the message is the one of the rule that rejected it. Held content is also reported.
+*/
+type Result struct {
+	Title    string
+	Body     string
+	Rejected bool
+	Message  string
+	Held     bool
+	Reported bool
+	Matched  []string
+}
+
+/*
+This function checks that the rule has at least one condition, that the pattern and words are not too long,
+that the pattern is a valid regular expression, and that the action is valid. Only rules with a pattern or words can replace text.
+*/
+func Validate(rule Rule) error {
+	if rule.Pattern == "" && len(rule.Words) == 0 && rule.MaxLinks == nil && rule.MaxAccountAge == nil {
+		return errors.New("at least one of pattern, words, max_links or max_account_age_hours is required")
+	}
+
+	if len(rule.Pattern) > MaxPatternLength {
+		return fmt.Errorf("pattern must be at most %d characters long", MaxPatternLength)
+	}
+
+	if len(rule.Words) > MaxWords {
+		return fmt.Errorf("number of words must be at most %d", MaxWords)
+	}
+
+	for _, word := range rule.Words {
+		if len(word) > MaxWordLength {
+			return fmt.Errorf("all words must be at most %d characters long", MaxWordLength)
+		}
+	}
+
+	_, err := compile(rule)
+	if err != nil {
+		return err
+	}
+
+	switch rule.Action {
+	case ActionReject, ActionHold, ActionReport:
+	case ActionReplace:
+		if rule.Pattern == "" && len(rule.Words) == 0 {
+			return errors.New("pattern or words are required to replace text")
+		}
+	default:
+		return errors.New("action must be one of reject, hold, replace or report")
+	}
+
+	return nil
+}
+
+/*
+This function compiles the rules in order, leaving out those that are not valid.
+*/
+func Compile(rules []Rule) []CompiledRule {
+	compiledRules := []CompiledRule{}
+
+	for _, rule := range rules {
+		matchers, err := compile(rule)
+		if err != nil {
+			continue
+		}
+		compiledRules = append(compiledRules, CompiledRule{Rule: rule, matchers: matchers})
+	}
+
+	return compiledRules
+}
+
+/*
+This function checks the content against the rules in order, so that later rules see the text replaced by earlier ones.
+*/
+func Evaluate(rules []CompiledRule, content Content) Result {
+	result := Result{Title: content.Title, Body: content.Body, Matched: []string{}}
+
+	for _, rule := range rules {
+		if !matches(rule.Rule, rule.matchers, result, content.AccountAge) {
+			continue
+		}
+		result.Matched = append(result.Matched, rule.Name)
+
+		switch rule.Action {
+		case ActionReject:
+			result.Rejected = true
+			result.Message = rule.Message
+			return result
+		case ActionHold:
+			result.Held = true
+			result.Reported = true
+		case ActionReport:
+			result.Reported = true
+		case ActionReplace:
+			for _, matcher := range rule.matchers {
+				result.Title = matcher.ReplaceAllLiteralString(result.Title, rule.Replacement)
+				result.Body = matcher.ReplaceAllLiteralString(result.Body, rule.Replacement)
+			}
+		}
+	}
+
+	return result
+}
+
+/*
+This function creates an empty cache, where the rules are reloaded once they are older than the TTL.
+*/
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl}
+}
+
+/*
+This function gets the compiled rules from the cache, loading and compiling them if they are not cached or are too old.
+Rules that were loaded while the cache was invalidated are returned without being cached, as they may be out of date.
+*/
+func (cache *Cache) Get(load func() ([]Rule, error)) ([]CompiledRule, error) {
+	cache.mu.Lock()
+	if cache.rules != nil && time.Since(cache.loadedAt) < cache.ttl {
+		rules := cache.rules
+		cache.mu.Unlock()
+		return rules, nil
+	}
+	version := cache.version
+	cache.mu.Unlock()
+
+	rules, err := load()
+	if err != nil {
+		return nil, err
+	}
+	compiledRules := Compile(rules)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.version == version {
+		cache.rules = compiledRules
+		cache.loadedAt = time.Now()
+	}
+
+	return compiledRules, nil
+}
+
+/*
+This function drops the cached rules, so that they are loaded again the next time they are needed.
+It is called whenever a rule is created, updated or deleted.
+*/
+func (cache *Cache) Invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.rules = nil
+	cache.version++
+}
+
+/*
+This function compiles the pattern and the words of the rule into regular expressions, leaving out those that are not given.
+*/
+func compile(rule Rule) ([]*regexp.Regexp, error) {
+	matchers := []*regexp.Regexp{}
+
+	if rule.Pattern != "" {
+		matcher, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, errors.New("pattern must be a valid regular expression")
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	if len(rule.Words) > 0 {
+		words := []string{}
+		for _, word := range rule.Words {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+		matchers = append(matchers, regexp.MustCompile(`(?i)\b(?:`+strings.Join(words, "|")+`)\b`))
+	}
+
+	return matchers, nil
+}
+
+/*
+This function checks if every condition of the rule matches the content.
+*/
+func matches(rule Rule, matchers []*regexp.Regexp, result Result, accountAge time.Duration) bool {
+	for _, matcher := range matchers {
+		if !matcher.MatchString(result.Title) && !matcher.MatchString(result.Body) {
+			return false
+		}
+	}
+
+	if rule.MaxLinks != nil {
+		links := len(linkPattern.FindAllStringIndex(result.Title, -1)) + len(linkPattern.FindAllStringIndex(result.Body, -1))
+		if links <= *rule.MaxLinks {
+			return false
+		}
+	}
+
+	if rule.MaxAccountAge != nil && accountAge >= *rule.MaxAccountAge {
+		return false
+	}
+
+	return true
+}
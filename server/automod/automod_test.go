@@ -0,0 +1,301 @@
+package automod
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func durationPointer(value time.Duration) *time.Duration {
+	return &value
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		content Content
+		want    Result
+	}{
+		{
+			name:    "no rules",
+			rules:   []Rule{},
+			content: Content{Title: "Hello", Body: "world"},
+			want:    Result{Title: "Hello", Body: "world", Matched: []string{}},
+		},
+		{
+			name: "words match whole words ignoring case",
+			rules: []Rule{
+				{Name: "words", Words: []string{"spam"}, Action: ActionReport},
+			},
+			content: Content{Body: "This is SPAM."},
+			want:    Result{Body: "This is SPAM.", Reported: true, Matched: []string{"words"}},
+		},
+		{
+			name: "words do not match inside other words",
+			rules: []Rule{
+				{Name: "words", Words: []string{"spam"}, Action: ActionReport},
+			},
+			content: Content{Body: "spammer"},
+			want:    Result{Body: "spammer", Matched: []string{}},
+		},
+		{
+			name: "words are matched literally",
+			rules: []Rule{
+				{Name: "words", Words: []string{"a.b"}, Action: ActionReport},
+			},
+			content: Content{Body: "axb"},
+			want:    Result{Body: "axb", Matched: []string{}},
+		},
+		{
+			name: "pattern matches the title",
+			rules: []Rule{
+				{Name: "pattern", Pattern: `^\[SALE\]`, Action: ActionReport},
+			},
+			content: Content{Title: "[SALE] Cheap", Body: "Buy now"},
+			want:    Result{Title: "[SALE] Cheap", Body: "Buy now", Reported: true, Matched: []string{"pattern"}},
+		},
+		{
+			name: "every condition must match",
+			rules: []Rule{
+				{Name: "both", Words: []string{"buy"}, MaxLinks: intPointer(0), Action: ActionReport},
+			},
+			content: Content{Body: "buy this"},
+			want:    Result{Body: "buy this", Matched: []string{}},
+		},
+		{
+			name: "more links than allowed",
+			rules: []Rule{
+				{Name: "links", MaxLinks: intPointer(1), Action: ActionHold},
+			},
+			content: Content{Body: "https://a.example www.b.example"},
+			want:    Result{Body: "https://a.example www.b.example", Held: true, Reported: true, Matched: []string{"links"}},
+		},
+		{
+			name: "links up to the limit are allowed",
+			rules: []Rule{
+				{Name: "links", MaxLinks: intPointer(1), Action: ActionHold},
+			},
+			content: Content{Body: "https://a.example"},
+			want:    Result{Body: "https://a.example", Matched: []string{}},
+		},
+		{
+			name: "new accounts",
+			rules: []Rule{
+				{Name: "new", MaxAccountAge: durationPointer(24 * time.Hour), Action: ActionReport},
+			},
+			content: Content{Body: "Hi", AccountAge: time.Hour},
+			want:    Result{Body: "Hi", Reported: true, Matched: []string{"new"}},
+		},
+		{
+			name: "old accounts",
+			rules: []Rule{
+				{Name: "new", MaxAccountAge: durationPointer(24 * time.Hour), Action: ActionReport},
+			},
+			content: Content{Body: "Hi", AccountAge: 48 * time.Hour},
+			want:    Result{Body: "Hi", Matched: []string{}},
+		},
+		{
+			name: "replace in the title and body",
+			rules: []Rule{
+				{Name: "replace", Words: []string{"darn"}, Action: ActionReplace, Replacement: "****"},
+			},
+			content: Content{Title: "Darn it", Body: "darn, darn"},
+			want:    Result{Title: "**** it", Body: "****, ****", Matched: []string{"replace"}},
+		},
+		{
+			name: "replacements are literal",
+			rules: []Rule{
+				{Name: "replace", Pattern: `(\w+)@example\.com`, Action: ActionReplace, Replacement: "$1"},
+			},
+			content: Content{Body: "mail me@example.com"},
+			want:    Result{Body: "mail $1", Matched: []string{"replace"}},
+		},
+		{
+			name: "later rules see replaced text",
+			rules: []Rule{
+				{Name: "replace", Words: []string{"cheap"}, Action: ActionReplace, Replacement: "free"},
+				{Name: "report", Words: []string{"free"}, Action: ActionReport},
+			},
+			content: Content{Body: "cheap pills"},
+			want:    Result{Body: "free pills", Reported: true, Matched: []string{"replace", "report"}},
+		},
+		{
+			name: "earlier rules do not see later replacements",
+			rules: []Rule{
+				{Name: "report", Words: []string{"free"}, Action: ActionReport},
+				{Name: "replace", Words: []string{"cheap"}, Action: ActionReplace, Replacement: "free"},
+			},
+			content: Content{Body: "cheap pills"},
+			want:    Result{Body: "free pills", Matched: []string{"replace"}},
+		},
+		{
+			name: "reject stops at the first rejecting rule",
+			rules: []Rule{
+				{Name: "hold", Words: []string{"pills"}, Action: ActionHold},
+				{Name: "reject", Words: []string{"pills"}, Action: ActionReject, Message: "No pills"},
+				{Name: "other", Words: []string{"pills"}, Action: ActionReject, Message: "Other"},
+				{Name: "replace", Words: []string{"pills"}, Action: ActionReplace, Replacement: "x"},
+			},
+			content: Content{Body: "pills"},
+			want: Result{
+				Body:     "pills",
+				Rejected: true,
+				Message:  "No pills",
+				Held:     true,
+				Reported: true,
+				Matched:  []string{"hold", "reject"},
+			},
+		},
+		{
+			name: "invalid rules are skipped",
+			rules: []Rule{
+				{Name: "invalid", Pattern: "(", Action: ActionReject},
+				{Name: "valid", Words: []string{"hi"}, Action: ActionReport},
+			},
+			content: Content{Body: "hi"},
+			want:    Result{Body: "hi", Reported: true, Matched: []string{"valid"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Evaluate(Compile(test.rules), test.content)
+
+			if got.Title != test.want.Title || got.Body != test.want.Body {
+				t.Errorf("got title %q and body %q, want %q and %q", got.Title, got.Body, test.want.Title, test.want.Body)
+			}
+			if got.Rejected != test.want.Rejected || got.Message != test.want.Message {
+				t.Errorf("got rejected %v with %q, want %v with %q", got.Rejected, got.Message, test.want.Rejected, test.want.Message)
+			}
+			if got.Held != test.want.Held || got.Reported != test.want.Reported {
+				t.Errorf("got held %v and reported %v, want %v and %v", got.Held, got.Reported, test.want.Held, test.want.Reported)
+			}
+			if !slices.Equal(got.Matched, test.want.Matched) {
+				t.Errorf("got matched %v, want %v", got.Matched, test.want.Matched)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooManyWords := make([]string, MaxWords+1)
+	for i := range tooManyWords {
+		tooManyWords[i] = "word"
+	}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{
+			name:    "pattern",
+			rule:    Rule{Pattern: `\bspam\b`, Action: ActionReject},
+			wantErr: false,
+		},
+		{
+			name:    "account age only",
+			rule:    Rule{MaxAccountAge: durationPointer(time.Hour), Action: ActionHold},
+			wantErr: false,
+		},
+		{
+			name:    "no conditions",
+			rule:    Rule{Action: ActionReject},
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			rule:    Rule{Pattern: "(", Action: ActionReject},
+			wantErr: true,
+		},
+		{
+			name:    "pattern too long",
+			rule:    Rule{Pattern: strings.Repeat("a", MaxPatternLength+1), Action: ActionReject},
+			wantErr: true,
+		},
+		{
+			name:    "pattern at the limit",
+			rule:    Rule{Pattern: strings.Repeat("a", MaxPatternLength), Action: ActionReject},
+			wantErr: false,
+		},
+		{
+			name:    "too many words",
+			rule:    Rule{Words: tooManyWords, Action: ActionReject},
+			wantErr: true,
+		},
+		{
+			name:    "word too long",
+			rule:    Rule{Words: []string{strings.Repeat("a", MaxWordLength+1)}, Action: ActionReject},
+			wantErr: true,
+		},
+		{
+			name:    "invalid action",
+			rule:    Rule{Words: []string{"spam"}, Action: "delete"},
+			wantErr: true,
+		},
+		{
+			name:    "replace with words",
+			rule:    Rule{Words: []string{"spam"}, Action: ActionReplace},
+			wantErr: false,
+		},
+		{
+			name:    "replace without text to replace",
+			rule:    Rule{MaxLinks: intPointer(2), Action: ActionReplace},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(test.rule)
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	loads := 0
+	load := func() ([]Rule, error) {
+		loads++
+		return []Rule{{Name: "words", Words: []string{"spam"}, Action: ActionReport}}, nil
+	}
+
+	cache := NewCache(time.Hour)
+	for range 2 {
+		rules, err := cache.Get(load)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		if len(rules) != 1 {
+			t.Fatalf("got %d rules, want 1", len(rules))
+		}
+	}
+	if loads != 1 {
+		t.Errorf("got %d loads before invalidating, want 1", loads)
+	}
+
+	cache.Invalidate()
+	_, err := cache.Get(load)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("got %d loads after invalidating, want 2", loads)
+	}
+
+	_, err = cache.Get(func() ([]Rule, error) {
+		return nil, errors.New("failed")
+	})
+	if err != nil {
+		t.Errorf("got error %v from the cached rules", err)
+	}
+}
@@ -15,3 +15,15 @@ require github.com/lib/pq v1.10.9
 require golang.org/x/crypto v0.31.0
 
 require github.com/golang-jwt/jwt/v5 v5.2.1
+
+require github.com/yuin/goldmark v1.8.6
+
+require github.com/microcosm-cc/bluemonday v1.0.27
+
+require golang.org/x/image v0.25.0
+
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	golang.org/x/net v0.26.0 // indirect
+)
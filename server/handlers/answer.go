@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+/*
+This handler marks the comment based on the 'comment_id' path parameter as the accepted answer of its thread,
+replacing the previously accepted answer if there is one.
+Only the creator of the thread is allowed to accept an answer, and answers in archived threads cannot be changed.
+The author of the comment is notified in the same transaction, unless they created the thread.
+The updated thread is returned.
+*/
+func (connection *DatabaseConnection) AcceptCommentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	comment, statusCode, err := connection.checkAnswerAccepter(r, int32(id), true)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed answer check: %v", err))
+		return
+	}
+
+	var thread database.Thread
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		thread, err = q.AcceptThreadComment(r.Context(), database.AcceptThreadCommentParams{
+			ID:        comment.ThreadID,
+			CommentID: int32(id),
+		})
+		if err != nil {
+			return err
+		}
+
+		if comment.CreatorID == thread.CreatorID {
+			return nil
+		}
+
+		return q.CreateNotification(r.Context(), database.CreateNotificationParams{
+			UserID:    comment.CreatorID,
+			Type:      "answer_accepted",
+			ActorID:   thread.CreatorID,
+			ThreadID:  thread.ID,
+			CommentID: sql.NullInt32{Int32: int32(id), Valid: true},
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusConflict, "The comment is already the accepted answer")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to accept answer: %v", err))
+		}
+		return
+	}
+
+	connection.respondWithAnswerThread(w, r, thread)
+}
+
+/*
+This handler removes the comment based on the 'comment_id' path parameter as the accepted answer of its thread,
+so that the thread is unanswered again.
+Only the creator of the thread is allowed to do so, and answers in archived threads cannot be changed.
+The updated thread is returned.
+*/
+func (connection *DatabaseConnection) UnacceptCommentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	comment, statusCode, err := connection.checkAnswerAccepter(r, int32(id), false)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed answer check: %v", err))
+		return
+	}
+
+	thread, err := connection.DB.UnacceptThreadComment(r.Context(), database.UnacceptThreadCommentParams{
+		ID:        comment.ThreadID,
+		CommentID: int32(id),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The comment is not the accepted answer")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to unaccept answer: %v", err))
+		}
+		return
+	}
+
+	connection.respondWithAnswerThread(w, r, thread)
+}
+
+/*
+This function gets the thread and creator of the comment, then checks that the user through jwt
+created the thread of the comment and that the thread is not archived.
+Comments that are held for review cannot be accepted, since they are hidden from the list of comments,
+but a held comment that is already accepted can still be unaccepted.
+*/
+func (connection *DatabaseConnection) checkAnswerAccepter(r *http.Request, commentID int32, accepting bool) (database.GetCommentThreadAndCreatorRow, int, error) {
+	comment, err := connection.DB.GetCommentThreadAndCreator(r.Context(), commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return comment, http.StatusNotFound, errors.New("the comment does not exist")
+		}
+		return comment, http.StatusInternalServerError, fmt.Errorf("failed to get comment: %v", err)
+	}
+
+	creatorID, err := connection.DB.GetThreadCreatorID(r.Context(), comment.ThreadID)
+	if err != nil {
+		return comment, http.StatusInternalServerError, fmt.Errorf("failed to get thread creator ID: %v", err)
+	}
+
+	userID, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
+	if err != nil {
+		return comment, statusCode, fmt.Errorf("failed jwt matching check: %v", err)
+	}
+
+	statusCode, err = connection.checkThreadWritable(r.Context(), comment.ThreadID, userID, false)
+	if err != nil {
+		return comment, statusCode, err
+	}
+
+	if accepting && comment.Held {
+		statusCode, err = connection.heldCheck(r.Context(), userID, comment.CreatorID, "comment")
+		if err != nil {
+			return comment, statusCode, err
+		}
+		return comment, http.StatusForbidden, errors.New("the comment is held for review and cannot be accepted")
+	}
+
+	return comment, http.StatusOK, nil
+}
+
+/*
+This function responds with the thread after its accepted answer was changed, formatted for its creator.
+*/
+func (connection *DatabaseConnection) respondWithAnswerThread(w http.ResponseWriter, r *http.Request, thread database.Thread) {
+	formattedThreads, err := connection.formatThreads(r.Context(), []database.Thread{thread},
+		uuid.NullUUID{UUID: thread.CreatorID, Valid: true})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format thread: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedThreads[0])
+}
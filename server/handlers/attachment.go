@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wangyuanchi/shibespace/server/imaging"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+	"github.com/wangyuanchi/shibespace/server/storage"
+)
+
+// The maximum size of an uploaded file, which is 10 MB
+const maxAttachmentSize = 10 << 20
+
+// The room for the rest of the multipart form around the file
+const multipartOverhead = 1 << 20
+
+// The maximum number of attachments that a single thread or comment can link to
+const maxAttachmentReferences = 20
+
+// Threads and comments link to attachments through the URL that is returned when uploading them
+var attachmentPattern = regexp.MustCompile(`/v1/attachments/([0-9]+)\b`)
+
+/*
+The types of files that can be uploaded, based on the type sniffed from the content
+rather than the type given by the client.
+*/
+var allowedAttachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+}
+
+/*
+This handler uploads the file in the 'file' field of the multipart form for the user through jwt.
+The type of the file is sniffed from its content and must be one of the allowed types.
+The metadata of images is removed before they are stored, and their thumbnails are generated in the background.
+Files are stored under the hash of their content, so the same file is only stored once.
+Attachments that are not linked to from a thread or comment are garbage collected after a while.
+*/
+func (connection *DatabaseConnection) UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.RespondWithError(w, http.StatusRequestEntityTooLarge, "The file must be at most 10 MB")
+		} else {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse file: %v", err))
+		}
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to read file: %v", err))
+		return
+	}
+
+	if len(data) > maxAttachmentSize {
+		response.RespondWithError(w, http.StatusRequestEntityTooLarge, "The file must be at most 10 MB")
+		return
+	}
+
+	if len(data) == 0 {
+		response.RespondWithError(w, http.StatusBadRequest, "The file is empty")
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !slices.Contains(allowedAttachmentTypes, mediaType) {
+		response.RespondWithError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Files of type '%s' are not allowed", contentType))
+		return
+	}
+
+	thumbnailStatus := "none"
+	if strings.HasPrefix(mediaType, "image/") {
+		err = imaging.CheckImage(data, mediaType)
+		if err == imaging.ErrTooLarge {
+			response.RespondWithError(w, http.StatusBadRequest, "Images must be at most 16 megapixels")
+			return
+		}
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid image: %v", err))
+			return
+		}
+
+		data, err = imaging.StripMetadata(data, mediaType)
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid image: %v", err))
+			return
+		}
+
+		thumbnailStatus = "pending"
+	}
+
+	hash := sha256.Sum256(data)
+	key := hex.EncodeToString(hash[:])
+
+	upsertBlobParams := database.UpsertBlobParams{
+		Sha256:          key,
+		Size:            int32(len(data)),
+		ContentType:     contentType,
+		ThumbnailStatus: thumbnailStatus,
+	}
+
+	// The blob is refreshed before the file is stored, so that the garbage collector leaves it alone for its grace period,
+	// and collects the file later if the upload fails before the attachment is created.
+	// Its thumbnails are only queued once the file is stored
+	_, err = connection.DB.UpsertBlob(r.Context(), database.UpsertBlobParams{
+		Sha256:          key,
+		Size:            int32(len(data)),
+		ContentType:     contentType,
+		ThumbnailStatus: "none",
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add blob to database: %v", err))
+		return
+	}
+
+	err = connection.Store.Put(r.Context(), key, data, contentType)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to store file: %v", err))
+		return
+	}
+
+	var attachment database.Attachment
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		thumbnailStatus, err = q.UpsertBlob(r.Context(), upsertBlobParams)
+		if err != nil {
+			return err
+		}
+
+		attachment, err = q.CreateAttachment(r.Context(), database.CreateAttachmentParams{
+			BlobSha256: key,
+			UploaderID: userID,
+			Filename:   attachmentFilename(header.Filename),
+		})
+		return err
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add attachment to database: %v", err))
+		return
+	}
+
+	if thumbnailStatus == "pending" {
+		connection.Thumbnails.Enqueue(key)
+	}
+
+	// The same image may have been uploaded before, in which case its thumbnails can already be ready
+	thumbnails, err := connection.DB.GetBlobThumbnails(r.Context(), key)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get thumbnails: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatAttachment(database.GetAttachmentRow{
+		ID:               attachment.ID,
+		BlobSha256:       attachment.BlobSha256,
+		UploaderID:       attachment.UploaderID,
+		Filename:         attachment.Filename,
+		CreatedTimestamp: attachment.CreatedTimestamp,
+		Size:             int32(len(data)),
+		ContentType:      contentType,
+		ThumbnailStatus:  thumbnailStatus,
+	}, thumbnails))
+}
+
+/*
+This handler gets the details of the attachment based on the 'attachment_id' path parameter.
+The thumbnail status of images is 'pending' until their thumbnails are generated in the background,
+and then either 'ready' or 'failed'. Files that are not images have a thumbnail status of 'none'.
+*/
+func (connection *DatabaseConnection) GetAttachmentInfoHandler(w http.ResponseWriter, r *http.Request) {
+	attachmentID := chi.URLParam(r, "attachment_id")
+	id, err := strconv.Atoi(attachmentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid attachment ID: %v", err))
+		return
+	}
+
+	attachment, err := connection.DB.GetAttachment(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The attachment does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get attachment: %v", err))
+		}
+		return
+	}
+
+	thumbnails, err := connection.DB.GetBlobThumbnails(r.Context(), attachment.BlobSha256)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get thumbnails: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatAttachment(attachment, thumbnails))
+}
+
+/*
+This handler serves the file of the attachment based on the 'attachment_id' path parameter,
+which is downloaded with its original filename unless it is an image.
+*/
+func (connection *DatabaseConnection) GetAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachmentID := chi.URLParam(r, "attachment_id")
+	id, err := strconv.Atoi(attachmentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid attachment ID: %v", err))
+		return
+	}
+
+	attachment, err := connection.DB.GetAttachment(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The attachment does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get attachment: %v", err))
+		}
+		return
+	}
+
+	connection.serveBlob(w, r, attachment.BlobSha256, attachment.ContentType, attachment.Size, attachment.Filename)
+}
+
+/*
+This handler serves the thumbnail of the image attachment based on the 'attachment_id' and 'size' path parameters,
+where the size must be one of the thumbnail sizes. Thumbnails only exist once they have been generated.
+*/
+func (connection *DatabaseConnection) GetAttachmentThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	attachmentID := chi.URLParam(r, "attachment_id")
+	id, err := strconv.Atoi(attachmentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid attachment ID: %v", err))
+		return
+	}
+
+	size, err := strconv.Atoi(chi.URLParam(r, "size"))
+	if err != nil || !slices.Contains(imaging.ThumbnailSizes, size) {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid size: must be one of %v", imaging.ThumbnailSizes))
+		return
+	}
+
+	thumbnail, err := connection.DB.GetAttachmentThumbnail(r.Context(), database.GetAttachmentThumbnailParams{
+		AttachmentID: int32(id),
+		Size:         int32(size),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The thumbnail does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get thumbnail: %v", err))
+		}
+		return
+	}
+
+	connection.serveBlob(w, r, thumbnail.Sha256, thumbnail.ContentType, thumbnail.Size, "")
+}
+
+/*
+This function streams the stored file to the response.
+Images are shown inline, while other files are downloaded with the given filename.
+Files never change for the same key, so they can be cached indefinitely.
+*/
+func (connection *DatabaseConnection) serveBlob(w http.ResponseWriter, r *http.Request, key, contentType string, size int32, filename string) {
+	file, err := connection.Store.Get(r.Context(), key)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			response.RespondWithError(w, http.StatusNotFound, "The file does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get file: %v", err))
+		}
+		return
+	}
+	defer file.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	params := map[string]string{}
+	if filename != "" {
+		params["filename"] = filename
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(int(size)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.WriteHeader(http.StatusOK)
+
+	io.Copy(w, file)
+}
+
+/*
+This function links the attachments in the content of a thread or comment to it, within the transaction of the write,
+replacing the previous links so that attachments which are no longer linked to can be garbage collected.
+The comment ID is only set for comments.
+*/
+func linkContentAttachments(ctx context.Context, q *database.Queries, threadID int32, commentID sql.NullInt32, content string) error {
+	var err error
+	if commentID.Valid {
+		err = q.DeleteCommentAttachmentReferences(ctx, commentID)
+	} else {
+		err = q.DeleteThreadAttachmentReferences(ctx, threadID)
+	}
+	if err != nil {
+		return err
+	}
+
+	attachmentIDs := parseAttachmentIDs(content)
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+
+	return q.CreateAttachmentReferences(ctx, database.CreateAttachmentReferencesParams{
+		ThreadID:      threadID,
+		CommentID:     commentID,
+		AttachmentIds: attachmentIDs,
+	})
+}
+
+/*
+This function parses the IDs of the attachments linked to in the content, returning the distinct IDs in order,
+up to the maximum number of attachments.
+*/
+func parseAttachmentIDs(content string) []int32 {
+	attachmentIDs := []int32{}
+
+	for _, match := range attachmentPattern.FindAllStringSubmatch(content, -1) {
+		id, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil || slices.Contains(attachmentIDs, int32(id)) {
+			continue
+		}
+
+		attachmentIDs = append(attachmentIDs, int32(id))
+		if len(attachmentIDs) == maxAttachmentReferences {
+			break
+		}
+	}
+
+	return attachmentIDs
+}
+
+/*
+This function cleans up the filename given by the client, keeping only the base name
+and cutting it down to at most 255 bytes without splitting any characters.
+*/
+func attachmentFilename(filename string) string {
+	filename = strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if filename == "." || filename == "/" || filename == "" {
+		return "attachment"
+	}
+
+	for len(filename) > 255 {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+
+	return filename
+}
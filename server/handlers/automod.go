@@ -0,0 +1,686 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/automod"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+// The reason of reports created by auto-moderation, which users cannot report with
+const automodReason = "automod"
+
+type automodRuleData struct {
+	Name               string   `json:"name"`
+	Pattern            string   `json:"pattern"`
+	Words              []string `json:"words"`
+	MaxLinks           *int32   `json:"max_links"`
+	MaxAccountAgeHours *int32   `json:"max_account_age_hours"`
+	Action             string   `json:"action"`
+	Message            string   `json:"message"`
+	Replacement        string   `json:"replacement"`
+	Enabled            *bool    `json:"enabled"`
+}
+
+type automodRuleSettings struct {
+	Name               *string  `json:"name"`
+	Pattern            *string  `json:"pattern"`
+	Words              []string `json:"words"`
+	MaxLinks           *int32   `json:"max_links"`
+	MaxAccountAgeHours *int32   `json:"max_account_age_hours"`
+	Action             *string  `json:"action"`
+	Message            *string  `json:"message"`
+	Replacement        *string  `json:"replacement"`
+	Enabled            *bool    `json:"enabled"`
+}
+
+type automodTest struct {
+	Title           string           `json:"title"`
+	Content         string           `json:"content"`
+	AccountAgeHours *int32           `json:"account_age_hours"`
+	Rule            *automodRuleData `json:"rule"`
+}
+
+/*
+The result of checking content against the auto-moderation rules and the spam classifier,
+where content that is likely to be spam is held as well.
+*/
+type moderation struct {
+	automod.Result
+	SpamProbability float64
+	Spam            bool
+}
+
+type automodTestResult struct {
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Rejected bool     `json:"rejected"`
+	Message  string   `json:"message"`
+	Held     bool     `json:"held"`
+	Reported bool     `json:"reported"`
+	Matched  []string `json:"matched"`
+}
+
+/*
+This handler gets every auto-moderation rule, in the order that they are evaluated in.
+Only admins are allowed to get the rules.
+The response may be a 204 status code (no content).
+*/
+func (connection *DatabaseConnection) GetAutomodRulesHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	rules, err := connection.DB.GetAutomodRules(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get rules: %v", err))
+		return
+	}
+
+	if rules == nil {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatAutomodRules(rules))
+}
+
+/*
+This handler parses the name, conditions, action, message and replacement of an auto-moderation rule from the request.
+It conducts input validation, then creates the rule, which is enabled unless specified otherwise.
+Rules are evaluated in the order that they are created in.
+Only admins are allowed to create rules.
+*/
+func (connection *DatabaseConnection) CreateAutomodRuleHandler(w http.ResponseWriter, r *http.Request) {
+	automodRuleData := automodRuleData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&automodRuleData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = automodRuleDataValidation(automodRuleData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	createAutomodRuleParams := database.CreateAutomodRuleParams{
+		Name:        automodRuleData.Name,
+		Pattern:     automodRuleData.Pattern,
+		Words:       automodRuleData.Words,
+		Action:      automodRuleData.Action,
+		Message:     automodRuleData.Message,
+		Replacement: automodRuleData.Replacement,
+		Enabled:     true,
+	}
+	if automodRuleData.MaxLinks != nil {
+		createAutomodRuleParams.MaxLinks = sql.NullInt32{Int32: *automodRuleData.MaxLinks, Valid: true}
+	}
+	if automodRuleData.MaxAccountAgeHours != nil {
+		createAutomodRuleParams.MaxAccountAgeHours = sql.NullInt32{Int32: *automodRuleData.MaxAccountAgeHours, Valid: true}
+	}
+	if automodRuleData.Enabled != nil {
+		createAutomodRuleParams.Enabled = *automodRuleData.Enabled
+	}
+	if createAutomodRuleParams.Words == nil {
+		createAutomodRuleParams.Words = []string{}
+	}
+
+	rule, err := connection.DB.CreateAutomodRule(r.Context(), createAutomodRuleParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The rule already exists")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add rule to database: %v", err))
+		}
+		return
+	}
+
+	connection.Rules.Invalidate()
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatAutomodRule(rule))
+}
+
+/*
+This handler updates the auto-moderation rule based on the 'rule_id' path parameter.
+Attributes that are not supplied are left unchanged, while an empty pattern, an empty list of words,
+and a max_links or max_account_age_hours of -1 remove that condition from the rule.
+Only admins are allowed to update rules.
+*/
+func (connection *DatabaseConnection) UpdateAutomodRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "rule_id")
+	id, err := strconv.Atoi(ruleID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid rule ID: %v", err))
+		return
+	}
+
+	automodRuleSettings := automodRuleSettings{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&automodRuleSettings)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	rule, err := connection.DB.GetAutomodRule(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The rule does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get rule: %v", err))
+		}
+		return
+	}
+
+	updateAutomodRuleParams := database.UpdateAutomodRuleParams{
+		ID:                 rule.ID,
+		Name:               rule.Name,
+		Pattern:            rule.Pattern,
+		Words:              rule.Words,
+		MaxLinks:           rule.MaxLinks,
+		MaxAccountAgeHours: rule.MaxAccountAgeHours,
+		Action:             rule.Action,
+		Message:            rule.Message,
+		Replacement:        rule.Replacement,
+		Enabled:            rule.Enabled,
+	}
+	if automodRuleSettings.Name != nil {
+		updateAutomodRuleParams.Name = *automodRuleSettings.Name
+	}
+	if automodRuleSettings.Pattern != nil {
+		updateAutomodRuleParams.Pattern = *automodRuleSettings.Pattern
+	}
+	if automodRuleSettings.Words != nil {
+		updateAutomodRuleParams.Words = automodRuleSettings.Words
+	}
+	if automodRuleSettings.MaxLinks != nil {
+		updateAutomodRuleParams.MaxLinks = sql.NullInt32{Int32: *automodRuleSettings.MaxLinks, Valid: *automodRuleSettings.MaxLinks != -1}
+	}
+	if automodRuleSettings.MaxAccountAgeHours != nil {
+		updateAutomodRuleParams.MaxAccountAgeHours = sql.NullInt32{
+			Int32: *automodRuleSettings.MaxAccountAgeHours,
+			Valid: *automodRuleSettings.MaxAccountAgeHours != -1,
+		}
+	}
+	if automodRuleSettings.Action != nil {
+		updateAutomodRuleParams.Action = *automodRuleSettings.Action
+	}
+	if automodRuleSettings.Message != nil {
+		updateAutomodRuleParams.Message = *automodRuleSettings.Message
+	}
+	if automodRuleSettings.Replacement != nil {
+		updateAutomodRuleParams.Replacement = *automodRuleSettings.Replacement
+	}
+	if automodRuleSettings.Enabled != nil {
+		updateAutomodRuleParams.Enabled = *automodRuleSettings.Enabled
+	}
+
+	automodRuleData := automodRuleData{
+		Name:        updateAutomodRuleParams.Name,
+		Pattern:     updateAutomodRuleParams.Pattern,
+		Words:       updateAutomodRuleParams.Words,
+		Action:      updateAutomodRuleParams.Action,
+		Message:     updateAutomodRuleParams.Message,
+		Replacement: updateAutomodRuleParams.Replacement,
+	}
+	if updateAutomodRuleParams.MaxLinks.Valid {
+		automodRuleData.MaxLinks = &updateAutomodRuleParams.MaxLinks.Int32
+	}
+	if updateAutomodRuleParams.MaxAccountAgeHours.Valid {
+		automodRuleData.MaxAccountAgeHours = &updateAutomodRuleParams.MaxAccountAgeHours.Int32
+	}
+
+	err = automodRuleDataValidation(automodRuleData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	updatedRule, err := connection.DB.UpdateAutomodRule(r.Context(), updateAutomodRuleParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The rule already exists")
+		} else if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The rule does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update rule: %v", err))
+		}
+		return
+	}
+
+	connection.Rules.Invalidate()
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatAutomodRule(updatedRule))
+}
+
+/*
+This handler deletes the auto-moderation rule based on the 'rule_id' path parameter.
+Content that was already held or reported by the rule is left as it is.
+Only admins are allowed to delete rules.
+*/
+func (connection *DatabaseConnection) DeleteAutomodRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "rule_id")
+	id, err := strconv.Atoi(ruleID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid rule ID: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	_, err = connection.DB.DeleteAutomodRule(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The rule does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete rule: %v", err))
+		}
+		return
+	}
+
+	connection.Rules.Invalidate()
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler checks the title and content from the request against the auto-moderation rules without posting anything,
+responding with what would happen to the content and the names of the rules that matched it.
+The enabled rules are used, unless a single rule is given in the request to try it out before creating it.
+The account age defaults to the age of the account of the admin.
+Only admins are allowed to test rules.
+*/
+func (connection *DatabaseConnection) TestAutomodRulesHandler(w http.ResponseWriter, r *http.Request) {
+	automodTest := automodTest{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&automodTest)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	if automodTest.Title == "" && automodTest.Content == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: title or content is required")
+		return
+	}
+
+	if automodTest.AccountAgeHours != nil && *automodTest.AccountAgeHours < 0 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: account_age_hours cannot be negative")
+		return
+	}
+
+	if automodTest.Rule != nil {
+		err = automodRuleDataValidation(*automodTest.Rule)
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+			return
+		}
+	}
+
+	adminID, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	var rules []automod.CompiledRule
+	if automodTest.Rule != nil {
+		rules = automod.Compile([]automod.Rule{automodRuleFromData(*automodTest.Rule)})
+	} else {
+		rules, err = connection.getEnabledAutomodRules(r.Context())
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get rules: %v", err))
+			return
+		}
+	}
+
+	var accountAge time.Duration
+	if automodTest.AccountAgeHours != nil {
+		accountAge = time.Duration(*automodTest.AccountAgeHours) * time.Hour
+	} else {
+		accountAge, err = connection.getAccountAge(r.Context(), adminID)
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get account age: %v", err))
+			return
+		}
+	}
+
+	result := automod.Evaluate(rules, automod.Content{
+		Title:      automodTest.Title,
+		Body:       automodTest.Content,
+		AccountAge: accountAge,
+	})
+
+	response.RespondWithJSON(w, http.StatusOK, automodTestResult{
+		Title:    result.Title,
+		Content:  result.Body,
+		Rejected: result.Rejected,
+		Message:  result.Message,
+		Held:     result.Held,
+		Reported: result.Reported,
+		Matched:  result.Matched,
+	})
+}
+
+/*
+This function checks the title and content posted by the user against the enabled auto-moderation rules,
+then holds the content if the spam classifier finds it likely to be spam, unless it is already rejected or held.
+The title is empty for comments and for updates of the content of threads.
+*/
+func (connection *DatabaseConnection) moderateContent(ctx context.Context, userID uuid.UUID, title, content string) (moderation, error) {
+	rules, err := connection.getEnabledAutomodRules(ctx)
+	if err != nil {
+		return moderation{}, fmt.Errorf("failed to get rules: %v", err)
+	}
+
+	accountAge, err := connection.getAccountAge(ctx, userID)
+	if err != nil {
+		return moderation{}, fmt.Errorf("failed to get account age: %v", err)
+	}
+
+	result := moderation{
+		Result: automod.Evaluate(rules, automod.Content{
+			Title:      title,
+			Body:       content,
+			AccountAge: accountAge,
+		}),
+	}
+	if result.Rejected || result.Held {
+		return result, nil
+	}
+
+	result.SpamProbability, err = connection.classifySpam(ctx, spamContent(result.Title, result.Body))
+	if err != nil {
+		return moderation{}, fmt.Errorf("failed to classify spam: %v", err)
+	}
+	if result.SpamProbability >= getSpamThreshold() {
+		result.Spam = true
+		result.Held = true
+	}
+
+	return result, nil
+}
+
+/*
+This function gets the enabled auto-moderation rules from the cache, loading and compiling them if needed.
+*/
+func (connection *DatabaseConnection) getEnabledAutomodRules(ctx context.Context) ([]automod.CompiledRule, error) {
+	return connection.Rules.Get(func() ([]automod.Rule, error) {
+		rules, err := connection.DB.GetEnabledAutomodRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return automodRules(rules), nil
+	})
+}
+
+/*
+This function gets how long ago the account of the user was created.
+Users who existed before accounts were timestamped and never posted are treated as created at the Unix epoch.
+*/
+func (connection *DatabaseConnection) getAccountAge(ctx context.Context, userID uuid.UUID) (time.Duration, error) {
+	createdTimestamp, err := connection.DB.GetUserCreatedTimestamp(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Since(createdTimestamp), nil
+}
+
+/*
+This function checks if the viewer can see held content of the creator,
+which is only allowed for the creator and for moderators and admins until it is released.
+*/
+func (connection *DatabaseConnection) canViewHeld(ctx context.Context, viewerID uuid.NullUUID, creatorID uuid.UUID) (bool, error) {
+	if !viewerID.Valid {
+		return false, nil
+	}
+	if viewerID.UUID == creatorID {
+		return true, nil
+	}
+
+	return connection.canViewAllHeld(ctx, viewerID.UUID)
+}
+
+/*
+This function checks if the user can see all held content, which is the case for moderators and above.
+*/
+func (connection *DatabaseConnection) canViewAllHeld(ctx context.Context, userID uuid.UUID) (bool, error) {
+	role, err := connection.DB.GetUserRole(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return middleware.RoleAtLeast(role, middleware.RoleModerator), nil
+}
+
+/*
+This function responds with the message of the rule that rejected the content, or a generic one if it has none.
+*/
+func respondWithRejection(w http.ResponseWriter, result moderation) {
+	message := result.Message
+	if message == "" {
+		message = "it is not allowed here"
+	}
+
+	response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("The content was rejected: %s", message))
+}
+
+/*
+This function reports the thread or comment if it was reported by the auto-moderation rules or held by the spam classifier.
+The details of the reports list the rules that matched the content or the probability that it is spam.
+*/
+func reportModeratedContent(ctx context.Context, q *database.Queries, target reportTarget, result moderation) error {
+	if result.Reported {
+		err := createModerationReport(ctx, q, target, automodReason, fmt.Sprintf("Matched rules: %s", strings.Join(result.Matched, ", ")))
+		if err != nil {
+			return err
+		}
+	}
+
+	if result.Spam {
+		return createModerationReport(ctx, q, target, spamReason, fmt.Sprintf("Spam probability: %.2f", result.SpamProbability))
+	}
+
+	return nil
+}
+
+/*
+This function reports the thread or comment without a reporter, adding to the open case of it if there is one.
+*/
+func createModerationReport(ctx context.Context, q *database.Queries, target reportTarget, reason, details string) error {
+	caseID, err := openReportCase(ctx, q, target)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateReport(ctx, database.CreateReportParams{
+		CaseID:  caseID,
+		Reason:  reason,
+		Details: details,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.AddReportCaseReport(ctx, caseID)
+}
+
+/*
+This function releases the reported thread or comment of the case if it is held,
+then notifies the mentioned users and the author of the parent comment as if it was just created.
+The released comment is returned so that the subscribers of the thread can be notified after the transaction.
+*/
+func releaseHeldContent(ctx context.Context, q *database.Queries, reportCase database.GetReportCaseRow) (*database.Comment, error) {
+	switch {
+	case reportCase.TargetType == "thread" && reportCase.ThreadID.Valid:
+		thread, err := q.ReleaseThread(ctx, reportCase.ThreadID.Int32)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		return nil, createContentNotifications(ctx, q, contentNotifications{
+			ActorID:  thread.CreatorID,
+			ThreadID: thread.ID,
+			Content:  thread.Content,
+		})
+	case reportCase.TargetType == "comment" && reportCase.CommentID.Valid:
+		comment, err := q.ReleaseComment(ctx, reportCase.CommentID.Int32)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		err = q.RefreshThreadComments(ctx, comment.ThreadID)
+		if err != nil {
+			return nil, err
+		}
+
+		parentAuthorID := uuid.NullUUID{}
+		if comment.ParentID.Valid {
+			parent, err := q.GetCommentThreadAndCreator(ctx, comment.ParentID.Int32)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			parentAuthorID = uuid.NullUUID{UUID: parent.CreatorID, Valid: err == nil}
+		}
+
+		err = createContentNotifications(ctx, q, contentNotifications{
+			ActorID:        comment.CreatorID,
+			ThreadID:       comment.ThreadID,
+			CommentID:      sql.NullInt32{Int32: comment.ID, Valid: true},
+			ParentAuthorID: parentAuthorID,
+			Content:        comment.Content,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &comment, nil
+	default:
+		return nil, nil
+	}
+}
+
+/*
+This function converts the rules stored in the database into the rules that are evaluated.
+*/
+func automodRules(rules []database.AutomodRule) []automod.Rule {
+	automodRules := []automod.Rule{}
+
+	for _, rule := range rules {
+		automodRuleData := automodRuleData{
+			Name:        rule.Name,
+			Pattern:     rule.Pattern,
+			Words:       rule.Words,
+			Action:      rule.Action,
+			Message:     rule.Message,
+			Replacement: rule.Replacement,
+		}
+		if rule.MaxLinks.Valid {
+			automodRuleData.MaxLinks = &rule.MaxLinks.Int32
+		}
+		if rule.MaxAccountAgeHours.Valid {
+			automodRuleData.MaxAccountAgeHours = &rule.MaxAccountAgeHours.Int32
+		}
+		automodRules = append(automodRules, automodRuleFromData(automodRuleData))
+	}
+
+	return automodRules
+}
+
+/*
+This function converts the rule from the request into the rule that is evaluated.
+*/
+func automodRuleFromData(automodRuleData automodRuleData) automod.Rule {
+	rule := automod.Rule{
+		Name:        automodRuleData.Name,
+		Pattern:     automodRuleData.Pattern,
+		Words:       automodRuleData.Words,
+		Action:      automodRuleData.Action,
+		Message:     automodRuleData.Message,
+		Replacement: automodRuleData.Replacement,
+	}
+	if automodRuleData.MaxLinks != nil {
+		maxLinks := int(*automodRuleData.MaxLinks)
+		rule.MaxLinks = &maxLinks
+	}
+	if automodRuleData.MaxAccountAgeHours != nil {
+		maxAccountAge := time.Duration(*automodRuleData.MaxAccountAgeHours) * time.Hour
+		rule.MaxAccountAge = &maxAccountAge
+	}
+
+	return rule
+}
+
+/*
+This function checks if the length of the name is between 1 and 50 characters,
+and the message and replacement are at most 255 characters long.
+The words cannot be empty, max_links cannot be negative and max_account_age_hours must be at least 1.
+Lastly, it checks the conditions and the action of the rule.
+*/
+func automodRuleDataValidation(automodRuleData automodRuleData) error {
+	if len(automodRuleData.Name) < 1 || len(automodRuleData.Name) > 50 {
+		return errors.New("name must be between 1 and 50 characters long")
+	}
+
+	if len(automodRuleData.Message) > 255 || len(automodRuleData.Replacement) > 255 {
+		return errors.New("message and replacement must be at most 255 characters long")
+	}
+
+	for _, word := range automodRuleData.Words {
+		if strings.TrimSpace(word) == "" {
+			return errors.New("words cannot be empty")
+		}
+	}
+
+	if automodRuleData.MaxLinks != nil && *automodRuleData.MaxLinks < 0 {
+		return errors.New("max_links cannot be negative")
+	}
+
+	if automodRuleData.MaxAccountAgeHours != nil && *automodRuleData.MaxAccountAgeHours < 1 {
+		return errors.New("max_account_age_hours must be at least 1")
+	}
+
+	return automod.Validate(automodRuleFromData(automodRuleData))
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+/*
+This handler blocks the user based on the 'user_id' path parameter for the user through jwt.
+Users who block each other cannot have direct conversations, and messages from blocked users are hidden.
+*/
+func (connection *DatabaseConnection) CreateUserBlockHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	if id == userID {
+		response.RespondWithError(w, http.StatusBadRequest, "Users cannot block themselves")
+		return
+	}
+
+	_, err = connection.DB.CreateUserBlock(r.Context(), database.CreateUserBlockParams{
+		BlockerID: userID,
+		BlockedID: id,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusNotFound, "The user does not exist")
+		} else if ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The user is already blocked")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add block to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler unblocks the user based on the 'user_id' path parameter for the user through jwt.
+*/
+func (connection *DatabaseConnection) DeleteUserBlockHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, err = connection.DB.DeleteUserBlock(r.Context(), database.DeleteUserBlockParams{
+		BlockerID: userID,
+		BlockedID: id,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The user is not blocked")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete block: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler gets the users blocked by the user through jwt, starting from the latest.
+The response may be a 204 status code (no content).
+*/
+func (connection *DatabaseConnection) GetUserBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	blocks, err := connection.DB.GetUserBlocks(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get blocked users: %v", err))
+		return
+	}
+
+	if len(blocks) == 0 {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatUserBlocks(blocks))
+}
+
+/*
+This function checks if the user has blocked or is blocked by any of the other users,
+returning a 403 status code if so.
+*/
+func (connection *DatabaseConnection) checkNotBlocked(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) (int, error) {
+	blocked, err := connection.DB.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+		UserID:   userID,
+		OtherIds: otherIDs,
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to check blocks: %v", err)
+	}
+
+	if blocked {
+		return http.StatusForbidden, errors.New("cannot message a user who has blocked you or is blocked by you")
+	}
+
+	return http.StatusOK, nil
+}
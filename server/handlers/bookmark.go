@@ -0,0 +1,474 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+const bookmarksCursorSort = "bookmarks"
+
+type bookmarkData struct {
+	Note   string `json:"note"`
+	Folder string `json:"folder"`
+}
+
+type bookmarkUpdate struct {
+	Note   *string `json:"note"`
+	Folder *string `json:"folder"`
+}
+
+/*
+This handler bookmarks the thread based on the 'thread_id' path parameter for the user through jwt.
+The optional note and folder are parsed from the request.
+Each user can only bookmark a thread once.
+*/
+func (connection *DatabaseConnection) CreateThreadBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	bookmarkData, err := getBookmarkData(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	includeHeld, err := connection.canViewAllHeld(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to check held content access: %v", err))
+		return
+	}
+
+	// Held threads cannot be bookmarked by those who cannot see them, as if they do not exist
+	bookmark, err := connection.DB.CreateThreadBookmark(r.Context(), database.CreateThreadBookmarkParams{
+		UserID:      userID,
+		ThreadID:    int32(id),
+		Note:        bookmarkData.Note,
+		Folder:      bookmarkData.Folder,
+		IncludeHeld: includeHeld,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The thread does not exist")
+		} else if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The thread is already bookmarked")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add bookmark to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatBookmark(bookmark))
+}
+
+/*
+This handler bookmarks the comment based on the 'comment_id' path parameter for the user through jwt.
+The optional note and folder are parsed from the request.
+Each user can only bookmark a comment once.
+*/
+func (connection *DatabaseConnection) CreateCommentBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	bookmarkData, err := getBookmarkData(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	includeHeld, err := connection.canViewAllHeld(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to check held content access: %v", err))
+		return
+	}
+
+	// Held comments and comments of held threads cannot be bookmarked by those who cannot see them
+	bookmark, err := connection.DB.CreateCommentBookmark(r.Context(), database.CreateCommentBookmarkParams{
+		UserID:      userID,
+		CommentID:   int32(id),
+		Note:        bookmarkData.Note,
+		Folder:      bookmarkData.Folder,
+		IncludeHeld: includeHeld,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
+		} else if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The comment is already bookmarked")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add bookmark to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatBookmark(bookmark))
+}
+
+/*
+This handler removes the bookmark of the thread based on the 'thread_id' path parameter for the user through jwt.
+*/
+func (connection *DatabaseConnection) DeleteThreadBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, err = connection.DB.DeleteThreadBookmark(r.Context(), database.DeleteThreadBookmarkParams{
+		UserID:   userID,
+		ThreadID: int32(id),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The bookmark does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete bookmark: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler removes the bookmark of the comment based on the 'comment_id' path parameter for the user through jwt.
+*/
+func (connection *DatabaseConnection) DeleteCommentBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, err = connection.DB.DeleteCommentBookmark(r.Context(), database.DeleteCommentBookmarkParams{
+		UserID:    userID,
+		CommentID: sql.NullInt32{Int32: int32(id), Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The bookmark does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete bookmark: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler updates the note and folder of a bookmark based on the 'bookmark_id' path parameter.
+Only the attributes that are given in the request are updated.
+Bookmarks of other users are treated as if they do not exist.
+*/
+func (connection *DatabaseConnection) UpdateBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	bookmarkID := chi.URLParam(r, "bookmark_id")
+	id, err := strconv.Atoi(bookmarkID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid bookmark ID: %v", err))
+		return
+	}
+
+	bookmarkUpdate := bookmarkUpdate{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&bookmarkUpdate)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	params := database.UpdateBookmarkParams{ID: int32(id)}
+	if bookmarkUpdate.Note != nil {
+		params.Note = sql.NullString{String: *bookmarkUpdate.Note, Valid: true}
+	}
+	if bookmarkUpdate.Folder != nil {
+		params.Folder = sql.NullString{String: *bookmarkUpdate.Folder, Valid: true}
+	}
+
+	err = bookmarkDataValidation(bookmarkData{Note: params.Note.String, Folder: params.Folder.String})
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+	params.UserID = userID
+
+	bookmark, err := connection.DB.UpdateBookmark(r.Context(), params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The bookmark does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update bookmark: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatBookmark(bookmark))
+}
+
+/*
+This handler gets the bookmarks of the user through jwt, starting from the newest,
+together with the content of the bookmarked threads and comments.
+It validates the 'folder', 'after', 'before', 'page' and 'limit' query, where 'folder' only includes the bookmarks in the folder.
+The page either starts after or ends before a cursor, or is selected by the page number.
+The cursors of the next and previous pages are included in the header as x-next-cursor and x-prev-cursor,
+and the links to the first, previous, next and last pages are included in the Link header.
+The response may be a 204 status code (no content).
+The total count is included in the header as x-total-count
+*/
+func (connection *DatabaseConnection) GetUserBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	folder := sql.NullString{}
+	if r.URL.Query().Has("folder") {
+		folder = sql.NullString{String: r.URL.Query().Get("folder"), Valid: true}
+	}
+
+	p, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(p, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	after, before, err := getCursors(r, bookmarksCursorSort)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get cursors: %v", err))
+		return
+	}
+
+	var bookmarks []database.GetBookmarksRow
+	if before != nil {
+		var rows []database.GetBookmarksBeforeRow
+		rows, err = connection.DB.GetBookmarksBefore(r.Context(), database.GetBookmarksBeforeParams{
+			UserID:      userID,
+			Folder:      folder,
+			BeforeID:    before.ID,
+			ResultLimit: int32(l + 1),
+		})
+		for _, row := range rows {
+			bookmarks = append(bookmarks, database.GetBookmarksRow(row))
+		}
+		slices.Reverse(bookmarks)
+	} else {
+		afterID := sql.NullInt32{}
+		if after != nil {
+			afterID = sql.NullInt32{Int32: after.ID, Valid: true}
+		}
+		bookmarks, err = connection.DB.GetBookmarks(r.Context(), database.GetBookmarksParams{
+			UserID:       userID,
+			Folder:       folder,
+			AfterID:      afterID,
+			ResultLimit:  int32(l + 1),
+			ResultOffset: int32((p - 1) * l),
+		})
+	}
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get bookmarks: %v", err))
+		return
+	}
+
+	bookmarksCount, err := connection.DB.GetBookmarksCount(r.Context(), database.GetBookmarksCountParams{
+		UserID: userID,
+		Folder: folder,
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get bookmarks count: %v", err))
+		return
+	}
+	w.Header().Set("x-total-count", strconv.Itoa(int(bookmarksCount)))
+
+	start, end, hasPrev, hasNext := trimPage(len(bookmarks), l, p, after, before)
+	bookmarks = bookmarks[start:end]
+	cursors := bookmarkPageCursors(bookmarks, hasPrev, hasNext)
+	setCursorHeaders(w, cursors)
+	setLinkHeader(w, r.URL, cursors, p, l, bookmarksCount)
+
+	if len(bookmarks) == 0 {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatSavedBookmarks(bookmarks))
+}
+
+/*
+This function marks the threads that are bookmarked by the viewer,
+using a single query regardless of the number of threads.
+Nothing is marked if the viewer is not logged in.
+*/
+func (connection *DatabaseConnection) addThreadsBookmarks(ctx context.Context, threads []database.FormattedThread, viewerID uuid.NullUUID) error {
+	if !viewerID.Valid {
+		return nil
+	}
+
+	threadIDs := make([]int32, len(threads))
+	indexes := make(map[int32]int)
+	for i, thread := range threads {
+		threadIDs[i] = thread.ID
+		indexes[thread.ID] = i
+	}
+
+	bookmarkedIDs, err := connection.DB.GetBookmarkedThreads(ctx, database.GetBookmarkedThreadsParams{
+		UserID:    viewerID.UUID,
+		ThreadIds: threadIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get thread bookmarks: %v", err)
+	}
+
+	for _, id := range bookmarkedIDs {
+		threads[indexes[id]].Bookmarked = true
+	}
+
+	return nil
+}
+
+/*
+This function marks the comments that are bookmarked by the viewer,
+using a single query regardless of the number of comments.
+Nothing is marked if the viewer is not logged in.
+*/
+func (connection *DatabaseConnection) addCommentsBookmarks(ctx context.Context, comments []database.FormattedComment, viewerID uuid.NullUUID) error {
+	if !viewerID.Valid {
+		return nil
+	}
+
+	commentIDs := make([]int32, len(comments))
+	indexes := make(map[int32]int)
+	for i, comment := range comments {
+		commentIDs[i] = comment.ID
+		indexes[comment.ID] = i
+	}
+
+	bookmarkedIDs, err := connection.DB.GetBookmarkedComments(ctx, database.GetBookmarkedCommentsParams{
+		UserID:     viewerID.UUID,
+		CommentIds: commentIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get comment bookmarks: %v", err)
+	}
+
+	for _, id := range bookmarkedIDs {
+		comments[indexes[id]].Bookmarked = true
+	}
+
+	return nil
+}
+
+/*
+This function parses the optional note and folder of a bookmark from the request, then validates them.
+An empty body is treated as a bookmark without a note and folder.
+*/
+func getBookmarkData(r *http.Request) (bookmarkData, error) {
+	bookmarkData := bookmarkData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&bookmarkData)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return bookmarkData, fmt.Errorf("Failed to parse from JSON: %v", err)
+	}
+
+	err = bookmarkDataValidation(bookmarkData)
+	if err != nil {
+		return bookmarkData, fmt.Errorf("Invalid input: %v", err)
+	}
+
+	return bookmarkData, nil
+}
+
+/*
+This function checks if the note is at most 1000 characters and the folder is at most 50 characters.
+*/
+func bookmarkDataValidation(bookmarkData bookmarkData) error {
+	if len(bookmarkData.Note) > 1000 {
+		return errors.New("note must be at most 1000 characters long")
+	}
+
+	if len(bookmarkData.Folder) > 50 {
+		return errors.New("folder must be at most 50 characters long")
+	}
+
+	return nil
+}
+
+/*
+This function creates the cursors of the pages around a page of bookmarks,
+using the last bookmark for the next page and the first bookmark for the previous page.
+Bookmarks are ordered by their ID, so the sort key is left empty.
+*/
+func bookmarkPageCursors(bookmarks []database.GetBookmarksRow, hasPrev, hasNext bool) pageCursors {
+	cursors := pageCursors{}
+	if len(bookmarks) == 0 {
+		return cursors
+	}
+
+	if hasNext {
+		cursors.Next = encodeCursor(cursor{Sort: bookmarksCursorSort, ID: bookmarks[len(bookmarks)-1].ID})
+	}
+	if hasPrev {
+		cursors.Prev = encodeCursor(cursor{Sort: bookmarksCursorSort, ID: bookmarks[0].ID})
+	}
+
+	return cursors
+}
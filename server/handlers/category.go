@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+type categoryData struct {
+	Slug         string   `json:"slug"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Position     int32    `json:"position"`
+	ParentID     *int32   `json:"parent_id"`
+	PostRole     string   `json:"post_role"`
+	RequiredTags []string `json:"required_tags"`
+}
+
+type categorySettings struct {
+	Slug         *string  `json:"slug"`
+	Name         *string  `json:"name"`
+	Description  *string  `json:"description"`
+	Position     *int32   `json:"position"`
+	ParentID     *int32   `json:"parent_id"`
+	PostRole     *string  `json:"post_role"`
+	RequiredTags []string `json:"required_tags"`
+}
+
+/*
+This handler gets all the categories, sorted based on their position and then their name.
+Nested categories are included in the same list, and point to their parent with the parent ID.
+*/
+func (connection *DatabaseConnection) GetCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, err := connection.DB.GetCategories(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get categories: %v", err))
+		return
+	}
+
+	if categories == nil {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	formattedCategories, err := connection.formatCategories(r.Context(), categories)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format categories: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedCategories)
+}
+
+/*
+This handler gets a single category based on the 'slug' path parameter.
+*/
+func (connection *DatabaseConnection) GetCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	category, err := connection.DB.GetCategoryBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The category does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get category: %v", err))
+		}
+		return
+	}
+
+	formattedCategories, err := connection.formatCategories(r.Context(), []database.Category{category})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format category: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedCategories[0])
+}
+
+/*
+This handler gets the threads in the category based on the 'slug' path parameter.
+It accepts the same queries as GetThreadsPaginatedHandler, and threads in nested categories are not included.
+*/
+func (connection *DatabaseConnection) GetCategoryThreadsHandler(w http.ResponseWriter, r *http.Request) {
+	category, err := connection.DB.GetCategoryBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The category does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get category: %v", err))
+		}
+		return
+	}
+
+	connection.respondWithThreads(w, r, sql.NullInt32{Int32: category.ID, Valid: true})
+}
+
+/*
+This handler parses the slug, name, description, position, parent ID, post role and required tags from the request.
+The post role is the minimum role needed to create threads in the category,
+and threads must have at least one of the required tags if there are any.
+Required tags that do not exist yet are created.
+Only admins are allowed to create categories.
+*/
+func (connection *DatabaseConnection) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categoryData := categoryData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&categoryData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	if categoryData.PostRole == "" {
+		categoryData.PostRole = middleware.RoleUser
+	}
+	if categoryData.RequiredTags == nil {
+		categoryData.RequiredTags = []string{}
+	}
+
+	err = categoryDataValidation(categoryData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	parentID := sql.NullInt32{}
+	if categoryData.ParentID != nil {
+		parentID = sql.NullInt32{Int32: *categoryData.ParentID, Valid: true}
+	}
+
+	var category database.Category
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		category, err = q.CreateCategory(r.Context(), database.CreateCategoryParams{
+			Slug:        categoryData.Slug,
+			Name:        categoryData.Name,
+			Description: categoryData.Description,
+			Position:    categoryData.Position,
+			ParentID:    parentID,
+			PostRole:    categoryData.PostRole,
+		})
+		if err != nil {
+			return err
+		}
+
+		return setCategoryRequiredTags(r.Context(), q, category.ID, categoryData.RequiredTags)
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusBadRequest, "The parent category does not exist")
+		} else if ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The category already exists")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add category to database: %v", err))
+		}
+		return
+	}
+
+	formattedCategories, err := connection.formatCategories(r.Context(), []database.Category{category})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format category: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, formattedCategories[0])
+}
+
+/*
+This handler updates the category based on the 'slug' path parameter.
+Attributes that are not supplied are left unchanged, and a parent ID of 0 moves the category to the top level.
+The required tags are replaced entirely if they are supplied.
+A category cannot be nested under itself or any of its nested categories.
+Only admins are allowed to update categories.
+*/
+func (connection *DatabaseConnection) UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categorySettings := categorySettings{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&categorySettings)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	category, err := connection.DB.GetCategoryBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The category does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get category: %v", err))
+		}
+		return
+	}
+
+	updateCategoryParams := database.UpdateCategoryParams{
+		ID:          category.ID,
+		Slug:        category.Slug,
+		Name:        category.Name,
+		Description: category.Description,
+		Position:    category.Position,
+		ParentID:    category.ParentID,
+		PostRole:    category.PostRole,
+	}
+	if categorySettings.Slug != nil {
+		updateCategoryParams.Slug = *categorySettings.Slug
+	}
+	if categorySettings.Name != nil {
+		updateCategoryParams.Name = *categorySettings.Name
+	}
+	if categorySettings.Description != nil {
+		updateCategoryParams.Description = *categorySettings.Description
+	}
+	if categorySettings.Position != nil {
+		updateCategoryParams.Position = *categorySettings.Position
+	}
+	if categorySettings.ParentID != nil {
+		updateCategoryParams.ParentID = sql.NullInt32{Int32: *categorySettings.ParentID, Valid: *categorySettings.ParentID != 0}
+	}
+	if categorySettings.PostRole != nil {
+		updateCategoryParams.PostRole = *categorySettings.PostRole
+	}
+
+	requiredTags := categorySettings.RequiredTags
+	if requiredTags == nil {
+		requiredTags = []string{}
+	}
+
+	err = categoryDataValidation(categoryData{
+		Slug:         updateCategoryParams.Slug,
+		Name:         updateCategoryParams.Name,
+		Description:  updateCategoryParams.Description,
+		PostRole:     updateCategoryParams.PostRole,
+		RequiredTags: requiredTags,
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	if updateCategoryParams.ParentID.Valid {
+		statusCode, err = connection.checkCategoryParent(r.Context(), category.ID, updateCategoryParams.ParentID.Int32)
+		if err != nil {
+			response.RespondWithError(w, statusCode, fmt.Sprintf("Failed category parent check: %v", err))
+			return
+		}
+	}
+
+	var updatedCategory database.Category
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		updatedCategory, err = q.UpdateCategory(r.Context(), updateCategoryParams)
+		if err != nil {
+			return err
+		}
+
+		if categorySettings.RequiredTags == nil {
+			return nil
+		}
+
+		err = q.DeleteCategoryRequiredTags(r.Context(), category.ID)
+		if err != nil {
+			return err
+		}
+
+		return setCategoryRequiredTags(r.Context(), q, category.ID, categorySettings.RequiredTags)
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The category already exists")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update category: %v", err))
+		}
+		return
+	}
+
+	formattedCategories, err := connection.formatCategories(r.Context(), []database.Category{updatedCategory})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format category: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedCategories[0])
+}
+
+/*
+This handler deletes the category based on the 'slug' path parameter.
+Categories that still have threads or nested categories cannot be deleted.
+Only admins are allowed to delete categories.
+*/
+func (connection *DatabaseConnection) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	category, err := connection.DB.GetCategoryBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The category does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get category: %v", err))
+		}
+		return
+	}
+
+	_, err = connection.DB.DeleteCategory(r.Context(), category.ID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusConflict, "The category still has threads or nested categories")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete category: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This function checks if the category can be posted in by the role,
+and that the resolved tags contain at least one of the required tags of the category, if there are any.
+*/
+func (connection *DatabaseConnection) checkCategoryRules(ctx context.Context, category database.Category, role string, tags []database.Tag) (int, error) {
+	if !middleware.RoleAtLeast(role, category.PostRole) {
+		return http.StatusForbidden, fmt.Errorf("only %ss can create threads in this category", category.PostRole)
+	}
+
+	requiredTags, err := connection.DB.GetCategoriesRequiredTags(ctx, []int32{category.ID})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to get required tags: %v", err)
+	}
+	if len(requiredTags) == 0 {
+		return http.StatusOK, nil
+	}
+
+	names := []string{}
+	for _, requiredTag := range requiredTags {
+		if slices.ContainsFunc(tags, func(tag database.Tag) bool { return tag.ID == requiredTag.ID }) {
+			return http.StatusOK, nil
+		}
+		names = append(names, requiredTag.Name)
+	}
+
+	return http.StatusBadRequest, fmt.Errorf("threads in this category must have at least one of these tags: %s", strings.Join(names, ", "))
+}
+
+/*
+This function checks if the parent exists, and that it is not the category itself or one of its nested categories,
+by following the parents upwards from the new parent.
+*/
+func (connection *DatabaseConnection) checkCategoryParent(ctx context.Context, categoryID, parentID int32) (int, error) {
+	categories, err := connection.DB.GetCategories(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to get categories: %v", err)
+	}
+
+	parents := make(map[int32]sql.NullInt32)
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	if _, ok := parents[parentID]; !ok {
+		return http.StatusBadRequest, errors.New("the parent category does not exist")
+	}
+
+	id := sql.NullInt32{Int32: parentID, Valid: true}
+	for id.Valid {
+		if id.Int32 == categoryID {
+			return http.StatusBadRequest, errors.New("a category cannot be nested under itself")
+		}
+		id = parents[id.Int32]
+	}
+
+	return http.StatusOK, nil
+}
+
+/*
+This function sets the required tags of the category, resolving them through their aliases
+and creating the tags that do not exist yet.
+*/
+func setCategoryRequiredTags(ctx context.Context, q *database.Queries, categoryID int32, names []string) error {
+	for _, name := range names {
+		tag, err := q.ResolveTag(ctx, name)
+		if err == sql.ErrNoRows {
+			tag, err = q.UpsertTag(ctx, name)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = q.CreateCategoryRequiredTag(ctx, database.CreateCategoryRequiredTagParams{
+			CategoryID: categoryID,
+			TagID:      tag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+This function formats the categories, then fills in their required tags,
+using a single query regardless of the number of categories.
+*/
+func (connection *DatabaseConnection) formatCategories(ctx context.Context, categories []database.Category) ([]database.FormattedCategory, error) {
+	formattedCategories := database.FormatCategories(categories)
+
+	categoryIDs := make([]int32, len(categories))
+	indexes := make(map[int32]int)
+	for i, category := range categories {
+		categoryIDs[i] = category.ID
+		indexes[category.ID] = i
+	}
+
+	requiredTags, err := connection.DB.GetCategoriesRequiredTags(ctx, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get required tags: %v", err)
+	}
+
+	for _, requiredTag := range requiredTags {
+		i := indexes[requiredTag.CategoryID]
+		formattedCategories[i].RequiredTags = append(formattedCategories[i].RequiredTags, requiredTag.Name)
+	}
+
+	return formattedCategories, nil
+}
+
+/*
+This function checks if the slug is between 1 and 50 characters long and only has
+lowercase letters, digits and single dashes in between, the name is between 1 and 100 characters,
+the description is at most 500 characters and the post role is valid.
+Lastly, it checks that there are at most 10 required tags, each between 1 and 35 characters.
+*/
+func categoryDataValidation(categoryData categoryData) error {
+	if len(categoryData.Slug) < 1 || len(categoryData.Slug) > 50 {
+		return errors.New("slug must be between 1 and 50 characters long")
+	}
+
+	if matching, _ := regexp.MatchString("^[a-z0-9]+(-[a-z0-9]+)*$", categoryData.Slug); !matching {
+		return errors.New("slug can only contain lowercase letters, digits and dashes between them")
+	}
+
+	if len(categoryData.Name) < 1 || len(categoryData.Name) > 100 {
+		return errors.New("name must be between 1 and 100 characters long")
+	}
+
+	if len(categoryData.Description) > 500 {
+		return errors.New("description must be at most 500 characters long")
+	}
+
+	if categoryData.PostRole != middleware.RoleUser && categoryData.PostRole != middleware.RoleModerator && categoryData.PostRole != middleware.RoleAdmin {
+		return errors.New("post_role must be one of user, moderator, admin")
+	}
+
+	if len(categoryData.RequiredTags) > 10 {
+		return errors.New("number of required tags must be at most 10")
+	}
+
+	for _, tag := range categoryData.RequiredTags {
+		if len(tag) < 1 || len(tag) > 35 {
+			return errors.New("all required tags must be between 1 and 35 characters long")
+		}
+	}
+
+	return nil
+}
@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/wangyuanchi/shibespace/server/internal/database"
 	"github.com/wangyuanchi/shibespace/server/middleware"
@@ -18,6 +22,7 @@ import (
 type commentData struct {
 	Content  string `json:"content"`
 	ThreadID int32  `json:"thread_id"`
+	ParentID int32  `json:"parent_id"`
 }
 
 type commentContent struct {
@@ -25,11 +30,20 @@ type commentContent struct {
 }
 
 /*
-This handler parses the content and thread ID from the request.
+This handler parses the content, thread ID and optional parent comment ID from the request.
 It conducts input validation, then it gets the creator through jwt.
+The parent comment, if given, must belong to the same thread.
+The content is checked against the auto-moderation rules, which can reject it, replace text in it,
+report the comment, or hold it for review, in which case it is only added to the thread once it is released.
+The content is rendered from Markdown into sanitized HTML, which is stored together with it,
+showing the authors of the comments that it quotes.
 The entire row for the comment is returned, which additionally includes the
 ID of the comment and the timestamp it was created and last updated.
-An error can be thrown if the thread does not actually exist.
+The activity of the thread is bumped, the creator is subscribed to the thread if they chose to be,
+and the notifications are created in the same transaction.
+The subscribers of the thread are notified in the background after the comment is created.
+An error can be thrown if the thread does not actually exist, or if it is locked or archived.
+Replies to held comments are treated as if the parent does not exist, unless the user can view it.
 */
 func (connection *DatabaseConnection) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 	commentData := commentData{}
@@ -54,28 +68,278 @@ func (connection *DatabaseConnection) CreateCommentHandler(w http.ResponseWriter
 		return
 	}
 
-	comment, err := connection.DB.CreateComment(r.Context(), database.CreateCommentParams{
-		Content:   commentData.Content,
-		ThreadID:  commentData.ThreadID,
-		CreatorID: userID,
+	statusCode, err = connection.checkThreadWritable(r.Context(), commentData.ThreadID, userID, true)
+	if err != nil {
+		// The thread is part of the request body rather than the path
+		if statusCode == http.StatusNotFound {
+			statusCode = http.StatusBadRequest
+		}
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	parentID := sql.NullInt32{}
+	parentAuthorID := uuid.NullUUID{}
+	if commentData.ParentID != 0 {
+		parent, err := connection.DB.GetCommentThreadAndCreator(r.Context(), commentData.ParentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				response.RespondWithError(w, http.StatusBadRequest, "The parent comment does not exist")
+			} else {
+				response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get parent comment: %v", err))
+			}
+			return
+		}
+		if parent.ThreadID != commentData.ThreadID {
+			response.RespondWithError(w, http.StatusBadRequest, "The parent comment does not belong to the thread")
+			return
+		}
+		if parent.Held {
+			statusCode, err := connection.heldCheck(r.Context(), userID, parent.CreatorID, "parent comment")
+			if err != nil {
+				response.RespondWithError(w, statusCode, fmt.Sprintf("Failed parent comment check: %v", err))
+				return
+			}
+		}
+
+		parentID = sql.NullInt32{Int32: commentData.ParentID, Valid: true}
+		parentAuthorID = uuid.NullUUID{UUID: parent.CreatorID, Valid: true}
+	}
+
+	moderation, err := connection.moderateContent(r.Context(), userID, "", commentData.Content)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to moderate content: %v", err))
+		return
+	}
+	if moderation.Rejected {
+		respondWithRejection(w, moderation)
+		return
+	}
+
+	// Replacements can leave the content invalid, in which case it is rejected as well
+	err = commentContentValidation(commentContent{
+		Content: moderation.Body,
 	})
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("The content was rejected: %v", err))
+		return
+	}
+
+	contentHTML, err := connection.renderContent(r.Context(), moderation.Body)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render content: %v", err))
+		return
+	}
+
+	var comment database.Comment
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err = lockThreadWritable(r.Context(), q, commentData.ThreadID, true)
+		if err != nil {
+			return err
+		}
+
+		comment, err = q.CreateComment(r.Context(), database.CreateCommentParams{
+			Content:     moderation.Body,
+			ContentHTML: contentHTML,
+			ThreadID:    commentData.ThreadID,
+			CreatorID:   userID,
+			ParentID:    parentID,
+			Held:        moderation.Held,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !comment.Held {
+			err = q.AddThreadComment(r.Context(), database.AddThreadCommentParams{
+				CommenterID:        comment.CreatorID,
+				CommentedTimestamp: comment.CreatedTimestamp,
+				ID:                 comment.ThreadID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = linkContentAttachments(r.Context(), q, comment.ThreadID, sql.NullInt32{Int32: comment.ID, Valid: true}, comment.Content)
+		if err != nil {
+			return err
+		}
+
+		err = q.AutoSubscribeThread(r.Context(), database.AutoSubscribeThreadParams{
+			ThreadID: comment.ThreadID,
+			UserID:   userID,
+			Created:  false,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = reportModeratedContent(r.Context(), q, reportTarget{
+			TargetType:    "comment",
+			ThreadID:      sql.NullInt32{Int32: comment.ThreadID, Valid: true},
+			CommentID:     sql.NullInt32{Int32: comment.ID, Valid: true},
+			TargetUserID:  userID,
+			TargetContent: comment.Content,
+		}, moderation)
+		if err != nil {
+			return err
+		}
+
+		if comment.Held {
+			return nil
+		}
+
+		return createContentNotifications(r.Context(), q, contentNotifications{
+			ActorID:        userID,
+			ThreadID:       comment.ThreadID,
+			CommentID:      sql.NullInt32{Int32: comment.ID, Valid: true},
+			ParentAuthorID: parentAuthorID,
+			Content:        comment.Content,
+		})
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); (ok && pqErr.Code == "23503") || err == sql.ErrNoRows {
 			response.RespondWithError(w, http.StatusBadRequest, "The thread does not exist")
+		} else if isThreadStateError(err) {
+			response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("Failed thread state check: %v", err))
 		} else {
 			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add comment to database: %v", err))
 		}
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusCreated, database.FormattedComment(comment))
+	if !comment.Held {
+		connection.Notifier.Enqueue(comment)
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatComment(comment))
 }
 
 /*
-This handler updates a comment's content (and also updated_timestamp).
+This handler gets a single comment based on the 'comment_id' path parameter.
+Held comments, and comments in held threads, can only be seen by their creator, moderators and admins.
+Its reactions are included in the same way as GetCommentsPaginatedHandler,
+marking those added by and whether it was bookmarked by the viewer if logged in.
+*/
+func (connection *DatabaseConnection) GetCommentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	comment, err := connection.DB.GetComment(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment: %v", err))
+		}
+		return
+	}
+
+	thread, err := connection.DB.GetThread(r.Context(), comment.ThreadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get thread: %v", err))
+		return
+	}
+
+	viewerID, statusCode, err := middleware.JWTExtractOptionalUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	if thread.Held || comment.Held {
+		creatorID := thread.CreatorID
+		if comment.Held {
+			creatorID = comment.CreatorID
+		}
+
+		visible, err := connection.canViewHeld(r.Context(), viewerID, creatorID)
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user role: %v", err))
+			return
+		}
+		if !visible {
+			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
+			return
+		}
+	}
+
+	formattedComments, err := connection.formatComments(r.Context(), []database.Comment{comment}, thread.AcceptedCommentID, viewerID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format comment: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedComments[0])
+}
+
+/*
+This handler gets the page of comments that contains the comment based on the 'comment_id' path parameter,
+so that links to a comment can open the thread at the right page.
+Only the 'limit' query is used, and the page is found in the same order as GetCommentsPaginatedHandler,
+then responded with in the same way as the page of the thread.
+The page number is included in the header as x-page.
+*/
+func (connection *DatabaseConnection) GetCommentContextHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	_, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(1, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	position, err := connection.DB.GetCommentListPosition(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment position: %v", err))
+		}
+		return
+	}
+	p := int(position.Position-1)/l + 1
+
+	thread, statusCode, err := connection.getVisibleThread(r, position.ThreadID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to get or validate thread: %v", err))
+		return
+	}
+
+	// The links to the other pages point to the comments of the thread rather than the context of the comment
+	query := url.Values{}
+	query.Set("thread_id", strconv.Itoa(int(thread.ID)))
+	query.Set("limit", strconv.Itoa(l))
+	pageURL := &url.URL{Path: path.Dir(path.Dir(r.URL.Path)), RawQuery: query.Encode()}
+
+	w.Header().Set("x-page", strconv.Itoa(p))
+	connection.respondWithCommentsPage(w, r, thread, p, l, nil, nil, pageURL)
+}
+
+/*
+This handler updates a comment's content (and also content_html and updated_timestamp).
 It gets the comment based on the 'comment_id' path parameter,
 then parses and conducts input validation on the content.
-Only the creator of the comment is allowed to do update the content.
+Only the creator of the comment is allowed to do update the content,
+and comments in archived threads cannot be updated.
+The content is checked against the auto-moderation rules in the same way as CreateCommentHandler.
+Newly mentioned users are notified in the same transaction, unless the comment is held.
 */
 func (connection *DatabaseConnection) UpdateCommentContentHandler(w http.ResponseWriter, r *http.Request) {
 	commentID := chi.URLParam(r, "comment_id")
@@ -85,12 +349,12 @@ func (connection *DatabaseConnection) UpdateCommentContentHandler(w http.Respons
 		return
 	}
 
-	creatorID, err := connection.DB.GetCommentCreatorID(r.Context(), int32(id))
+	comment, err := connection.DB.GetCommentThreadAndCreator(r.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
 		} else {
-			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment creator ID: %v", err))
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment: %v", err))
 		}
 		return
 	}
@@ -109,18 +373,101 @@ func (connection *DatabaseConnection) UpdateCommentContentHandler(w http.Respons
 		return
 	}
 
-	_, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
+	userID, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, comment.CreatorID.String())
 	if err != nil {
 		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed jwt matching check: %v", err))
 		return
 	}
 
-	updatedComment, err := connection.DB.UpdateCommentContent(r.Context(), database.UpdateCommentContentParams{
-		ID:      int32(id),
-		Content: commentContent.Content,
+	statusCode, err = connection.checkCommentWritable(r.Context(), int32(id), userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	moderation, err := connection.moderateContent(r.Context(), comment.CreatorID, "", commentContent.Content)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to moderate content: %v", err))
+		return
+	}
+	if moderation.Rejected {
+		respondWithRejection(w, moderation)
+		return
+	}
+
+	// Replacements can leave the content invalid, in which case it is rejected as well
+	moderatedContent := commentContent
+	moderatedContent.Content = moderation.Body
+	err = commentContentValidation(moderatedContent)
+	if err != nil {
+		response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("The content was rejected: %v", err))
+		return
+	}
+
+	contentHTML, err := connection.renderContent(r.Context(), moderation.Body)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render content: %v", err))
+		return
+	}
+
+	var updatedComment database.UpdateCommentContentRow
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err = lockThreadWritable(r.Context(), q, comment.ThreadID, false)
+		if err != nil {
+			return err
+		}
+
+		updatedComment, err = q.UpdateCommentContent(r.Context(), database.UpdateCommentContentParams{
+			ID:          int32(id),
+			Content:     moderation.Body,
+			ContentHTML: contentHTML,
+			Held:        moderation.Held,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = linkContentAttachments(r.Context(), q, comment.ThreadID, sql.NullInt32{Int32: int32(id), Valid: true}, updatedComment.Content)
+		if err != nil {
+			return err
+		}
+
+		if moderation.Held {
+			// The comment is taken out of the thread until it is released
+			err = q.RefreshThreadComments(r.Context(), comment.ThreadID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = reportModeratedContent(r.Context(), q, reportTarget{
+			TargetType:    "comment",
+			ThreadID:      sql.NullInt32{Int32: comment.ThreadID, Valid: true},
+			CommentID:     sql.NullInt32{Int32: int32(id), Valid: true},
+			TargetUserID:  comment.CreatorID,
+			TargetContent: updatedComment.Content,
+		}, moderation)
+		if err != nil {
+			return err
+		}
+
+		if updatedComment.Held {
+			return nil
+		}
+
+		return createContentNotifications(r.Context(), q, contentNotifications{
+			ActorID:   comment.CreatorID,
+			ThreadID:  comment.ThreadID,
+			CommentID: sql.NullInt32{Int32: int32(id), Valid: true},
+			Content:   updatedComment.Content,
+		})
 	})
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update comment content: %v", err))
+		if isThreadStateError(err) {
+			response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("Failed thread state check: %v", err))
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update comment content: %v", err))
+		}
 		return
 	}
 
@@ -129,7 +476,9 @@ func (connection *DatabaseConnection) UpdateCommentContentHandler(w http.Respons
 
 /*
 This handler deletes a comment based on the 'comment_id' path parameter.
-Only the creator of the comment is allowed to do delete the comment.
+Only the creator of the comment is allowed to do delete the comment,
+and comments in archived threads cannot be deleted since the thread is read-only.
+The activity of the thread is recalculated in the same transaction.
 */
 func (connection *DatabaseConnection) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
 	commentID := chi.URLParam(r, "comment_id")
@@ -139,31 +488,76 @@ func (connection *DatabaseConnection) DeleteCommentHandler(w http.ResponseWriter
 		return
 	}
 
-	creatorID, err := connection.DB.GetCommentCreatorID(r.Context(), int32(id))
+	comment, err := connection.DB.GetCommentThreadAndCreator(r.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
 		} else {
-			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment creator ID: %v", err))
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment: %v", err))
 		}
 		return
 	}
 
-	_, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
+	userID, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, comment.CreatorID.String())
 	if err != nil {
 		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed jwt matching check: %v", err))
 		return
 	}
 
-	_, err = connection.DB.DeleteComment(r.Context(), int32(id))
+	statusCode, err = connection.checkCommentWritable(r.Context(), int32(id), userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err := lockThreadWritable(r.Context(), q, comment.ThreadID, false)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteComment(r.Context(), int32(id))
+		if err != nil {
+			return err
+		}
+
+		return q.RefreshThreadComments(r.Context(), comment.ThreadID)
+	})
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete comment: %v", err))
+		if isThreadStateError(err) {
+			response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("Failed thread state check: %v", err))
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete comment: %v", err))
+		}
 		return
 	}
 
 	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
 }
 
+/*
+This function formats the comments, then fills in their reactions and whether the viewer bookmarked them,
+marking the comment with the accepted ID as the accepted answer.
+*/
+func (connection *DatabaseConnection) formatComments(ctx context.Context, comments []database.Comment, acceptedID sql.NullInt32, viewerID uuid.NullUUID) ([]database.FormattedComment, error) {
+	formattedComments := database.FormatComments(comments)
+	for i := range formattedComments {
+		formattedComments[i].Accepted = acceptedID.Valid && formattedComments[i].ID == acceptedID.Int32
+	}
+
+	err := connection.addCommentsReactions(ctx, formattedComments, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.addCommentsBookmarks(ctx, formattedComments, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return formattedComments, nil
+}
+
 /*
 This function checks if the length of the content is at least 1 character.
 */
@@ -1,11 +1,48 @@
 package handlers
 
-import "github.com/wangyuanchi/shibespace/server/internal/database"
+import (
+	"context"
+	"database/sql"
+
+	"github.com/wangyuanchi/shibespace/server/automod"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/jobs"
+	"github.com/wangyuanchi/shibespace/server/storage"
+)
 
 /*
 	Wraps a database connection so that this can be used as a
 	pointer receiver, allowing handlers to have the database connection.
+	The underlying database handle is kept for running transactions,
+	and the notifier is used to notify the subscribers of threads in the background.
+	The uploaded files are kept in the blob store, and the thumbnails of images are generated in the background.
+	The compiled auto-moderation rules are cached so that they are not loaded for every post.
 */
 type DatabaseConnection struct {
-	DB *database.Queries
-}
\ No newline at end of file
+	DB         *database.Queries
+	Conn       *sql.DB
+	Notifier   *jobs.SubscriptionNotifier
+	Store      storage.BlobStore
+	Thumbnails *jobs.ThumbnailGenerator
+	Rules      *automod.Cache
+}
+
+/*
+	This function runs the given function with queries bound to a new transaction.
+	The transaction is committed if the function succeeds and rolled back otherwise.
+	The error from the function is returned as is, so that it can still be inspected.
+*/
+func (connection *DatabaseConnection) withTx(ctx context.Context, fn func(*database.Queries) error) error {
+	tx, err := connection.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(connection.DB.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
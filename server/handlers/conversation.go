@@ -0,0 +1,656 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/markdown"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+const messagesCursorSort = "messages"
+
+// The maximum number of participants in a conversation, including the creator
+const maxParticipants = 10
+
+type conversationData struct {
+	ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	Title          string      `json:"title"`
+}
+
+type messageContent struct {
+	Content string `json:"content"`
+}
+
+type conversationReadData struct {
+	MessageID int32 `json:"message_id"`
+}
+
+/*
+This handler parses the participant IDs and the optional title from the request, then gets the creator through jwt.
+A conversation with a single other participant is a direct conversation, and the existing
+direct conversation between the two users is returned instead if there is one.
+Conversations with more participants are group conversations, which can have a title.
+Conversations cannot be started with users who have blocked or are blocked by the creator.
+*/
+func (connection *DatabaseConnection) CreateConversationHandler(w http.ResponseWriter, r *http.Request) {
+	conversationData := conversationData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&conversationData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = conversationDataValidation(conversationData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	if slices.Contains(conversationData.ParticipantIDs, userID) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: participant_ids cannot include the creator")
+		return
+	}
+
+	statusCode, err = connection.checkNotBlocked(r.Context(), userID, conversationData.ParticipantIDs)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed block check: %v", err))
+		return
+	}
+
+	isGroup := len(conversationData.ParticipantIDs) > 1
+	created := true
+	var conversationID int32
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		if isGroup {
+			conversation, err := q.CreateConversation(r.Context(), database.CreateConversationParams{
+				CreatorID: uuid.NullUUID{UUID: userID, Valid: true},
+				IsGroup:   true,
+				Title:     conversationData.Title,
+			})
+			if err != nil {
+				return err
+			}
+			conversationID = conversation.ID
+		} else {
+			conversation, err := q.CreateDirectConversation(r.Context(), database.CreateDirectConversationParams{
+				CreatorID: userID,
+				OtherID:   conversationData.ParticipantIDs[0],
+			})
+			if err != nil {
+				return err
+			}
+			conversationID = conversation.ID
+			created = conversation.Created
+			if !created {
+				return nil
+			}
+		}
+
+		return q.AddConversationParticipants(r.Context(), database.AddConversationParticipantsParams{
+			ConversationID: conversationID,
+			UserIds:        append([]uuid.UUID{userID}, conversationData.ParticipantIDs...),
+		})
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusBadRequest, "A participant does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add conversation to database: %v", err))
+		}
+		return
+	}
+
+	statusCode = http.StatusOK
+	if created {
+		statusCode = http.StatusCreated
+	}
+
+	connection.respondWithConversation(w, r, statusCode, conversationID, userID)
+}
+
+/*
+This handler gets the conversations of the user through jwt, starting from the one with the latest message.
+It validates the 'page' and 'limit' query.
+The participants and the number of unread messages of every conversation are included.
+The response may be a 204 status code (no content).
+The total count is included in the header as x-total-count
+*/
+func (connection *DatabaseConnection) GetConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	p, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(p, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	conversations, err := connection.DB.GetUserConversations(r.Context(), database.GetUserConversationsParams{
+		UserID:       userID,
+		ResultLimit:  int32(l),
+		ResultOffset: int32((p - 1) * l),
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get conversations: %v", err))
+		return
+	}
+
+	conversationsCount, err := connection.DB.GetUserConversationsCount(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get conversations count: %v", err))
+		return
+	}
+	w.Header().Set("x-total-count", strconv.Itoa(int(conversationsCount)))
+
+	if len(conversations) == 0 {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	formattedConversations := database.FormatConversations(conversations)
+	err = connection.addConversationsParticipants(r.Context(), formattedConversations)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add participants: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedConversations)
+}
+
+/*
+This handler gets a conversation based on the 'conversation_id' path parameter.
+Only the participants who have not left the conversation can get it.
+The last read message of each participant is included as the read receipts.
+*/
+func (connection *DatabaseConnection) GetConversationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := getConversationID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	connection.respondWithConversation(w, r, http.StatusOK, id, userID)
+}
+
+/*
+This handler gets the total number of unread messages in the conversations of the user through jwt.
+*/
+func (connection *DatabaseConnection) GetUnreadMessagesCountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	count, err := connection.DB.GetUnreadMessagesCount(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get unread messages count: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, unreadCount{Count: count})
+}
+
+/*
+This handler removes the user through jwt from the conversation based on the 'conversation_id' path parameter.
+The user can no longer read or send messages in the conversation, but their previous messages are kept.
+A new direct conversation can be started with the other user afterwards.
+*/
+func (connection *DatabaseConnection) LeaveConversationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := getConversationID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		_, err := q.LeaveConversation(r.Context(), database.LeaveConversationParams{
+			ConversationID: id,
+			UserID:         userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.ClearDirectConversation(r.Context(), id)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The conversation does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to leave conversation: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler parses the content of a message from the request and sends it in the conversation
+based on the 'conversation_id' path parameter, as the user through jwt.
+There must be other participants left in the conversation,
+and messages cannot be sent if the user has blocked or is blocked by any of them.
+The content is rendered from Markdown into sanitized HTML, which is stored together with it.
+The conversation is marked as read up to the message for the sender in the same transaction.
+*/
+func (connection *DatabaseConnection) CreateMessageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := getConversationID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	messageContent := messageContent{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&messageContent)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = commentContentValidation(commentContent(messageContent))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	statusCode, err = connection.checkConversationWritable(r.Context(), id, userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed conversation check: %v", err))
+		return
+	}
+
+	contentHTML, err := markdown.Render(messageContent.Content)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render content: %v", err))
+		return
+	}
+
+	var message database.Message
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		message, err = q.CreateMessage(r.Context(), database.CreateMessageParams{
+			ConversationID: id,
+			SenderID:       userID,
+			Content:        messageContent.Content,
+			ContentHTML:    contentHTML,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.UpdateConversationLastMessage(r.Context(), database.UpdateConversationLastMessageParams{
+			ID:                   id,
+			LastMessageTimestamp: message.CreatedTimestamp,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+			ConversationID: id,
+			UserID:         userID,
+			MessageID:      message.ID,
+		})
+		return err
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add message to database: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormattedMessage(message))
+}
+
+/*
+This handler gets the messages in the conversation based on the 'conversation_id' path parameter, starting from the newest.
+It validates the 'after', 'before' and 'limit' query, and messages from users blocked by the viewer are left out.
+The cursors of the pages around the current page are returned in the x-next-cursor and x-prev-cursor headers.
+The response may be a 204 status code (no content).
+*/
+func (connection *DatabaseConnection) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := getConversationID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(1, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: %v", err))
+		return
+	}
+
+	after, before, err := getCursors(r, messagesCursorSort)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get cursors: %v", err))
+		return
+	}
+
+	_, err = connection.getConversation(r.Context(), id, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The conversation does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get conversation: %v", err))
+		}
+		return
+	}
+
+	var messages []database.Message
+	if before != nil {
+		messages, err = connection.DB.GetMessagesBefore(r.Context(), database.GetMessagesBeforeParams{
+			ConversationID: id,
+			BeforeID:       before.ID,
+			ViewerID:       userID,
+			ResultLimit:    int32(l + 1),
+		})
+		slices.Reverse(messages)
+	} else {
+		afterID := sql.NullInt32{}
+		if after != nil {
+			afterID = sql.NullInt32{Int32: after.ID, Valid: true}
+		}
+		messages, err = connection.DB.GetMessages(r.Context(), database.GetMessagesParams{
+			ConversationID: id,
+			AfterID:        afterID,
+			ViewerID:       userID,
+			ResultLimit:    int32(l + 1),
+		})
+	}
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get messages: %v", err))
+		return
+	}
+
+	start, end, hasPrev, hasNext := trimPage(len(messages), l, 1, after, before)
+	messages = messages[start:end]
+	setCursorHeaders(w, messagePageCursors(messages, hasPrev, hasNext))
+
+	if len(messages) == 0 {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatMessages(messages))
+}
+
+/*
+This handler marks the conversation based on the 'conversation_id' path parameter as read for the user through jwt,
+up to the optional message ID from the request, or up to the latest message if it is not given.
+The read marker only moves forward, and is shown to the other participants as a read receipt.
+*/
+func (connection *DatabaseConnection) MarkConversationReadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := getConversationID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conversationReadData := conversationReadData{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&conversationReadData)
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	messageID := conversationReadData.MessageID
+	if messageID != 0 {
+		conversationID, err := connection.DB.GetMessageConversationID(r.Context(), messageID)
+		if err != nil && err != sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get message: %v", err))
+			return
+		}
+		if err == sql.ErrNoRows || conversationID != id {
+			response.RespondWithError(w, http.StatusBadRequest, "The message does not belong to the conversation")
+			return
+		}
+	} else {
+		messageID, err = connection.DB.GetLatestMessageID(r.Context(), id)
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get latest message: %v", err))
+			return
+		}
+	}
+
+	_, err = connection.DB.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: id,
+		UserID:         userID,
+		MessageID:      messageID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The conversation does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to mark conversation as read: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This function responds with the conversation for the user together with its participants,
+or a 404 status code if the user is not a participant of the conversation.
+*/
+func (connection *DatabaseConnection) respondWithConversation(w http.ResponseWriter, r *http.Request, statusCode int, conversationID int32, userID uuid.UUID) {
+	conversation, err := connection.getConversation(r.Context(), conversationID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The conversation does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get conversation: %v", err))
+		}
+		return
+	}
+
+	formattedConversations := database.FormatConversations([]database.GetUserConversationsRow{conversation})
+	err = connection.addConversationsParticipants(r.Context(), formattedConversations)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add participants: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, statusCode, formattedConversations[0])
+}
+
+/*
+This function gets the conversation if the user is a participant who has not left it,
+returning sql.ErrNoRows otherwise.
+*/
+func (connection *DatabaseConnection) getConversation(ctx context.Context, conversationID int32, userID uuid.UUID) (database.GetUserConversationsRow, error) {
+	conversation, err := connection.DB.GetConversation(ctx, database.GetConversationParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+
+	return database.GetUserConversationsRow(conversation), err
+}
+
+/*
+This function checks if the user can send messages in the conversation.
+The user must be a participant who has not left, there must be other participants left,
+and the user cannot have blocked or be blocked by any of the other participants who have not left.
+*/
+func (connection *DatabaseConnection) checkConversationWritable(ctx context.Context, conversationID int32, userID uuid.UUID) (int, error) {
+	_, err := connection.getConversation(ctx, conversationID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.New("the conversation does not exist")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("failed to get conversation: %v", err)
+	}
+
+	participants, err := connection.DB.GetConversationsParticipants(ctx, []int32{conversationID})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to get participants: %v", err)
+	}
+
+	otherIDs := []uuid.UUID{}
+	for _, participant := range participants {
+		if participant.UserID != userID && !participant.LeftTimestamp.Valid {
+			otherIDs = append(otherIDs, participant.UserID)
+		}
+	}
+
+	if len(otherIDs) == 0 {
+		return http.StatusForbidden, errors.New("everyone else has left the conversation")
+	}
+
+	return connection.checkNotBlocked(ctx, userID, otherIDs)
+}
+
+/*
+This function fills in the participants of the given conversations,
+using a single query regardless of the number of conversations.
+*/
+func (connection *DatabaseConnection) addConversationsParticipants(ctx context.Context, conversations []database.FormattedConversation) error {
+	conversationIDs := make([]int32, len(conversations))
+	indexes := make(map[int32]int)
+	for i, conversation := range conversations {
+		conversationIDs[i] = conversation.ID
+		indexes[conversation.ID] = i
+	}
+
+	participants, err := connection.DB.GetConversationsParticipants(ctx, conversationIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation participants: %v", err)
+	}
+
+	for _, participant := range participants {
+		i := indexes[participant.ConversationID]
+		conversations[i].Participants = append(conversations[i].Participants, database.FormatParticipant(participant))
+	}
+
+	return nil
+}
+
+/*
+This function gets the 'conversation_id' path parameter and converts it into an integer.
+*/
+func getConversationID(r *http.Request) (int32, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "conversation_id"))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid conversation ID: %v", err)
+	}
+
+	return int32(id), nil
+}
+
+/*
+This function checks that there are between 1 and 9 unique participants other than the creator,
+and that the title is at most 100 characters and only given for group conversations.
+*/
+func conversationDataValidation(conversationData conversationData) error {
+	participantIDs := conversationData.ParticipantIDs
+
+	if len(participantIDs) < 1 || len(participantIDs) > maxParticipants-1 {
+		return fmt.Errorf("participant_ids must have between 1 and %d participants", maxParticipants-1)
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, participantID := range participantIDs {
+		if seen[participantID] {
+			return errors.New("participant_ids must be unique")
+		}
+		seen[participantID] = true
+	}
+
+	if len(conversationData.Title) > 100 {
+		return errors.New("title must be at most 100 characters long")
+	}
+
+	if len(participantIDs) == 1 && conversationData.Title != "" {
+		return errors.New("title can only be given for group conversations")
+	}
+
+	return nil
+}
+
+/*
+This function creates the cursors of the pages around a page of messages,
+using the last message for the next page and the first message for the previous page.
+Messages are ordered by their ID, so the sort key is left empty.
+*/
+func messagePageCursors(messages []database.Message, hasPrev, hasNext bool) pageCursors {
+	cursors := pageCursors{}
+	if len(messages) == 0 {
+		return cursors
+	}
+
+	if hasNext {
+		cursors.Next = encodeCursor(cursor{Sort: messagesCursorSort, ID: messages[len(messages)-1].ID})
+	}
+	if hasPrev {
+		cursors.Prev = encodeCursor(cursor{Sort: messagesCursorSort, ID: messages[0].ID})
+	}
+
+	return cursors
+}
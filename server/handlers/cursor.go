@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+)
+
+const commentsCursorSort = "comments"
+
+/*
+A cursor is given to clients as an opaque token, which encodes the sort mode,
+together with the sort key and ID of the item that the page starts after (or ends before).
+Threads also encode whether they are pinned, since pinned threads are listed first.
+*/
+type cursor struct {
+	Sort   string `json:"s"`
+	Pinned bool   `json:"p,omitempty"`
+	Key    string `json:"k"`
+	ID     int32  `json:"i"`
+}
+
+/*
+The pages around the current page, which are returned as cursors in the headers.
+A cursor is empty if there is no such page.
+*/
+type pageCursors struct {
+	Next string
+	Prev string
+}
+
+/*
+This function encodes the cursor into an URL-safe token.
+*/
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+/*
+This function decodes the token into a cursor,
+and checks that it was created for the same sort mode.
+*/
+func decodeCursor(token, sort string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, errors.New("malformed cursor")
+	}
+
+	c := cursor{}
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return cursor{}, errors.New("malformed cursor")
+	}
+
+	if c.Sort != sort {
+		return cursor{}, errors.New("cursor does not belong to the sort mode")
+	}
+
+	return c, nil
+}
+
+/*
+This function gets the 'after' and 'before' query from the URL and decodes them for the sort mode.
+At most one of them can be specified, and neither can be used together with the 'page' query.
+The returned cursors are nil if they are not specified.
+*/
+func getCursors(r *http.Request, sort string) (after, before *cursor, err error) {
+	afterToken := r.URL.Query().Get("after")
+	beforeToken := r.URL.Query().Get("before")
+
+	if afterToken != "" && beforeToken != "" {
+		return nil, nil, errors.New("after and before query cannot be used together")
+	}
+	if (afterToken != "" || beforeToken != "") && r.URL.Query().Get("page") != "" {
+		return nil, nil, errors.New("page query cannot be used together with a cursor")
+	}
+
+	if afterToken != "" {
+		c, err := decodeCursor(afterToken, sort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid after query: %v", err)
+		}
+		after = &c
+	}
+	if beforeToken != "" {
+		c, err := decodeCursor(beforeToken, sort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid before query: %v", err)
+		}
+		before = &c
+	}
+
+	return after, before, nil
+}
+
+/*
+This function converts the cursor into a thread cursor by parsing its sort key.
+It returns nil if the cursor is nil.
+*/
+func toThreadCursor(c *cursor) (*database.ThreadCursor, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	key, err := database.ParseThreadSortKey(c.Sort, c.Key)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+
+	return &database.ThreadCursor{Pinned: c.Pinned, Key: key, ID: c.ID}, nil
+}
+
+/*
+This function parses the sort key of a comments cursor, which is the created timestamp.
+*/
+func commentCursorTimestamp(c *cursor) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Key)
+	if err != nil {
+		return time.Time{}, errors.New("malformed cursor")
+	}
+
+	return t, nil
+}
+
+/*
+This function trims the extra item that is fetched on top of the limit, which shows that there are more items.
+When paging backwards, the extra item is the first one instead of the last one.
+It returns the start and end of the page within the items,
+and whether there is a previous and a next page.
+*/
+func trimPage(count, limit, page int, after, before *cursor) (start, end int, hasPrev, hasNext bool) {
+	hasMore := count > limit
+	start, end = 0, count
+
+	if before != nil {
+		if hasMore {
+			start = 1
+		}
+		return start, end, hasMore, true
+	}
+
+	if hasMore {
+		end = limit
+	}
+	return start, end, after != nil || page > 1, hasMore
+}
+
+/*
+This function sets the cursors of the pages around the current page in the
+x-next-cursor and x-prev-cursor headers, leaving out those that are empty.
+*/
+func setCursorHeaders(w http.ResponseWriter, cursors pageCursors) {
+	if cursors.Next != "" {
+		w.Header().Set("x-next-cursor", cursors.Next)
+	}
+	if cursors.Prev != "" {
+		w.Header().Set("x-prev-cursor", cursors.Prev)
+	}
+}
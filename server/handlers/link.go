@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+This function sets the Link header (RFC 8288) with the first, prev, next and last pages,
+keeping all the other query parameters of the URL, which is usually the URL of the request.
+When the request uses a cursor, the prev and next pages use the cursors as well,
+otherwise they use the page number. The first and last pages always use the page number.
+The prev and next pages are only included if there are such pages, based on the cursors.
+*/
+func setLinkHeader(w http.ResponseWriter, u *url.URL, cursors pageCursors, p, l int, total int64) {
+	query := u.Query()
+	cursorMode := query.Get("after") != "" || query.Get("before") != ""
+
+	lastPage := int((total + int64(l) - 1) / int64(l))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{formatLink(u, "first", "page", "1")}
+
+	if cursors.Prev != "" {
+		if cursorMode {
+			links = append(links, formatLink(u, "prev", "before", cursors.Prev))
+		} else {
+			links = append(links, formatLink(u, "prev", "page", strconv.Itoa(p-1)))
+		}
+	}
+
+	if cursors.Next != "" {
+		if cursorMode {
+			links = append(links, formatLink(u, "next", "after", cursors.Next))
+		} else {
+			links = append(links, formatLink(u, "next", "page", strconv.Itoa(p+1)))
+		}
+	}
+
+	links = append(links, formatLink(u, "last", "page", strconv.Itoa(lastPage)))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+/*
+This function formats a single link with the relation type, pointing to the requested path
+with the page or cursor query replaced by the given one.
+The 'first_unread' query is removed as well, since it also selects the page.
+*/
+func formatLink(u *url.URL, rel, key, value string) string {
+	query := u.Query()
+	query.Del("page")
+	query.Del("after")
+	query.Del("before")
+	query.Del("first_unread")
+	query.Set(key, value)
+
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+const notificationsCursorSort = "notifications"
+
+// The maximum number of users that can be notified by mentions in a single thread or comment
+const maxMentions = 10
+
+// A mention cannot directly follow a word character or another '@', so that emails are not mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([a-zA-Z0-9_-]+)`)
+
+/*
+The recipients of the notifications for a new or edited thread or comment.
+The parent author is only set for new replies, since the other cases can only notify the mentioned users.
+The subscribers of the thread are notified separately in the background.
+*/
+type contentNotifications struct {
+	ActorID        uuid.UUID
+	ThreadID       int32
+	CommentID      sql.NullInt32
+	ParentAuthorID uuid.NullUUID
+	Content        string
+}
+
+type unreadCount struct {
+	Count int64 `json:"count"`
+}
+
+/*
+This handler gets the notifications of the user through jwt, starting from the newest.
+It validates the 'unread', 'after', 'before' and 'limit' query, where 'unread=true' only includes unread notifications.
+The cursors of the pages around the current page are returned in the x-next-cursor and x-prev-cursor headers.
+*/
+func (connection *DatabaseConnection) GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	unread, err := getBoolQuery(r, "unread")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(1, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: %v", err))
+		return
+	}
+
+	after, before, err := getCursors(r, notificationsCursorSort)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get cursors: %v", err))
+		return
+	}
+
+	unreadOnly := unread.Valid && unread.Bool
+
+	var notifications []database.GetNotificationsRow
+	if before != nil {
+		var rows []database.GetNotificationsBeforeRow
+		rows, err = connection.DB.GetNotificationsBefore(r.Context(), database.GetNotificationsBeforeParams{
+			UserID:      userID,
+			BeforeID:    before.ID,
+			UnreadOnly:  unreadOnly,
+			ResultLimit: int32(l + 1),
+		})
+		for _, row := range rows {
+			notifications = append(notifications, database.GetNotificationsRow(row))
+		}
+		slices.Reverse(notifications)
+	} else {
+		afterID := sql.NullInt32{}
+		if after != nil {
+			afterID = sql.NullInt32{Int32: after.ID, Valid: true}
+		}
+		notifications, err = connection.DB.GetNotifications(r.Context(), database.GetNotificationsParams{
+			UserID:      userID,
+			AfterID:     afterID,
+			UnreadOnly:  unreadOnly,
+			ResultLimit: int32(l + 1),
+		})
+	}
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get notifications: %v", err))
+		return
+	}
+
+	start, end, hasPrev, hasNext := trimPage(len(notifications), l, 1, after, before)
+	notifications = notifications[start:end]
+	setCursorHeaders(w, notificationPageCursors(notifications, hasPrev, hasNext))
+
+	if len(notifications) == 0 {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatNotifications(notifications))
+}
+
+/*
+This handler gets the number of unread notifications of the user through jwt.
+*/
+func (connection *DatabaseConnection) GetUnreadNotificationsCountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	count, err := connection.DB.GetUnreadNotificationsCount(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get unread notifications count: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, unreadCount{Count: count})
+}
+
+/*
+This handler marks a notification as read based on the 'notification_id' path parameter.
+Notifications of other users are treated as if they do not exist.
+*/
+func (connection *DatabaseConnection) MarkNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	notificationID := chi.URLParam(r, "notification_id")
+	id, err := strconv.Atoi(notificationID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid notification ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, err = connection.DB.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The notification does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to mark notification as read: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler marks all the notifications of the user through jwt as read.
+*/
+func (connection *DatabaseConnection) MarkAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, err = connection.DB.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to mark notifications as read: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This function creates the notifications for a new or edited thread or comment, within the transaction of the write.
+Each user gets at most one notification for the same content, so the author of the parent comment
+is notified first, and then the mentioned users.
+Users are never notified about their own content, and are only notified once for each mention even after edits.
+*/
+func createContentNotifications(ctx context.Context, q *database.Queries, n contentNotifications) error {
+	if n.ParentAuthorID.Valid && n.ParentAuthorID.UUID != n.ActorID {
+		err := q.CreateNotification(ctx, database.CreateNotificationParams{
+			UserID:    n.ParentAuthorID.UUID,
+			Type:      "comment_reply",
+			ActorID:   n.ActorID,
+			ThreadID:  n.ThreadID,
+			CommentID: n.CommentID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	usernames := parseMentions(n.Content)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	return q.CreateMentionNotifications(ctx, database.CreateMentionNotificationsParams{
+		ActorID:   n.ActorID,
+		ThreadID:  n.ThreadID,
+		CommentID: n.CommentID,
+		Usernames: usernames,
+	})
+}
+
+/*
+This function parses the '@username' mentions in the content, returning the distinct usernames in order.
+Only usernames that could be valid are returned, up to the maximum number of mentions.
+*/
+func parseMentions(content string) []string {
+	usernames := []string{}
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := match[1]
+		if len(username) < 3 || len(username) > 20 || slices.Contains(usernames, username) {
+			continue
+		}
+
+		usernames = append(usernames, username)
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+
+	return usernames
+}
+
+/*
+This function creates the cursors of the pages around a page of notifications,
+using the last notification for the next page and the first notification for the previous page.
+Notifications are ordered by their ID, so the sort key is left empty.
+*/
+func notificationPageCursors(notifications []database.GetNotificationsRow, hasPrev, hasNext bool) pageCursors {
+	cursors := pageCursors{}
+	if len(notifications) == 0 {
+		return cursors
+	}
+
+	if hasNext {
+		cursors.Next = encodeCursor(cursor{Sort: notificationsCursorSort, ID: notifications[len(notifications)-1].ID})
+	}
+	if hasPrev {
+		cursors.Prev = encodeCursor(cursor{Sort: notificationsCursorSort, ID: notifications[0].ID})
+	}
+
+	return cursors
+}
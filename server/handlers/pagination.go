@@ -1,30 +1,119 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
 	"github.com/wangyuanchi/shibespace/server/response"
 )
 
+const maxLimit = 100
+
 /*
-This handler first validates the 'tags' (CSV), 'page' and 'limit' query.
-Then, it gets the threads using the queries and sort based on the latest updated thread.
+This handler first validates the 'tags', 'tags_any', 'tags_none' (CSV), 'creator_id', 'created_after',
+'created_before', 'has_comments', 'answered', 'sort', 'window', 'after', 'before', 'page' and 'limit' query.
+Then, it gets the threads that match all of the filters, with the pinned threads first,
+and sorts them based on the sort mode.
+The page either starts after or ends before a cursor, or is selected by the page number.
+The cursors of the next and previous pages are included in the header as x-next-cursor and x-prev-cursor,
+and the links to the first, previous, next and last pages are included in the Link header.
+The tags and reactions of every thread are included, marking the reactions added by and
+the threads bookmarked by the viewer if logged in.
 The response may be a 204 status code (no content).
 The total count is included in the header as x-total-count
 */
 func (connection *DatabaseConnection) GetThreadsPaginatedHandler(w http.ResponseWriter, r *http.Request) {
-	tags, err := getAndValidateTags(r)
+	connection.respondWithThreads(w, r, sql.NullInt32{})
+}
+
+/*
+This function responds with the threads for GetThreadsPaginatedHandler and GetCategoryThreadsHandler,
+only including the threads in the category if the category ID is valid.
+*/
+func (connection *DatabaseConnection) respondWithThreads(w http.ResponseWriter, r *http.Request, categoryID sql.NullInt32) {
+	tags, err := getAndValidateTags(r, "tags")
 	if err != nil {
 		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get and validate tags: %v", err))
 		return
 	}
 
+	tagsAny, err := getAndValidateTags(r, "tags_any")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get and validate tags_any: %v", err))
+		return
+	}
+
+	tagsNone, err := getAndValidateTags(r, "tags_none")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get and validate tags_none: %v", err))
+		return
+	}
+
+	creatorID, err := getUUIDQuery(r, "creator_id")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createdAfter, err := getTimeQuery(r, "created_after")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createdBefore, err := getTimeQuery(r, "created_before")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hasComments, err := getBoolQuery(r, "has_comments")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	answered, err := getBoolQuery(r, "answered")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sort, since, err := getAndValidateSort(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get and validate sort: %v", err))
+		return
+	}
+
+	after, before, err := getCursors(r, sort)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get cursors: %v", err))
+		return
+	}
+
+	afterCursor, err := toThreadCursor(after)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid after query: %v", err))
+		return
+	}
+
+	beforeCursor, err := toThreadCursor(before)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid before query: %v", err))
+		return
+	}
+
 	p, l, err := getPageAndLimit(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
@@ -37,38 +126,78 @@ func (connection *DatabaseConnection) GetThreadsPaginatedHandler(w http.Response
 		return
 	}
 
-	threads, err := connection.DB.GetThreadsPaginated(r.Context(), database.GetThreadsPaginatedParams{
-		Column1: tags,
-		Limit:   int32(l),
-		Offset:  int32((p - 1) * l),
-	})
+	listThreadsParams := database.ListThreadsParams{
+		CategoryID:    categoryID,
+		Tags:          tags,
+		TagsAny:       tagsAny,
+		TagsNone:      tagsNone,
+		CreatorID:     creatorID,
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		HasComments:   hasComments,
+		Answered:      answered,
+		Sort:          sort,
+		Since:         since,
+		After:         afterCursor,
+		Before:        beforeCursor,
+		Limit:         int32(l + 1),
+		Offset:        int32((p - 1) * l),
+	}
+
+	threads, err := connection.DB.ListThreads(r.Context(), listThreadsParams)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get threads: %v", err))
 		return
 	}
 
-	threadsCount, err := connection.DB.GetThreadsPaginatedCount(r.Context(), tags)
+	threadsCount, err := connection.DB.CountThreads(r.Context(), listThreadsParams)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get threads count: %v", err))
 		return
 	}
 	w.Header().Set("x-total-count", strconv.Itoa(int(threadsCount)))
 
-	if threads == nil {
+	start, end, hasPrev, hasNext := trimPage(len(threads), l, p, after, before)
+	threads = threads[start:end]
+	cursors := threadPageCursors(sort, threads, hasPrev, hasNext)
+	setCursorHeaders(w, cursors)
+	setLinkHeader(w, r.URL, cursors, p, l, threadsCount)
+
+	if len(threads) == 0 {
 		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
-	} else {
-		response.RespondWithJSON(w, http.StatusOK, database.FormatThreads(threads))
+		return
+	}
+
+	viewerID, statusCode, err := middleware.JWTExtractOptionalUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
 	}
+
+	formattedThreads, err := connection.formatThreads(r.Context(), threads, viewerID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format threads: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedThreads)
 }
 
 /*
-This handler first validates the 'thread_id' (compulsory), 'page' and 'limit' query.
-Next, it gets the comments using the queries and sorts based on the first created comment.
+This handler first validates the 'thread_id' (compulsory), 'after', 'before', 'page', 'first_unread' and 'limit' query.
+Next, it gets the comments using the queries, with the accepted answer of the thread first,
+and sorts the rest based on the first created comment.
+The page either starts after or ends before a cursor, or is selected by the page number,
+which can also be the page with the first unread comment of the user.
+The cursors of the next and previous pages are included in the header as x-next-cursor and x-prev-cursor,
+and the links to the first, previous, next and last pages are included in the Link header.
+The reactions of every comment are included, marking those added by and
+the comments bookmarked by the viewer if logged in.
 The response may be a 204 status code (no content).
 The total count is included in the header as x-total-count
 */
 func (connection *DatabaseConnection) GetCommentsPaginatedHandler(w http.ResponseWriter, r *http.Request) {
-	id, statusCode, err := getAndValidateThread(connection, r)
+	thread, statusCode, err := getAndValidateThread(connection, r)
 	if err != nil {
 		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to get or validate thread: %v", err))
 		return
@@ -86,28 +215,180 @@ func (connection *DatabaseConnection) GetCommentsPaginatedHandler(w http.Respons
 		return
 	}
 
-	comments, err := connection.DB.GetCommentsPaginated(r.Context(), database.GetCommentsPaginatedParams{
-		ThreadID: int32(id),
-		Limit:    int32(l),
-		Offset:   int32((p - 1) * l),
-	})
+	after, before, err := getCursors(r, commentsCursorSort)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get cursors: %v", err))
+		return
+	}
+
+	firstUnread, err := getBoolQuery(r, "first_unread")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if firstUnread.Bool {
+		if after != nil || before != nil || r.URL.Query().Get("page") != "" {
+			response.RespondWithError(w, http.StatusBadRequest, "Failed to get first unread page: first_unread query cannot be used together with page or a cursor")
+			return
+		}
+
+		userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+		if err != nil {
+			response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+			return
+		}
+
+		p, err = connection.getFirstUnreadPage(r.Context(), userID, thread.ID, l)
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get first unread page: %v", err))
+			return
+		}
+	}
+
+	connection.respondWithCommentsPage(w, r, thread, p, l, after, before, r.URL)
+}
+
+/*
+This function responds with a page of comments of the thread, either after or before a cursor, or by the page number,
+together with the total count, the cursors and the Link header, where the links point to the other pages of pageURL.
+The reactions of every comment are included, marking those added by and the comments bookmarked by the viewer if logged in.
+*/
+func (connection *DatabaseConnection) respondWithCommentsPage(w http.ResponseWriter, r *http.Request, thread database.Thread, p, l int, after, before *cursor, pageURL *url.URL) {
+	comments, statusCode, err := connection.getCommentsPage(r.Context(), thread, p, l, after, before)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comments: %v", err))
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to get comments: %v", err))
 		return
 	}
 
-	commentsCount, err := connection.DB.GetCommentsPaginatedCount(r.Context(), int32(id))
+	commentsCount, err := connection.DB.GetCommentsPaginatedCount(r.Context(), thread.ID)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comments count: %v", err))
 		return
 	}
 	w.Header().Set("x-total-count", strconv.Itoa(int(commentsCount)))
 
-	if comments == nil {
+	start, end, hasPrev, hasNext := trimPage(len(comments), l, p, after, before)
+	comments = comments[start:end]
+	cursors := commentPageCursors(comments, thread.AcceptedCommentID, hasPrev, hasNext)
+	setCursorHeaders(w, cursors)
+	setLinkHeader(w, pageURL, cursors, p, l, commentsCount)
+
+	if len(comments) == 0 {
 		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
-	} else {
-		response.RespondWithJSON(w, http.StatusOK, database.FormatComments(comments))
+		return
+	}
+
+	viewerID, statusCode, err := middleware.JWTExtractOptionalUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	formattedComments, err := connection.formatComments(r.Context(), comments, thread.AcceptedCommentID, viewerID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format comments: %v", err))
+		return
 	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedComments)
+}
+
+/*
+This function gets a page of comments of the thread, together with one extra comment to show that there are more,
+either after or before a cursor, or by the page number.
+The accepted answer is fetched separately and placed before the rest of the comments,
+so that the rest of the comments are paged on their creation alone.
+*/
+func (connection *DatabaseConnection) getCommentsPage(ctx context.Context, thread database.Thread, p, l int, after, before *cursor) ([]database.Comment, int, error) {
+	accepted := []database.Comment{}
+	if thread.AcceptedCommentID.Valid {
+		comment, err := connection.DB.GetAcceptedComment(ctx, thread.AcceptedCommentID.Int32)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, http.StatusInternalServerError, err
+		}
+		if err == nil {
+			accepted = append(accepted, comment)
+		}
+	}
+
+	var comments []database.Comment
+	var err error
+	switch {
+	case after != nil && after.Pinned:
+		// Every other comment is after the accepted answer
+		comments, err = connection.DB.GetCommentsPaginated(ctx, database.GetCommentsPaginatedParams{
+			ThreadID:    thread.ID,
+			AcceptedID:  thread.AcceptedCommentID,
+			ResultLimit: int32(l + 1),
+		})
+	case after != nil:
+		createdTimestamp, err := commentCursorTimestamp(after)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid after query: %v", err)
+		}
+		comments, err = connection.DB.GetCommentsAfter(ctx, database.GetCommentsAfterParams{
+			ThreadID:         thread.ID,
+			AcceptedID:       thread.AcceptedCommentID,
+			CreatedTimestamp: createdTimestamp,
+			ID:               after.ID,
+			ResultLimit:      int32(l + 1),
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	case before != nil && before.Pinned:
+		// Nothing is before the accepted answer
+	case before != nil:
+		createdTimestamp, err := commentCursorTimestamp(before)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid before query: %v", err)
+		}
+		comments, err = connection.DB.GetCommentsBefore(ctx, database.GetCommentsBeforeParams{
+			ThreadID:         thread.ID,
+			AcceptedID:       thread.AcceptedCommentID,
+			CreatedTimestamp: createdTimestamp,
+			ID:               before.ID,
+			ResultLimit:      int32(l + 1),
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		slices.Reverse(comments)
+
+		// The accepted answer comes right before the first comment once there are no more comments before the page
+		if len(comments) < l+1 {
+			comments = append(accepted, comments...)
+		}
+	default:
+		offset := (p - 1) * l
+		if len(accepted) != 0 {
+			if offset == 0 {
+				comments, err = connection.DB.GetCommentsPaginated(ctx, database.GetCommentsPaginatedParams{
+					ThreadID:    thread.ID,
+					AcceptedID:  thread.AcceptedCommentID,
+					ResultLimit: int32(l),
+				})
+				if err != nil {
+					return nil, http.StatusInternalServerError, err
+				}
+				return append(accepted, comments...), http.StatusOK, nil
+			}
+			offset--
+		}
+
+		comments, err = connection.DB.GetCommentsPaginated(ctx, database.GetCommentsPaginatedParams{
+			ThreadID:     thread.ID,
+			AcceptedID:   thread.AcceptedCommentID,
+			ResultLimit:  int32(l + 1),
+			ResultOffset: int32(offset),
+		})
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return comments, http.StatusOK, nil
 }
 
 /*
@@ -138,7 +419,8 @@ func getPageAndLimit(r *http.Request) (p, l int, err error) {
 }
 
 /*
-This function checks if the page and limit value is at least 1.
+This function checks if the page and limit value is at least 1,
+and that the limit value does not exceed the maximum.
 */
 func validatePageAndLimit(p, l int) error {
 	if p < 1 {
@@ -147,16 +429,66 @@ func validatePageAndLimit(p, l int) error {
 	if l < 1 {
 		return errors.New("limit value must be at least 1")
 	}
+	if l > maxLimit {
+		return fmt.Errorf("limit value must be at most %d", maxLimit)
+	}
 	return nil
 }
 
 /*
-This function gets the 'tags' query from the URL.
+This function creates the cursors of the pages around a page of threads,
+using the last thread for the next page and the first thread for the previous page.
+*/
+func threadPageCursors(sort string, threads []database.Thread, hasPrev, hasNext bool) pageCursors {
+	cursors := pageCursors{}
+	if len(threads) == 0 {
+		return cursors
+	}
+
+	if hasNext {
+		last := threads[len(threads)-1]
+		cursors.Next = encodeCursor(cursor{Sort: sort, Pinned: last.Pinned, Key: database.ThreadSortKey(sort, last), ID: last.ID})
+	}
+	if hasPrev {
+		first := threads[0]
+		cursors.Prev = encodeCursor(cursor{Sort: sort, Pinned: first.Pinned, Key: database.ThreadSortKey(sort, first), ID: first.ID})
+	}
+
+	return cursors
+}
+
+/*
+This function creates the cursors of the pages around a page of comments,
+using the last comment for the next page and the first comment for the previous page.
+The accepted answer is encoded as pinned, since it is listed before the rest of the comments.
+*/
+func commentPageCursors(comments []database.Comment, acceptedID sql.NullInt32, hasPrev, hasNext bool) pageCursors {
+	cursors := pageCursors{}
+	if len(comments) == 0 {
+		return cursors
+	}
+
+	if hasNext {
+		last := comments[len(comments)-1]
+		cursors.Next = encodeCursor(cursor{Sort: commentsCursorSort, Pinned: acceptedID.Valid && last.ID == acceptedID.Int32,
+			Key: last.CreatedTimestamp.Format(time.RFC3339Nano), ID: last.ID})
+	}
+	if hasPrev {
+		first := comments[0]
+		cursors.Prev = encodeCursor(cursor{Sort: commentsCursorSort, Pinned: acceptedID.Valid && first.ID == acceptedID.Int32,
+			Key: first.CreatedTimestamp.Format(time.RFC3339Nano), ID: first.ID})
+	}
+
+	return cursors
+}
+
+/*
+This function gets a tags query, such as 'tags', from the URL.
 It will be treated as a CSV, and the values are split into a []string, then validated.
 The default return value will be an empty string slice, i.e. no tags.
 */
-func getAndValidateTags(r *http.Request) ([]string, error) {
-	tags := r.URL.Query().Get("tags")
+func getAndValidateTags(r *http.Request, name string) ([]string, error) {
+	tags := r.URL.Query().Get(name)
 	t := strings.Split(tags, ",")
 
 	if tags == "" {
@@ -175,26 +507,154 @@ func getAndValidateTags(r *http.Request) ([]string, error) {
 	return t, nil
 }
 
+/*
+This function gets the 'sort' query from the URL, which defaults to 'active'.
+For the 'top' sort mode, the 'window' query (day, week or all) is also read,
+which defaults to 'all' and is returned as the earliest creation time allowed.
+*/
+func getAndValidateSort(r *http.Request) (string, sql.NullTime, error) {
+	sort := r.URL.Query().Get("sort")
+	window := r.URL.Query().Get("window")
+
+	if sort == "" {
+		sort = database.ThreadSortActive
+	}
+
+	switch sort {
+	case database.ThreadSortNew, database.ThreadSortActive, database.ThreadSortHot:
+		if window != "" {
+			return "", sql.NullTime{}, errors.New("window query is only allowed for the top sort mode")
+		}
+		return sort, sql.NullTime{}, nil
+	case database.ThreadSortTop:
+		since, err := getWindowSince(window)
+		if err != nil {
+			return "", sql.NullTime{}, err
+		}
+		return sort, since, nil
+	default:
+		return "", sql.NullTime{}, errors.New("sort must be one of new, active, top, hot")
+	}
+}
+
+/*
+This function converts the window (day, week or all) into the earliest time allowed.
+An empty window is treated as all, in which case the returned time is not valid.
+*/
+func getWindowSince(window string) (sql.NullTime, error) {
+	switch window {
+	case "day":
+		return sql.NullTime{Time: time.Now().AddDate(0, 0, -1), Valid: true}, nil
+	case "week":
+		return sql.NullTime{Time: time.Now().AddDate(0, 0, -7), Valid: true}, nil
+	case "", "all":
+		return sql.NullTime{}, nil
+	default:
+		return sql.NullTime{}, errors.New("window must be one of day, week, all")
+	}
+}
+
+/*
+This function gets a timestamp query from the URL, which can either be
+in the RFC 3339 format or a date (YYYY-MM-DD) that is treated as midnight UTC.
+The returned time is only valid if the query is specified.
+*/
+func getTimeQuery(r *http.Request, name string) (sql.NullTime, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			return sql.NullTime{}, fmt.Errorf("invalid %s query, must be RFC 3339 or YYYY-MM-DD", name)
+		}
+	}
+
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+/*
+This function gets a user ID query from the URL.
+The returned user ID is only valid if the query is specified.
+*/
+func getUUIDQuery(r *http.Request, name string) (uuid.NullUUID, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("invalid %s query: %v", name, err)
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+/*
+This function gets a boolean query from the URL, which must be either true or false.
+The returned boolean is only valid if the query is specified.
+*/
+func getBoolQuery(r *http.Request, name string) (sql.NullBool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullBool{}, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return sql.NullBool{}, fmt.Errorf("invalid %s query, must be true or false", name)
+	}
+
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
 /*
 This function gets the 'thread_id' query from the URL,
-then checks if the thread actually exists.
-If it does, it returns the thread ID and the 200 status code.
+then checks if the thread actually exists and is not held, unless the viewer is allowed to see it.
+If it does, it returns the thread and the 200 status code.
 */
-func getAndValidateThread(connection *DatabaseConnection, r *http.Request) (id, statusCode int, err error) {
+func getAndValidateThread(connection *DatabaseConnection, r *http.Request) (thread database.Thread, statusCode int, err error) {
 	threadID := r.URL.Query().Get("thread_id")
-	id, err = strconv.Atoi(threadID)
+	id, err := strconv.Atoi(threadID)
 	if err != nil {
-		return 0, http.StatusBadRequest, fmt.Errorf("invalid thread ID: %v", err)
+		return database.Thread{}, http.StatusBadRequest, fmt.Errorf("invalid thread ID: %v", err)
 	}
 
-	_, err = connection.DB.GetThread(r.Context(), int32(id))
+	return connection.getVisibleThread(r, int32(id))
+}
+
+/*
+This function gets the thread, returning a 404 status code if it does not exist
+or if it is held and the viewer through the optional jwt cannot see it.
+*/
+func (connection *DatabaseConnection) getVisibleThread(r *http.Request, id int32) (database.Thread, int, error) {
+	thread, err := connection.DB.GetThread(r.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, http.StatusNotFound, errors.New("the thread does not exist")
+			return database.Thread{}, http.StatusNotFound, errors.New("the thread does not exist")
 		} else {
-			return 0, http.StatusInternalServerError, fmt.Errorf("failed to get thread: %v", err)
+			return database.Thread{}, http.StatusInternalServerError, fmt.Errorf("failed to get thread: %v", err)
+		}
+	}
+
+	if thread.Held {
+		viewerID, statusCode, err := middleware.JWTExtractOptionalUserID(connection.DB, r)
+		if err != nil {
+			return database.Thread{}, statusCode, fmt.Errorf("failed to extract username: %v", err)
+		}
+
+		visible, err := connection.canViewHeld(r.Context(), viewerID, thread.CreatorID)
+		if err != nil {
+			return database.Thread{}, http.StatusInternalServerError, fmt.Errorf("failed to get user role: %v", err)
+		}
+		if !visible {
+			return database.Thread{}, http.StatusNotFound, errors.New("the thread does not exist")
 		}
 	}
 
-	return id, http.StatusOK, nil
+	return thread, http.StatusOK, nil
 }
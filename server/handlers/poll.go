@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+type pollData struct {
+	Question        string     `json:"question"`
+	Options         []string   `json:"options"`
+	MultipleChoice  bool       `json:"multiple_choice"`
+	HideResults     bool       `json:"hide_results"`
+	ClosesTimestamp *time.Time `json:"closes_timestamp"`
+}
+
+type pollVote struct {
+	OptionIDs []int32 `json:"option_ids"`
+}
+
+/*
+This handler votes in the poll of the thread based on the 'thread_id' path parameter for the user through jwt.
+The chosen option IDs are parsed from the request, where single choice polls only accept one option.
+Each user can only vote once in a poll, which is enforced by their ballot in the database.
+The poll is returned with the updated results, unless the results are hidden until the poll closes.
+*/
+func (connection *DatabaseConnection) CreatePollVoteHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	pollVote := pollVote{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&pollVote)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = pollVoteValidation(pollVote)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	poll, statusCode, err := connection.getOpenPoll(r.Context(), int32(id), userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed poll check: %v", err))
+		return
+	}
+
+	if !poll.MultipleChoice && len(pollVote.OptionIDs) > 1 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: only one option can be chosen in the poll")
+		return
+	}
+
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.CreatePollBallot(r.Context(), database.CreatePollBallotParams{
+			PollID: poll.ID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.CreatePollVotes(r.Context(), database.CreatePollVotesParams{
+			PollID:    poll.ID,
+			UserID:    userID,
+			OptionIds: pollVote.OptionIDs,
+		})
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The user has already voted in the poll")
+		} else if ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusBadRequest, "An option does not belong to the poll")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add vote to database: %v", err))
+		}
+		return
+	}
+
+	formattedPoll, err := connection.getThreadPoll(r.Context(), int32(id), uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get poll: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, formattedPoll)
+}
+
+/*
+This handler removes the vote of the user through jwt in the poll of the thread based on the 'thread_id' path parameter,
+so that the user can vote again. Votes can only be removed while the poll is open.
+*/
+func (connection *DatabaseConnection) DeletePollVoteHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	poll, statusCode, err := connection.getOpenPoll(r.Context(), int32(id), userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed poll check: %v", err))
+		return
+	}
+
+	rows, err := connection.DB.DeletePollBallot(r.Context(), database.DeletePollBallotParams{
+		PollID: poll.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete vote: %v", err))
+		return
+	}
+
+	if rows == 0 {
+		response.RespondWithError(w, http.StatusNotFound, "The user has not voted in the poll")
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This function creates the poll of a new thread together with its options, within the transaction of the thread.
+*/
+func createThreadPoll(ctx context.Context, q *database.Queries, threadID int32, pollData pollData) error {
+	closesTimestamp := sql.NullTime{}
+	if pollData.ClosesTimestamp != nil {
+		closesTimestamp = sql.NullTime{Time: *pollData.ClosesTimestamp, Valid: true}
+	}
+
+	poll, err := q.CreatePoll(ctx, database.CreatePollParams{
+		ThreadID:        threadID,
+		Question:        pollData.Question,
+		MultipleChoice:  pollData.MultipleChoice,
+		HideResults:     pollData.HideResults,
+		ClosesTimestamp: closesTimestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.CreatePollOptions(ctx, database.CreatePollOptionsParams{
+		PollID: poll.ID,
+		Texts:  pollData.Options,
+	})
+}
+
+/*
+This function gets the poll of the thread together with its results and the options voted by the viewer,
+returning nil if the thread does not have a poll.
+The options voted by the viewer are left empty if the viewer is not logged in.
+*/
+func (connection *DatabaseConnection) getThreadPoll(ctx context.Context, threadID int32, viewerID uuid.NullUUID) (*database.FormattedPoll, error) {
+	poll, err := connection.DB.GetThreadPoll(ctx, threadID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	results, err := connection.DB.GetPollResults(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	votersCount, err := connection.DB.GetPollVotersCount(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	votedOptionIDs := []int32{}
+	if viewerID.Valid {
+		votedOptionIDs, err = connection.DB.GetUserPollVotes(ctx, database.GetUserPollVotesParams{
+			PollID: poll.ID,
+			UserID: viewerID.UUID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if votedOptionIDs == nil {
+			votedOptionIDs = []int32{}
+		}
+	}
+
+	formattedPoll := database.FormatPoll(poll, results, votersCount, votedOptionIDs, pollClosed(poll))
+	return &formattedPoll, nil
+}
+
+/*
+This function gets the poll of the thread for voting, returning a 403 status code if the poll has closed
+or if the thread is archived, and a 404 status code if the thread does not have a poll.
+*/
+func (connection *DatabaseConnection) getOpenPoll(ctx context.Context, threadID int32, userID uuid.UUID) (database.Poll, int, error) {
+	statusCode, err := connection.checkThreadWritable(ctx, threadID, userID, false)
+	if err != nil {
+		return database.Poll{}, statusCode, err
+	}
+
+	poll, err := connection.DB.GetThreadPoll(ctx, threadID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.Poll{}, http.StatusNotFound, errors.New("the thread does not have a poll")
+		}
+		return database.Poll{}, http.StatusInternalServerError, fmt.Errorf("failed to get poll: %v", err)
+	}
+
+	if pollClosed(poll) {
+		return database.Poll{}, http.StatusForbidden, errors.New("the poll is closed")
+	}
+
+	return poll, http.StatusOK, nil
+}
+
+/*
+This function checks if the poll has reached its close time.
+Polls without a close time never close.
+*/
+func pollClosed(poll database.Poll) bool {
+	return poll.ClosesTimestamp.Valid && !poll.ClosesTimestamp.Time.After(time.Now())
+}
+
+/*
+This function checks if the length of the question is between 1 and 255 characters,
+and that there are between 2 and 10 unique options that are each between 1 and 100 characters.
+The close time must be in the future if given, and is required for hiding the results until the poll closes.
+*/
+func pollDataValidation(pollData pollData) error {
+	if len(pollData.Question) < 1 || len(pollData.Question) > 255 {
+		return errors.New("poll question must be between 1 and 255 characters long")
+	}
+
+	if len(pollData.Options) < 2 || len(pollData.Options) > 10 {
+		return errors.New("number of poll options must be between 2 and 10")
+	}
+
+	seen := make(map[string]bool)
+	for _, option := range pollData.Options {
+		if seen[option] {
+			return errors.New("poll options must be unique")
+		}
+
+		if len(option) < 1 || len(option) > 100 {
+			return errors.New("all poll options must be between 1 and 100 characters long")
+		}
+
+		seen[option] = true
+	}
+
+	if pollData.ClosesTimestamp != nil && !pollData.ClosesTimestamp.After(time.Now()) {
+		return errors.New("poll closes_timestamp must be in the future")
+	}
+
+	if pollData.HideResults && pollData.ClosesTimestamp == nil {
+		return errors.New("poll closes_timestamp is required to hide the results")
+	}
+
+	return nil
+}
+
+/*
+This function checks that at least one option is chosen and that the options are unique.
+*/
+func pollVoteValidation(pollVote pollVote) error {
+	if len(pollVote.OptionIDs) < 1 {
+		return errors.New("option_ids must have at least 1 option")
+	}
+
+	seen := make(map[int32]bool)
+	for _, optionID := range pollVote.OptionIDs {
+		if seen[optionID] {
+			return errors.New("option_ids must be unique")
+		}
+		seen[optionID] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/wangyuanchi/shibespace/server/markdown"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+type renderedContent struct {
+	ContentHTML string `json:"content_html"`
+}
+
+/*
+This handler renders the content from the request in the same way as threads and comments,
+so that editors can preview it before it is posted. Nothing is stored.
+*/
+func (connection *DatabaseConnection) PreviewHandler(w http.ResponseWriter, r *http.Request) {
+	commentContent := commentContent{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&commentContent)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = commentContentValidation(commentContent)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	contentHTML, err := connection.renderContent(r.Context(), commentContent.Content)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render content: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, renderedContent{ContentHTML: contentHTML})
+}
+
+/*
+This function renders the content from Markdown into sanitized HTML,
+showing the authors of the comments that are quoted in it. Quoted comments that do not exist are left without one.
+*/
+func (connection *DatabaseConnection) renderContent(ctx context.Context, content string) (string, error) {
+	quotes := make(map[int32]string)
+
+	ids := markdown.QuoteIDs(content)
+	if len(ids) > 0 {
+		authors, err := connection.DB.GetQuotedCommentAuthors(ctx, ids)
+		if err != nil {
+			return "", err
+		}
+
+		for _, author := range authors {
+			quotes[author.ID] = author.Username
+		}
+	}
+
+	return markdown.RenderWithQuotes(content, quotes)
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+var defaultReactions = []string{"thumbsup", "heart", "laughing", "tada", "eyes", "rocket"}
+
+type reactionData struct {
+	Reaction string `json:"reaction"`
+}
+
+/*
+This handler returns the reaction shortcodes that are allowed on threads and comments.
+*/
+func GetReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	response.RespondWithJSON(w, http.StatusOK, getAllowedReactions())
+}
+
+/*
+This handler adds a reaction to the thread based on the 'thread_id' path parameter.
+The reaction is parsed from the request and must be one of the allowed shortcodes.
+Each user can only react to a thread once with the same reaction, and archived threads cannot be reacted to.
+The score of the thread, which is used for sorting, is increased in the same transaction.
+*/
+func (connection *DatabaseConnection) CreateThreadReactionHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	reactionData := reactionData{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&reactionData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = reactionValidation(reactionData.Reaction)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	statusCode, err = connection.checkThreadWritable(r.Context(), int32(id), userID, false)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	var reaction database.ThreadReaction
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		reaction, err = q.CreateThreadReaction(r.Context(), database.CreateThreadReactionParams{
+			ThreadID: int32(id),
+			UserID:   userID,
+			Reaction: reactionData.Reaction,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.UpdateThreadScore(r.Context(), database.UpdateThreadScoreParams{
+			Delta: 1,
+			ID:    int32(id),
+		})
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusNotFound, "The thread does not exist")
+		} else if ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The reaction has already been added")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add reaction to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormattedThreadReaction(reaction))
+}
+
+/*
+This handler removes the user's reaction based on the 'thread_id' and 'reaction' path parameters.
+The score of the thread, which is used for sorting, is decreased in the same transaction.
+Reactions cannot be removed from archived threads.
+*/
+func (connection *DatabaseConnection) DeleteThreadReactionHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	statusCode, err = connection.checkThreadWritable(r.Context(), int32(id), userID, false)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		_, err := q.DeleteThreadReaction(r.Context(), database.DeleteThreadReactionParams{
+			ThreadID: int32(id),
+			UserID:   userID,
+			Reaction: chi.URLParam(r, "reaction"),
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.UpdateThreadScore(r.Context(), database.UpdateThreadScoreParams{
+			Delta: -1,
+			ID:    int32(id),
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The reaction does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete reaction: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This handler adds a reaction to the comment based on the 'comment_id' path parameter.
+The reaction is parsed from the request and must be one of the allowed shortcodes.
+Each user can only react to a comment once with the same reaction, and comments in archived threads cannot be reacted to.
+*/
+func (connection *DatabaseConnection) CreateCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	reactionData := reactionData{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&reactionData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = reactionValidation(reactionData.Reaction)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	statusCode, err = connection.checkCommentWritable(r.Context(), int32(id), userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	reaction, err := connection.DB.CreateCommentReaction(r.Context(), database.CreateCommentReactionParams{
+		CommentID: int32(id),
+		UserID:    userID,
+		Reaction:  reactionData.Reaction,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusNotFound, "The comment does not exist")
+		} else if ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The reaction has already been added")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add reaction to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormattedCommentReaction(reaction))
+}
+
+/*
+This handler removes the user's reaction based on the 'comment_id' and 'reaction' path parameters.
+Reactions cannot be removed from comments in archived threads.
+*/
+func (connection *DatabaseConnection) DeleteCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := chi.URLParam(r, "comment_id")
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid comment ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	statusCode, err = connection.checkCommentWritable(r.Context(), int32(id), userID)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	_, err = connection.DB.DeleteCommentReaction(r.Context(), database.DeleteCommentReactionParams{
+		CommentID: int32(id),
+		UserID:    userID,
+		Reaction:  chi.URLParam(r, "reaction"),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The reaction does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete reaction: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This function fills in the aggregated reactions of the given threads,
+using a single query regardless of the number of threads.
+The viewer ID is used to mark the reactions added by the viewer,
+and can be invalid if the viewer is not logged in.
+*/
+func (connection *DatabaseConnection) addThreadsReactions(ctx context.Context, threads []database.FormattedThread, viewerID uuid.NullUUID) error {
+	threadIDs := make([]int32, len(threads))
+	indexes := make(map[int32]int)
+	for i, thread := range threads {
+		threadIDs[i] = thread.ID
+		indexes[thread.ID] = i
+	}
+
+	reactions, err := connection.DB.GetThreadsReactions(ctx, database.GetThreadsReactionsParams{
+		ViewerID:  viewerID,
+		ThreadIds: threadIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get thread reactions: %v", err)
+	}
+
+	for _, reaction := range reactions {
+		i := indexes[reaction.ThreadID]
+		threads[i].Reactions = append(threads[i].Reactions, database.FormattedReaction{
+			Reaction: reaction.Reaction,
+			Count:    reaction.Count,
+			Reacted:  reaction.Reacted,
+		})
+	}
+
+	return nil
+}
+
+/*
+This function fills in the aggregated reactions of the given comments,
+using a single query regardless of the number of comments.
+The viewer ID is used to mark the reactions added by the viewer,
+and can be invalid if the viewer is not logged in.
+*/
+func (connection *DatabaseConnection) addCommentsReactions(ctx context.Context, comments []database.FormattedComment, viewerID uuid.NullUUID) error {
+	commentIDs := make([]int32, len(comments))
+	indexes := make(map[int32]int)
+	for i, comment := range comments {
+		commentIDs[i] = comment.ID
+		indexes[comment.ID] = i
+	}
+
+	reactions, err := connection.DB.GetCommentsReactions(ctx, database.GetCommentsReactionsParams{
+		ViewerID:   viewerID,
+		CommentIds: commentIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get comment reactions: %v", err)
+	}
+
+	for _, reaction := range reactions {
+		i := indexes[reaction.CommentID]
+		comments[i].Reactions = append(comments[i].Reactions, database.FormattedReaction{
+			Reaction: reaction.Reaction,
+			Count:    reaction.Count,
+			Reacted:  reaction.Reacted,
+		})
+	}
+
+	return nil
+}
+
+/*
+This function gets the allowed reaction shortcodes from the 'ALLOWED_REACTIONS'
+environment variable, which is treated as a CSV.
+The default shortcodes are used if it is not found in the environment.
+*/
+func getAllowedReactions() []string {
+	godotenv.Load(".env")
+
+	allowed := os.Getenv("ALLOWED_REACTIONS")
+	if allowed == "" {
+		return defaultReactions
+	}
+
+	reactions := []string{}
+	for _, reaction := range strings.Split(allowed, ",") {
+		reaction = strings.TrimSpace(reaction)
+		if reaction != "" {
+			reactions = append(reactions, reaction)
+		}
+	}
+
+	return reactions
+}
+
+/*
+This function checks if the reaction is one of the allowed shortcodes.
+*/
+func reactionValidation(reaction string) error {
+	if !slices.Contains(getAllowedReactions(), reaction) {
+		return fmt.Errorf("reaction must be one of %s", strings.Join(getAllowedReactions(), ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+type threadReadData struct {
+	CommentID int32 `json:"comment_id"`
+}
+
+/*
+This handler marks the thread based on the 'thread_id' path parameter as read for the user through jwt,
+up to the optional comment ID from the request, or up to the latest comment if it is not given.
+The read marker only moves forward, so marking an older comment keeps the current marker.
+The current read marker of the thread is returned.
+*/
+func (connection *DatabaseConnection) MarkThreadReadHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	threadReadData := threadReadData{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&threadReadData)
+	if err != nil && !errors.Is(err, io.EOF) {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	params := database.MarkThreadReadParams{
+		UserID:   userID,
+		ThreadID: int32(id),
+	}
+
+	var position database.GetCommentReadPositionRow
+	if threadReadData.CommentID != 0 {
+		position, err = connection.DB.GetCommentReadPosition(r.Context(), threadReadData.CommentID)
+		if err == nil && position.ThreadID != int32(id) {
+			response.RespondWithError(w, http.StatusBadRequest, "The comment does not belong to the thread")
+			return
+		}
+	} else {
+		var latest database.GetLatestCommentReadPositionRow
+		latest, err = connection.DB.GetLatestCommentReadPosition(r.Context(), int32(id))
+		position = database.GetCommentReadPositionRow(latest)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment position: %v", err))
+		return
+	}
+	if err == sql.ErrNoRows && threadReadData.CommentID != 0 {
+		response.RespondWithError(w, http.StatusBadRequest, "The comment does not exist")
+		return
+	}
+
+	// Threads without any comments are marked as read without a last read comment
+	if err == nil {
+		params.ReadCommentCount = position.Position
+		params.LastReadCommentID = sql.NullInt32{Int32: position.ID, Valid: true}
+		params.LastReadCommentTimestamp = sql.NullTime{Time: position.CreatedTimestamp, Valid: true}
+	}
+
+	var threadRead database.ThreadRead
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.MarkThreadRead(r.Context(), params)
+		if err != nil {
+			return err
+		}
+
+		threadRead, err = q.GetThreadRead(r.Context(), database.GetThreadReadParams{
+			UserID:   userID,
+			ThreadID: int32(id),
+		})
+		return err
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusNotFound, "The thread does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to mark thread as read: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatThreadRead(threadRead))
+}
+
+/*
+This function fills in the number of unread comments of the threads and whether they have anything new for the viewer,
+using a single query regardless of the number of threads.
+The unread comments are based on the comment count of the thread when it was marked as read,
+and a thread is new if it has unread comments or any activity since then, or if it was never read.
+Nothing is filled in if the viewer is not logged in.
+*/
+func (connection *DatabaseConnection) addThreadsReads(ctx context.Context, threads []database.FormattedThread, viewerID uuid.NullUUID) error {
+	if !viewerID.Valid {
+		return nil
+	}
+
+	threadIDs := make([]int32, len(threads))
+	indexes := make(map[int32]int)
+	for i, thread := range threads {
+		threadIDs[i] = thread.ID
+		indexes[thread.ID] = i
+
+		unreadComments := thread.CommentCount
+		hasNew := true
+		threads[i].UnreadComments = &unreadComments
+		threads[i].HasNew = &hasNew
+	}
+
+	reads, err := connection.DB.GetThreadsReads(ctx, database.GetThreadsReadsParams{
+		UserID:    viewerID.UUID,
+		ThreadIds: threadIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get thread reads: %v", err)
+	}
+
+	for _, read := range reads {
+		thread := &threads[indexes[read.ThreadID]]
+		*thread.UnreadComments = max(thread.CommentCount-read.ReadCommentCount, 0)
+		*thread.HasNew = *thread.UnreadComments > 0 || thread.LastActivityTimestamp.After(read.ReadTimestamp)
+	}
+
+	return nil
+}
+
+/*
+This function gets the page of comments with the first comment in the thread that is unread by the user,
+which is the last page if every comment has been read.
+*/
+func (connection *DatabaseConnection) getFirstUnreadPage(ctx context.Context, userID uuid.UUID, threadID int32, limit int) (int, error) {
+	readCount, err := connection.DB.GetReadCommentsCount(ctx, database.GetReadCommentsCountParams{
+		UserID:   userID,
+		ThreadID: threadID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	commentsCount, err := connection.DB.GetCommentsPaginatedCount(ctx, threadID)
+	if err != nil {
+		return 0, err
+	}
+
+	if readCount >= commentsCount {
+		readCount = max(commentsCount-1, 0)
+	}
+
+	return int(readCount)/limit + 1, nil
+}
@@ -0,0 +1,652 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+var reportReasons = []string{"spam", "harassment", "hate_speech", "explicit", "misinformation", "other"}
+
+// The status of a case after it is resolved with each action
+var caseResolutions = map[string]string{
+	"dismiss": "dismissed",
+	"remove":  "removed",
+	"warn":    "warned",
+	"ban":     "banned",
+}
+
+// The statuses that the moderation queue can be filtered by, where 'resolved' is any status other than 'open'
+var caseStatuses = []string{"open", "resolved", "dismissed", "removed", "warned", "banned", "all"}
+
+type reportData struct {
+	ThreadID  int32         `json:"thread_id"`
+	CommentID int32         `json:"comment_id"`
+	UserID    uuid.NullUUID `json:"user_id"`
+	Reason    string        `json:"reason"`
+	Details   string        `json:"details"`
+}
+
+/*
+The thread, comment or user that a case is about, together with the user that is responsible for it.
+The title and content of the thread or comment are kept in the case, so that they can be reviewed after it is deleted.
+*/
+type reportTarget struct {
+	TargetType    string
+	ThreadID      sql.NullInt32
+	CommentID     sql.NullInt32
+	TargetUserID  uuid.UUID
+	TargetTitle   string
+	TargetContent string
+}
+
+type caseAssignee struct {
+	AssigneeID uuid.NullUUID `json:"assignee_id"`
+}
+
+type caseResolution struct {
+	Action  string `json:"action"`
+	Note    string `json:"note"`
+	BanDays *int32 `json:"ban_days"`
+}
+
+/*
+This handler parses the reported thread, comment or user, together with the reason and details from the request.
+It conducts input validation, then it gets the reporter through jwt.
+Reports of the same thread, comment or user are grouped into the same case until it is resolved,
+and each user can only report it once in the same case.
+Users cannot report themselves or their own content.
+*/
+func (connection *DatabaseConnection) CreateReportHandler(w http.ResponseWriter, r *http.Request) {
+	reportData := reportData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&reportData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = reportDataValidation(reportData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	target, statusCode, err := connection.getReportTarget(r, reportData)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to get report target: %v", err))
+		return
+	}
+
+	if target.TargetUserID == userID {
+		response.RespondWithError(w, http.StatusBadRequest, "Users cannot report themselves or their own content")
+		return
+	}
+
+	var report database.Report
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		caseID, err := openReportCase(r.Context(), q, target)
+		if err != nil {
+			return err
+		}
+
+		report, err = q.CreateReport(r.Context(), database.CreateReportParams{
+			CaseID:     caseID,
+			ReporterID: uuid.NullUUID{UUID: userID, Valid: true},
+			Reason:     reportData.Reason,
+			Details:    reportData.Details,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.AddReportCaseReport(r.Context(), caseID)
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The user has already reported this")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add report to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatReport(report))
+}
+
+/*
+This handler gets the report cases in the moderation queue, starting from the oldest.
+It validates the 'status', 'target_type', 'reason', 'assignee_id', 'unassigned', 'page' and 'limit' query,
+where only open cases are included by default.
+Only moderators and admins are allowed to get the report cases.
+The response may be a 204 status code (no content).
+The total count is included in the header as x-total-count
+*/
+func (connection *DatabaseConnection) GetReportCasesHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	status := sql.NullString{String: "open", Valid: true}
+	if r.URL.Query().Has("status") {
+		status.String = r.URL.Query().Get("status")
+		if !slices.Contains(caseStatuses, status.String) {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid status query, must be one of %v", caseStatuses))
+			return
+		}
+		status.Valid = status.String != "all"
+	}
+
+	targetType := sql.NullString{}
+	if r.URL.Query().Has("target_type") {
+		targetType = sql.NullString{String: r.URL.Query().Get("target_type"), Valid: true}
+		if !slices.Contains([]string{"thread", "comment", "user"}, targetType.String) {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid target_type query, must be one of thread, comment or user")
+			return
+		}
+	}
+
+	reason := sql.NullString{}
+	if r.URL.Query().Has("reason") {
+		reason = sql.NullString{String: r.URL.Query().Get("reason"), Valid: true}
+		reasons := append(slices.Clone(reportReasons), automodReason)
+		if !slices.Contains(reasons, reason.String) {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid reason query, must be one of %v", reasons))
+			return
+		}
+	}
+
+	assigneeID, err := getUUIDQuery(r, "assignee_id")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	unassigned, err := getBoolQuery(r, "unassigned")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(p, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	reportCases, err := connection.DB.GetReportCases(r.Context(), database.GetReportCasesParams{
+		Status:       status,
+		TargetType:   targetType,
+		Reason:       reason,
+		AssigneeID:   assigneeID,
+		Unassigned:   unassigned.Valid && unassigned.Bool,
+		ResultLimit:  int32(l),
+		ResultOffset: int32((p - 1) * l),
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get report cases: %v", err))
+		return
+	}
+
+	reportCasesCount, err := connection.DB.GetReportCasesCount(r.Context(), database.GetReportCasesCountParams{
+		Status:     status,
+		TargetType: targetType,
+		Reason:     reason,
+		AssigneeID: assigneeID,
+		Unassigned: unassigned.Valid && unassigned.Bool,
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get report cases count: %v", err))
+		return
+	}
+	w.Header().Set("x-total-count", strconv.Itoa(int(reportCasesCount)))
+
+	if len(reportCases) == 0 {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatReportCases(reportCases))
+}
+
+/*
+This handler gets a report case based on the 'case_id' path parameter, together with every report in it.
+Only moderators and admins are allowed to get report cases.
+*/
+func (connection *DatabaseConnection) GetReportCaseHandler(w http.ResponseWriter, r *http.Request) {
+	caseID := chi.URLParam(r, "case_id")
+	id, err := strconv.Atoi(caseID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid case ID: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	connection.respondWithReportCase(w, r, int32(id))
+}
+
+/*
+This handler assigns the report case based on the 'case_id' path parameter to a moderator or admin,
+or unassigns it if the assignee ID is null.
+Only moderators and admins are allowed to assign report cases.
+*/
+func (connection *DatabaseConnection) UpdateReportCaseAssigneeHandler(w http.ResponseWriter, r *http.Request) {
+	caseID := chi.URLParam(r, "case_id")
+	id, err := strconv.Atoi(caseID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid case ID: %v", err))
+		return
+	}
+
+	caseAssignee := caseAssignee{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&caseAssignee)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	if caseAssignee.AssigneeID.Valid {
+		role, err := connection.DB.GetUserRole(r.Context(), caseAssignee.AssigneeID.UUID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				response.RespondWithError(w, http.StatusBadRequest, "The assignee does not exist")
+			} else {
+				response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user role: %v", err))
+			}
+			return
+		}
+
+		if !middleware.RoleAtLeast(role, middleware.RoleModerator) {
+			response.RespondWithError(w, http.StatusBadRequest, "The assignee must be a moderator or admin")
+			return
+		}
+	}
+
+	_, err = connection.DB.AssignReportCase(r.Context(), database.AssignReportCaseParams{
+		ID:         int32(id),
+		AssigneeID: caseAssignee.AssigneeID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The case does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to assign case: %v", err))
+		}
+		return
+	}
+
+	connection.respondWithReportCase(w, r, int32(id))
+}
+
+/*
+This handler resolves the open report case based on the 'case_id' path parameter with one of the actions:
+'dismiss' leaves everything as it is apart from releasing the thread or comment if it is held,
+'remove' deletes the reported thread or comment,
+'warn' notifies the reported user and releases the thread or comment if it is held,
+and 'ban' bans the reported user for the number of days, or permanently if not given.
+Held content stays hidden when its author is banned, as it is only released by 'dismiss' and 'warn'.
+Moderators and admins cannot be banned.
+Cases about threads and comments reported as spam train the spam classifier before the action is taken,
+as ham if the case is dismissed and as spam otherwise.
+The action and the notifications of every reporter of the case are done in the same transaction.
+Only moderators and admins are allowed to resolve report cases.
+*/
+func (connection *DatabaseConnection) ResolveReportCaseHandler(w http.ResponseWriter, r *http.Request) {
+	caseID := chi.URLParam(r, "case_id")
+	id, err := strconv.Atoi(caseID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid case ID: %v", err))
+		return
+	}
+
+	caseResolution := caseResolution{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&caseResolution)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = caseResolutionValidation(caseResolution)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	moderatorID, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	reportCase, err := connection.DB.GetReportCase(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The case does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get case: %v", err))
+		}
+		return
+	}
+
+	if reportCase.Status != "open" {
+		response.RespondWithError(w, http.StatusConflict, "The case is already resolved")
+		return
+	}
+
+	switch caseResolution.Action {
+	case "remove":
+		if reportCase.TargetType == "user" {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid input: only threads and comments can be removed")
+			return
+		}
+		if (reportCase.TargetType == "thread" && !reportCase.ThreadID.Valid) ||
+			(reportCase.TargetType == "comment" && !reportCase.CommentID.Valid) {
+			response.RespondWithError(w, http.StatusConflict, "The reported content was already deleted")
+			return
+		}
+	case "ban":
+		role, err := connection.DB.GetUserRole(r.Context(), reportCase.TargetUserID)
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user role: %v", err))
+			return
+		}
+		if middleware.RoleAtLeast(role, middleware.RoleModerator) {
+			response.RespondWithError(w, http.StatusForbidden, "Moderators and admins cannot be banned")
+			return
+		}
+	}
+
+	var released *database.Comment
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		_, err := q.ResolveReportCase(r.Context(), database.ResolveReportCaseParams{
+			ID:             reportCase.ID,
+			Status:         caseResolutions[caseResolution.Action],
+			ResolverID:     uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ResolutionNote: caseResolution.Note,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = trainFromReportCase(r.Context(), q, reportCase, moderatorID, caseResolution.Action != "dismiss")
+		if err != nil {
+			return err
+		}
+
+		switch caseResolution.Action {
+		case "dismiss":
+			released, err = releaseHeldContent(r.Context(), q, reportCase)
+			if err != nil {
+				return err
+			}
+		case "remove":
+			if reportCase.TargetType == "thread" {
+				_, err = q.DeleteThread(r.Context(), reportCase.ThreadID.Int32)
+				if err != nil {
+					return err
+				}
+			} else {
+				comment, err := q.DeleteComment(r.Context(), reportCase.CommentID.Int32)
+				if err != nil {
+					return err
+				}
+
+				err = q.RefreshThreadComments(r.Context(), comment.ThreadID)
+				if err != nil {
+					return err
+				}
+			}
+		case "warn":
+			// A warning is enough for the content, so it is released like a dismissal
+			released, err = releaseHeldContent(r.Context(), q, reportCase)
+			if err != nil {
+				return err
+			}
+
+			err = q.CreateWarningNotification(r.Context(), database.CreateWarningNotificationParams{
+				ActorID: moderatorID,
+				CaseID:  reportCase.ID,
+			})
+			if err != nil {
+				return err
+			}
+		case "ban":
+			banDays := sql.NullInt32{}
+			if caseResolution.BanDays != nil {
+				banDays = sql.NullInt32{Int32: *caseResolution.BanDays, Valid: true}
+			}
+
+			err = q.BanUser(r.Context(), database.BanUserParams{
+				ID:      reportCase.TargetUserID,
+				BanDays: banDays,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// The reported content is already deleted at this point, so removed content is not referenced
+		return q.CreateReportResolvedNotifications(r.Context(), database.CreateReportResolvedNotificationsParams{
+			ActorID: moderatorID,
+			CaseID:  reportCase.ID,
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusConflict, "The case is already resolved or the reported content was already deleted")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to resolve case: %v", err))
+		}
+		return
+	}
+
+	if released != nil {
+		connection.Notifier.Enqueue(*released)
+	}
+
+	connection.respondWithReportCase(w, r, reportCase.ID)
+}
+
+/*
+This function opens a case for the target, or gets the case of the target if it already has an open one.
+*/
+func openReportCase(ctx context.Context, q *database.Queries, target reportTarget) (int32, error) {
+	switch target.TargetType {
+	case "thread":
+		return q.OpenThreadReportCase(ctx, database.OpenThreadReportCaseParams{
+			ThreadID:      target.ThreadID,
+			TargetUserID:  target.TargetUserID,
+			TargetTitle:   target.TargetTitle,
+			TargetContent: target.TargetContent,
+		})
+	case "comment":
+		return q.OpenCommentReportCase(ctx, database.OpenCommentReportCaseParams{
+			ThreadID:      target.ThreadID,
+			CommentID:     target.CommentID,
+			TargetUserID:  target.TargetUserID,
+			TargetContent: target.TargetContent,
+		})
+	default:
+		return q.OpenUserReportCase(ctx, target.TargetUserID)
+	}
+}
+
+/*
+This function gets the thread, comment or user that is reported, together with the user that is responsible for it
+and the current title and content of the thread or comment.
+The IDs are part of the request body, so targets that do not exist are bad requests.
+*/
+func (connection *DatabaseConnection) getReportTarget(r *http.Request, reportData reportData) (reportTarget, int, error) {
+	switch {
+	case reportData.ThreadID != 0:
+		thread, err := connection.DB.GetThread(r.Context(), reportData.ThreadID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return reportTarget{}, http.StatusBadRequest, errors.New("the thread does not exist")
+			}
+			return reportTarget{}, http.StatusInternalServerError, fmt.Errorf("failed to get thread: %v", err)
+		}
+
+		return reportTarget{
+			TargetType:    "thread",
+			ThreadID:      sql.NullInt32{Int32: thread.ID, Valid: true},
+			TargetUserID:  thread.CreatorID,
+			TargetTitle:   thread.Title,
+			TargetContent: thread.Content,
+		}, http.StatusOK, nil
+	case reportData.CommentID != 0:
+		comment, err := connection.DB.GetComment(r.Context(), reportData.CommentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return reportTarget{}, http.StatusBadRequest, errors.New("the comment does not exist")
+			}
+			return reportTarget{}, http.StatusInternalServerError, fmt.Errorf("failed to get comment: %v", err)
+		}
+
+		return reportTarget{
+			TargetType:    "comment",
+			ThreadID:      sql.NullInt32{Int32: comment.ThreadID, Valid: true},
+			CommentID:     sql.NullInt32{Int32: comment.ID, Valid: true},
+			TargetUserID:  comment.CreatorID,
+			TargetContent: comment.Content,
+		}, http.StatusOK, nil
+	default:
+		_, err := connection.DB.GetUserRole(r.Context(), reportData.UserID.UUID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return reportTarget{}, http.StatusBadRequest, errors.New("the user does not exist")
+			}
+			return reportTarget{}, http.StatusInternalServerError, fmt.Errorf("failed to get user role: %v", err)
+		}
+
+		return reportTarget{
+			TargetType:   "user",
+			TargetUserID: reportData.UserID.UUID,
+		}, http.StatusOK, nil
+	}
+}
+
+/*
+This function responds with the report case based on the case ID, together with every report in it.
+*/
+func (connection *DatabaseConnection) respondWithReportCase(w http.ResponseWriter, r *http.Request, caseID int32) {
+	reportCase, err := connection.DB.GetReportCase(r.Context(), caseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The case does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get case: %v", err))
+		}
+		return
+	}
+
+	reports, err := connection.DB.GetCaseReports(r.Context(), caseID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get case reports: %v", err))
+		return
+	}
+
+	formattedReportCase := database.FormatReportCase(reportCase)
+	formattedReportCase.Reports = database.FormatCaseReports(reports)
+
+	response.RespondWithJSON(w, http.StatusOK, formattedReportCase)
+}
+
+/*
+This function checks that exactly one of the thread, comment or user is reported,
+that the reason is valid, and that the details are at most 1000 characters long.
+Details are required if the reason is 'other'.
+*/
+func reportDataValidation(reportData reportData) error {
+	targets := 0
+	if reportData.ThreadID != 0 {
+		targets++
+	}
+	if reportData.CommentID != 0 {
+		targets++
+	}
+	if reportData.UserID.Valid {
+		targets++
+	}
+	if targets != 1 {
+		return errors.New("exactly one of thread_id, comment_id or user_id is required")
+	}
+
+	if !slices.Contains(reportReasons, reportData.Reason) {
+		return fmt.Errorf("reason must be one of %v", reportReasons)
+	}
+
+	if len(reportData.Details) > 1000 {
+		return errors.New("details must be at most 1000 characters long")
+	}
+
+	if reportData.Reason == "other" && len(reportData.Details) < 1 {
+		return errors.New("details are required if the reason is other")
+	}
+
+	return nil
+}
+
+/*
+This function checks that the action is valid, that the note is at most 1000 characters long,
+and that the number of days of a ban is between 1 and 3650, which can only be given for bans.
+*/
+func caseResolutionValidation(caseResolution caseResolution) error {
+	if _, ok := caseResolutions[caseResolution.Action]; !ok {
+		return errors.New("action must be one of dismiss, remove, warn or ban")
+	}
+
+	if len(caseResolution.Note) > 1000 {
+		return errors.New("note must be at most 1000 characters long")
+	}
+
+	if caseResolution.BanDays != nil {
+		if caseResolution.Action != "ban" {
+			return errors.New("ban_days can only be given for the ban action")
+		}
+		if *caseResolution.BanDays < 1 || *caseResolution.BanDays > 3650 {
+			return errors.New("ban_days must be between 1 and 3650")
+		}
+	}
+
+	return nil
+}
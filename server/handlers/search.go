@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+type searchToken struct {
+	Text   string
+	Phrase bool
+}
+
+/*
+This handler searches the title and content of threads and the content of comments.
+It first validates the 'q' (compulsory), 'type', 'tags' (CSV), 'creator_id',
+'created_after', 'created_before', 'page' and 'limit' query.
+The results are ranked by relevance, and include a snippet with the matches highlighted.
+The response may be a 204 status code (no content).
+The total count is included in the header as x-total-count
+*/
+func (connection *DatabaseConnection) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if len(q) < 1 || len(q) > 255 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid q query: must be between 1 and 255 characters long")
+		return
+	}
+
+	query, err := parseSearchQuery(q)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid q query: %v", err))
+		return
+	}
+
+	includeThreads, includeComments := true, true
+	switch r.URL.Query().Get("type") {
+	case "":
+	case "threads":
+		includeComments = false
+	case "comments":
+		includeThreads = false
+	default:
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid type query: must be either threads or comments")
+		return
+	}
+
+	tags, err := getAndValidateTags(r, "tags")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get and validate tags: %v", err))
+		return
+	}
+
+	creatorID, err := getUUIDQuery(r, "creator_id")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createdAfter, err := getTimeQuery(r, "created_after")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createdBefore, err := getTimeQuery(r, "created_before")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(p, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	results, err := connection.DB.SearchPosts(r.Context(), database.SearchPostsParams{
+		Query:           query,
+		IncludeThreads:  includeThreads,
+		IncludeComments: includeComments,
+		Tags:            tags,
+		CreatorID:       creatorID,
+		CreatedAfter:    createdAfter,
+		CreatedBefore:   createdBefore,
+		ResultLimit:     int32(l),
+		ResultOffset:    int32((p - 1) * l),
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to search posts: %v", err))
+		return
+	}
+
+	resultsCount, err := connection.DB.SearchPostsCount(r.Context(), database.SearchPostsCountParams{
+		Query:           query,
+		IncludeThreads:  includeThreads,
+		IncludeComments: includeComments,
+		Tags:            tags,
+		CreatorID:       creatorID,
+		CreatedAfter:    createdAfter,
+		CreatedBefore:   createdBefore,
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get search results count: %v", err))
+		return
+	}
+	w.Header().Set("x-total-count", strconv.Itoa(int(resultsCount)))
+
+	if results == nil {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+	} else {
+		response.RespondWithJSON(w, http.StatusOK, database.FormatSearchResults(results))
+	}
+}
+
+/*
+This function converts the search query into a tsquery expression.
+Words must all be matched, unless OR is placed between them, in which case either can be matched.
+A "quoted phrase" must be matched in order, a word ending with * is matched as a prefix,
+and a word or phrase starting with - must not be matched.
+Only letters and digits are kept, so the expression can always be parsed by Postgres.
+*/
+func parseSearchQuery(q string) (string, error) {
+	var expression strings.Builder
+	operator := " & "
+	hasTerm, hasIncludedTerm := false, false
+
+	for _, token := range tokenizeSearchQuery(q) {
+		text := token.Text
+		if !token.Phrase && text == "OR" {
+			operator = " | "
+			continue
+		}
+
+		negate := strings.HasPrefix(text, "-")
+		text = strings.TrimPrefix(text, "-")
+		prefix := !token.Phrase && strings.HasSuffix(text, "*")
+
+		words := strings.FieldsFunc(text, func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+		})
+		if len(words) == 0 {
+			continue
+		}
+
+		term := strings.Join(words, " <-> ")
+		if prefix {
+			term += ":*"
+		}
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+		if negate {
+			term = "!" + term
+		} else {
+			hasIncludedTerm = true
+		}
+
+		if hasTerm {
+			expression.WriteString(operator)
+		}
+		expression.WriteString(term)
+		hasTerm = true
+		operator = " & "
+	}
+
+	if !hasIncludedTerm {
+		return "", errors.New("must contain at least one word that is not excluded")
+	}
+
+	return expression.String(), nil
+}
+
+/*
+This function splits the search query by whitespace, except within double quotes,
+where the whole quoted text is kept as a single phrase token.
+A leading - before the opening quote is kept so that the phrase can be excluded.
+*/
+func tokenizeSearchQuery(q string) []searchToken {
+	var tokens []searchToken
+	var current strings.Builder
+	inPhrase := false
+
+	flush := func(phrase bool) {
+		if current.Len() > 0 {
+			tokens = append(tokens, searchToken{Text: current.String(), Phrase: phrase})
+			current.Reset()
+		}
+	}
+
+	for _, c := range q {
+		switch {
+		case c == '"':
+			if inPhrase {
+				flush(true)
+			} else if current.String() != "-" {
+				flush(false)
+			}
+			inPhrase = !inPhrase
+		case unicode.IsSpace(c) && !inPhrase:
+			flush(false)
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush(inPhrase)
+
+	return tokens
+}
@@ -0,0 +1,514 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+	"github.com/wangyuanchi/shibespace/server/spam"
+)
+
+// The reason of reports about spam, which the classifier is trained from when their cases are resolved
+const spamReason = "spam"
+
+const defaultSpamThreshold = 0.9
+
+type spamLabel struct {
+	ThreadID  int32 `json:"thread_id"`
+	CommentID int32 `json:"comment_id"`
+	Spam      *bool `json:"spam"`
+}
+
+/*
+The thread or comment that the classifier is trained on, together with its content and label.
+*/
+type spamSampleParams struct {
+	ThreadID  sql.NullInt32
+	CommentID sql.NullInt32
+	Content   string
+	Spam      bool
+	TrainerID uuid.NullUUID
+}
+
+type spamStatistics struct {
+	SpamSamples int64   `json:"spam_samples"`
+	HamSamples  int64   `json:"ham_samples"`
+	Vocabulary  int64   `json:"vocabulary"`
+	Threshold   float64 `json:"threshold"`
+	Trained     bool    `json:"trained"`
+}
+
+/*
+This handler gets the number of spam and ham samples the classifier is trained on, the number of distinct tokens,
+the threshold above which content is held, and whether there are enough samples for content to be classified.
+Only admins are allowed to get the statistics of the classifier.
+*/
+func (connection *DatabaseConnection) GetSpamStatisticsHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	connection.respondWithSpamStatistics(w, r)
+}
+
+/*
+This handler retrains the classifier from scratch using every spam and ham sample, and counts its totals again.
+Resolved spam cases that were not trained on yet, such as those resolved before the classifier existed,
+are added as samples first if their thread or comment still exists.
+Only admins are allowed to retrain the classifier.
+*/
+func (connection *DatabaseConnection) RetrainSpamClassifierHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		_, err := q.BackfillThreadSpamSamples(r.Context())
+		if err != nil {
+			return err
+		}
+
+		_, err = q.BackfillCommentSpamSamples(r.Context())
+		if err != nil {
+			return err
+		}
+
+		samples, err := q.GetSpamSamples(r.Context())
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteSpamTokens(r.Context())
+		if err != nil {
+			return err
+		}
+
+		counts := make(map[string]*spam.Counts)
+		createSpamTokensParams := database.CreateSpamTokensParams{}
+		for _, sample := range samples {
+			for _, token := range spam.Tokenize(sample.Content) {
+				if counts[token] == nil {
+					counts[token] = &spam.Counts{}
+					createSpamTokensParams.Tokens = append(createSpamTokensParams.Tokens, token)
+				}
+				if sample.Spam {
+					counts[token].Spam++
+				} else {
+					counts[token].Ham++
+				}
+			}
+		}
+		if len(counts) != 0 {
+			for _, token := range createSpamTokensParams.Tokens {
+				createSpamTokensParams.SpamCounts = append(createSpamTokensParams.SpamCounts, int32(counts[token].Spam))
+				createSpamTokensParams.HamCounts = append(createSpamTokensParams.HamCounts, int32(counts[token].Ham))
+			}
+
+			err = q.CreateSpamTokens(r.Context(), createSpamTokensParams)
+			if err != nil {
+				return err
+			}
+		}
+
+		return q.RecountSpamModel(r.Context())
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to retrain classifier: %v", err))
+		return
+	}
+
+	connection.respondWithSpamStatistics(w, r)
+}
+
+/*
+This handler gets the tokens of the classifier together with their weights, which are positive for tokens that point towards spam
+and negative for tokens that point towards ham. It validates the 'sort', 'prefix', 'page' and 'limit' query,
+where the tokens that point the most towards spam are first by default.
+Only admins are allowed to get the tokens.
+The response may be a 204 status code (no content).
+The total count is included in the header as x-total-count
+*/
+func (connection *DatabaseConnection) GetSpamTokensHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "spam"
+	}
+	if !slices.Contains([]string{"spam", "ham"}, sort) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid sort query, must be one of spam or ham")
+		return
+	}
+
+	prefix := sql.NullString{}
+	if r.URL.Query().Has("prefix") {
+		prefix = sql.NullString{String: r.URL.Query().Get("prefix"), Valid: true}
+	}
+
+	p, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(p, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	tokens, err := connection.DB.GetSpamTokenWeights(r.Context(), database.GetSpamTokenWeightsParams{
+		Prefix:       prefix,
+		Ham:          sort == "ham",
+		ResultLimit:  int32(l),
+		ResultOffset: int32((p - 1) * l),
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tokens: %v", err))
+		return
+	}
+
+	tokensCount, err := connection.DB.GetSpamTokenWeightsCount(r.Context(), prefix)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tokens count: %v", err))
+		return
+	}
+	w.Header().Set("x-total-count", strconv.Itoa(int(tokensCount)))
+
+	if tokens == nil {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormatSpamTokens(tokens))
+}
+
+/*
+This handler parses the thread or comment, together with whether it is spam, from the request,
+then trains the classifier on it, replacing the previous label if it was already trained on.
+Only moderators and admins are allowed to train the classifier.
+*/
+func (connection *DatabaseConnection) CreateSpamSampleHandler(w http.ResponseWriter, r *http.Request) {
+	spamLabel := spamLabel{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&spamLabel)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = spamLabelValidation(spamLabel)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	moderatorID, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	trainingSample := spamSampleParams{
+		Spam:      *spamLabel.Spam,
+		TrainerID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+	}
+	if spamLabel.ThreadID != 0 {
+		thread, err := connection.DB.GetThread(r.Context(), spamLabel.ThreadID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				response.RespondWithError(w, http.StatusBadRequest, "The thread does not exist")
+			} else {
+				response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get thread: %v", err))
+			}
+			return
+		}
+		trainingSample.ThreadID = sql.NullInt32{Int32: thread.ID, Valid: true}
+		trainingSample.Content = spamContent(thread.Title, thread.Content)
+	} else {
+		comment, err := connection.DB.GetComment(r.Context(), spamLabel.CommentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				response.RespondWithError(w, http.StatusBadRequest, "The comment does not exist")
+			} else {
+				response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment: %v", err))
+			}
+			return
+		}
+		trainingSample.CommentID = sql.NullInt32{Int32: comment.ID, Valid: true}
+		trainingSample.Content = comment.Content
+	}
+
+	var sample database.SpamSample
+	var created bool
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		sample, created, err = trainSpamSample(r.Context(), q, trainingSample)
+		return err
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to train classifier: %v", err))
+		return
+	}
+
+	statusCode = http.StatusOK
+	if created {
+		statusCode = http.StatusCreated
+	}
+
+	response.RespondWithJSON(w, statusCode, database.FormatSpamSample(sample))
+}
+
+/*
+This function responds with the statistics of the classifier.
+*/
+func (connection *DatabaseConnection) respondWithSpamStatistics(w http.ResponseWriter, r *http.Request) {
+	statistics, err := connection.DB.GetSpamModel(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get classifier statistics: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, spamStatistics{
+		SpamSamples: statistics.SpamSamples,
+		HamSamples:  statistics.HamSamples,
+		Vocabulary:  statistics.Vocabulary,
+		Threshold:   getSpamThreshold(),
+		Trained:     spam.Model{SpamSamples: statistics.SpamSamples, HamSamples: statistics.HamSamples}.Trained(),
+	})
+}
+
+/*
+This function returns the probability that the content is spam, which is 0 until the classifier has enough samples.
+Only the counts of the tokens in the content are loaded.
+*/
+func (connection *DatabaseConnection) classifySpam(ctx context.Context, content string) (float64, error) {
+	statistics, err := connection.DB.GetSpamModel(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	model := spam.Model{
+		SpamSamples:    statistics.SpamSamples,
+		HamSamples:     statistics.HamSamples,
+		SpamTokenCount: statistics.SpamTokenCount,
+		HamTokenCount:  statistics.HamTokenCount,
+		Vocabulary:     statistics.Vocabulary,
+		Tokens:         make(map[string]spam.Counts),
+	}
+	if !model.Trained() {
+		return 0, nil
+	}
+
+	tokens := spam.Tokenize(content)
+	counts, err := connection.DB.GetSpamTokens(ctx, tokens)
+	if err != nil {
+		return 0, err
+	}
+	for _, count := range counts {
+		model.Tokens[count.Token] = spam.Counts{Spam: int64(count.SpamCount), Ham: int64(count.HamCount)}
+	}
+
+	return model.Probability(tokens), nil
+}
+
+/*
+This function trains the classifier on the thread or comment of the report case if it was reported as spam,
+where dismissing the case marks it as ham and any other action marks it as spam.
+It has to be called before the content is removed, since the content is copied into the sample.
+*/
+func trainFromReportCase(ctx context.Context, q *database.Queries, reportCase database.GetReportCaseRow, moderatorID uuid.UUID, isSpam bool) error {
+	if !slices.Contains(reportCase.Reasons, spamReason) {
+		return nil
+	}
+
+	trainingSample := spamSampleParams{
+		Spam:      isSpam,
+		TrainerID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+	}
+	switch {
+	case reportCase.TargetType == "thread" && reportCase.ThreadID.Valid:
+		thread, err := q.GetThread(ctx, reportCase.ThreadID.Int32)
+		if err != nil {
+			return err
+		}
+		trainingSample.ThreadID = reportCase.ThreadID
+		trainingSample.Content = spamContent(thread.Title, thread.Content)
+	case reportCase.TargetType == "comment" && reportCase.CommentID.Valid:
+		comment, err := q.GetComment(ctx, reportCase.CommentID.Int32)
+		if err != nil {
+			return err
+		}
+		trainingSample.CommentID = reportCase.CommentID
+		trainingSample.Content = comment.Content
+	default:
+		return nil
+	}
+
+	_, _, err := trainSpamSample(ctx, q, trainingSample)
+	return err
+}
+
+/*
+This function saves the sample and adds its tokens to the classifier, together with the totals of the classifier.
+If the thread or comment was already trained on, its previous sample is taken out of the classifier and replaced.
+The sample is locked while it is replaced, so that training the same thread or comment concurrently does not take it out twice.
+It returns whether a new sample was created.
+*/
+func trainSpamSample(ctx context.Context, q *database.Queries, params spamSampleParams) (database.SpamSample, bool, error) {
+	var claimed database.ClaimThreadSpamSampleRow
+	var err error
+	if params.ThreadID.Valid {
+		claimed, err = q.ClaimThreadSpamSample(ctx, database.ClaimThreadSpamSampleParams{
+			ThreadID:  params.ThreadID,
+			Content:   params.Content,
+			Spam:      params.Spam,
+			TrainerID: params.TrainerID,
+		})
+	} else {
+		var row database.ClaimCommentSpamSampleRow
+		row, err = q.ClaimCommentSpamSample(ctx, database.ClaimCommentSpamSampleParams{
+			CommentID: params.CommentID,
+			Content:   params.Content,
+			Spam:      params.Spam,
+			TrainerID: params.TrainerID,
+		})
+		claimed = database.ClaimThreadSpamSampleRow(row)
+	}
+	if err != nil {
+		return database.SpamSample{}, false, err
+	}
+
+	sample := database.SpamSample{
+		ID:               claimed.ID,
+		ThreadID:         claimed.ThreadID,
+		CommentID:        claimed.CommentID,
+		Content:          claimed.Content,
+		Spam:             claimed.Spam,
+		TrainerID:        claimed.TrainerID,
+		CreatedTimestamp: claimed.CreatedTimestamp,
+		UpdatedTimestamp: claimed.UpdatedTimestamp,
+	}
+	adjustment := database.AdjustSpamModelParams{}
+
+	if !claimed.Created {
+		tokens := spam.Tokenize(claimed.Content)
+		// The tokens are locked in the same order by every transaction, so that concurrent training cannot deadlock
+		slices.Sort(tokens)
+		removed, err := q.RemoveSpamTokens(ctx, database.RemoveSpamTokensParams{Spam: claimed.Spam, Tokens: tokens})
+		if err != nil {
+			return database.SpamSample{}, false, err
+		}
+
+		deleted, err := q.DeleteUnusedSpamTokens(ctx, tokens)
+		if err != nil {
+			return database.SpamSample{}, false, err
+		}
+
+		sample, err = q.UpdateSpamSample(ctx, database.UpdateSpamSampleParams{
+			ID:        claimed.ID,
+			Content:   params.Content,
+			Spam:      params.Spam,
+			TrainerID: params.TrainerID,
+		})
+		if err != nil {
+			return database.SpamSample{}, false, err
+		}
+
+		adjustSpamModel(&adjustment, claimed.Spam, -1, -removed)
+		adjustment.Vocabulary -= deleted
+	}
+
+	tokens := spam.Tokenize(sample.Content)
+	slices.Sort(tokens)
+	inserted, err := q.AddSpamTokens(ctx, database.AddSpamTokensParams{Spam: sample.Spam, Tokens: tokens})
+	if err != nil {
+		return database.SpamSample{}, false, err
+	}
+
+	adjustSpamModel(&adjustment, sample.Spam, 1, int64(len(inserted)))
+	for _, isNew := range inserted {
+		if isNew {
+			adjustment.Vocabulary++
+		}
+	}
+
+	err = q.AdjustSpamModel(ctx, adjustment)
+	if err != nil {
+		return database.SpamSample{}, false, err
+	}
+
+	return sample, claimed.Created, nil
+}
+
+/*
+This function adds the change in the number of samples and tokens to the totals of the label.
+*/
+func adjustSpamModel(adjustment *database.AdjustSpamModelParams, isSpam bool, samples, tokens int64) {
+	if isSpam {
+		adjustment.SpamSamples += samples
+		adjustment.SpamTokenCount += tokens
+	} else {
+		adjustment.HamSamples += samples
+		adjustment.HamTokenCount += tokens
+	}
+}
+
+/*
+This function joins the title and content of a thread in the same way as the samples of threads, leaving out empty titles.
+*/
+func spamContent(title, content string) string {
+	if title == "" {
+		return content
+	}
+
+	return title + "\n" + content
+}
+
+/*
+This function gets the probability above which content is held as spam from the 'SPAM_THRESHOLD' environment variable.
+The default threshold is used if it is not found in the environment or is not between 0 and 1.
+*/
+func getSpamThreshold() float64 {
+	godotenv.Load(".env")
+
+	threshold, err := strconv.ParseFloat(os.Getenv("SPAM_THRESHOLD"), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return defaultSpamThreshold
+	}
+
+	return threshold
+}
+
+/*
+This function checks that exactly one of the thread or comment is given, and that whether it is spam is given.
+*/
+func spamLabelValidation(spamLabel spamLabel) error {
+	if (spamLabel.ThreadID != 0) == (spamLabel.CommentID != 0) {
+		return errors.New("exactly one of thread_id or comment_id is required")
+	}
+
+	if spamLabel.Spam == nil {
+		return errors.New("spam is required")
+	}
+
+	return nil
+}
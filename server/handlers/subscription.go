@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+/*
+This handler subscribes the user through jwt to the thread based on the 'thread_id' path parameter,
+so that they are notified of new comments in the thread.
+Each user can only subscribe to a thread once.
+*/
+func (connection *DatabaseConnection) CreateThreadSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	subscription, err := connection.DB.CreateThreadSubscription(r.Context(), database.CreateThreadSubscriptionParams{
+		UserID:   userID,
+		ThreadID: int32(id),
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The user is already subscribed to the thread")
+		} else if ok && pqErr.Code == "23503" {
+			response.RespondWithError(w, http.StatusNotFound, "The thread does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add subscription to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormattedThreadSubscription(subscription))
+}
+
+/*
+This handler unsubscribes the user through jwt from the thread based on the 'thread_id' path parameter.
+*/
+func (connection *DatabaseConnection) DeleteThreadSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	_, err = connection.DB.DeleteThreadSubscription(r.Context(), database.DeleteThreadSubscriptionParams{
+		UserID:   userID,
+		ThreadID: int32(id),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The user is not subscribed to the thread")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete subscription: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/middleware"
+	"github.com/wangyuanchi/shibespace/server/response"
+)
+
+type tagData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Restricted  bool   `json:"restricted"`
+}
+
+type tagSettings struct {
+	Description *string `json:"description"`
+	Restricted  *bool   `json:"restricted"`
+}
+
+type tagAliasData struct {
+	Alias string `json:"alias"`
+}
+
+/*
+This handler first validates the 'prefix', 'window', 'page' and 'limit' query.
+Then, it gets the tags whose name or alias starts with the prefix (case-insensitive),
+together with the number of threads using them, sorted based on the most used tag.
+Unused tags are left out, except for canonical tags when there is no window.
+With a window, only threads created within the window are counted, which gives the trending tags.
+The response may be a 204 status code (no content).
+The total count is included in the header as x-total-count
+*/
+func (connection *DatabaseConnection) GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if len(prefix) > 35 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid prefix query: must be at most 35 characters long")
+		return
+	}
+
+	since, err := getWindowSince(r.URL.Query().Get("window"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid window query: %v", err))
+		return
+	}
+
+	p, l, err := getPageAndLimit(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get page and limit: %v", err))
+		return
+	}
+
+	err = validatePageAndLimit(p, l)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid page or limit: %v", err))
+		return
+	}
+
+	tags, err := connection.DB.GetTags(r.Context(), database.GetTagsParams{
+		Prefix:    prefix,
+		Since:     since,
+		TagLimit:  int32(l),
+		TagOffset: int32((p - 1) * l),
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tags: %v", err))
+		return
+	}
+
+	tagsCount, err := connection.DB.GetTagsCount(r.Context(), database.GetTagsCountParams{
+		Prefix: prefix,
+		Since:  since,
+	})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tags count: %v", err))
+		return
+	}
+	w.Header().Set("x-total-count", strconv.Itoa(int(tagsCount)))
+
+	if tags == nil {
+		response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+	} else {
+		response.RespondWithJSON(w, http.StatusOK, database.FormatTags(tags))
+	}
+}
+
+/*
+This handler gets a single tag based on the 'tag_name' path parameter,
+which can also be one of its aliases.
+*/
+func (connection *DatabaseConnection) GetTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag, err := connection.DB.ResolveTag(r.Context(), chi.URLParam(r, "tag_name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The tag does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag: %v", err))
+		}
+		return
+	}
+
+	tagDetails, err := getTagDetails(r.Context(), connection.DB, tag)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag details: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, tagDetails)
+}
+
+/*
+This handler parses the name, description and restricted flag from the request,
+then creates a canonical tag. Only moderators and admins are allowed to create tags.
+An error is thrown if the name is already used by another tag or alias.
+*/
+func (connection *DatabaseConnection) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	tagData := tagData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&tagData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	err = tagDataValidation(tagData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	_, err = connection.DB.ResolveTag(r.Context(), tagData.Name)
+	if err == nil {
+		response.RespondWithError(w, http.StatusConflict, "The tag or alias already exists")
+		return
+	} else if err != sql.ErrNoRows {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag: %v", err))
+		return
+	}
+
+	tag, err := connection.DB.CreateTag(r.Context(), database.CreateTagParams{
+		Name:        tagData.Name,
+		Description: tagData.Description,
+		Canonical:   true,
+		Restricted:  tagData.Restricted,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The tag or alias already exists")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add tag to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, database.FormatTagDetails(tag, 0, nil))
+}
+
+/*
+This handler updates the description and restricted flag of the tag based on the 'tag_name' path parameter.
+Attributes that are not supplied are left unchanged, and the tag becomes canonical.
+Only moderators and admins are allowed to update tags.
+*/
+func (connection *DatabaseConnection) UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	tagSettings := tagSettings{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&tagSettings)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	if tagSettings.Description != nil && len(*tagSettings.Description) > 500 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: description must be at most 500 characters long")
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	tag, err := connection.DB.ResolveTag(r.Context(), chi.URLParam(r, "tag_name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The tag does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag: %v", err))
+		}
+		return
+	}
+
+	updateTagParams := database.UpdateTagParams{
+		ID:          tag.ID,
+		Description: tag.Description,
+		Restricted:  tag.Restricted,
+	}
+	if tagSettings.Description != nil {
+		updateTagParams.Description = *tagSettings.Description
+	}
+	if tagSettings.Restricted != nil {
+		updateTagParams.Restricted = *tagSettings.Restricted
+	}
+
+	updatedTag, err := connection.DB.UpdateTag(r.Context(), updateTagParams)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update tag: %v", err))
+		return
+	}
+
+	tagDetails, err := getTagDetails(r.Context(), connection.DB, updatedTag)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag details: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, tagDetails)
+}
+
+/*
+This handler adds an alias to the tag based on the 'tag_name' path parameter,
+so that threads created with the alias are given the tag instead.
+If a tag with the same name as the alias already exists, it is merged into the tag,
+moving over its threads, aliases and the categories that require it.
+The tag becomes restricted if either of the tags was restricted. Only moderators and admins are allowed to add aliases.
+*/
+func (connection *DatabaseConnection) CreateTagAliasHandler(w http.ResponseWriter, r *http.Request) {
+	tagAliasData := tagAliasData{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&tagAliasData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	if len(tagAliasData.Alias) < 1 || len(tagAliasData.Alias) > 35 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: alias must be between 1 and 35 characters long")
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	tag, err := connection.DB.ResolveTag(r.Context(), chi.URLParam(r, "tag_name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The tag does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag: %v", err))
+		}
+		return
+	}
+
+	if strings.EqualFold(tag.Name, tagAliasData.Alias) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: alias must be different from the name of the tag")
+		return
+	}
+
+	var tagDetails database.FormattedTagDetails
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		mergedTag, err := q.GetTagByName(r.Context(), tagAliasData.Alias)
+		if err == nil {
+			err = q.MoveThreadTags(r.Context(), database.MoveThreadTagsParams{
+				ToTagID:   tag.ID,
+				FromTagID: mergedTag.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = q.MoveTagAliases(r.Context(), database.MoveTagAliasesParams{
+				ToTagID:   tag.ID,
+				FromTagID: mergedTag.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = q.MoveCategoryRequiredTags(r.Context(), database.MoveCategoryRequiredTagsParams{
+				ToTagID:   tag.ID,
+				FromTagID: mergedTag.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			tag, err = q.MergeTagRestricted(r.Context(), database.MergeTagRestrictedParams{
+				Restricted: mergedTag.Restricted,
+				ID:         tag.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = q.DeleteTag(r.Context(), mergedTag.ID)
+			if err != nil {
+				return err
+			}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		_, err = q.CreateTagAlias(r.Context(), database.CreateTagAliasParams{
+			Alias: tagAliasData.Alias,
+			TagID: tag.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		tagDetails, err = getTagDetails(r.Context(), q, tag)
+		return err
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.RespondWithError(w, http.StatusConflict, "The alias already exists")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add alias to database: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, tagDetails)
+}
+
+/*
+This handler removes an alias based on the 'tag_name' and 'alias' path parameters.
+Threads that were given the tag through the alias keep the tag.
+Only moderators and admins are allowed to remove aliases.
+*/
+func (connection *DatabaseConnection) DeleteTagAliasHandler(w http.ResponseWriter, r *http.Request) {
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	tag, err := connection.DB.GetTagByName(r.Context(), chi.URLParam(r, "tag_name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The tag does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get tag: %v", err))
+		}
+		return
+	}
+
+	_, err = connection.DB.DeleteTagAlias(r.Context(), database.DeleteTagAliasParams{
+		Alias: chi.URLParam(r, "alias"),
+		TagID: tag.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The alias does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete alias: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
+}
+
+/*
+This function resolves the given tag names through their aliases into the tags to be applied,
+leaving out names that resolve to a tag which was already resolved.
+Tags that do not exist yet are returned with a zero ID, and are created when applied.
+Restricted tags can only be applied by moderators and admins, otherwise 403 is returned.
+*/
+func (connection *DatabaseConnection) resolveTags(ctx context.Context, names []string, role string) ([]database.Tag, int, error) {
+	tags := []database.Tag{}
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		tag, err := connection.DB.ResolveTag(ctx, name)
+		if err == sql.ErrNoRows {
+			tag = database.Tag{Name: name}
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to get tag: %v", err)
+		}
+
+		if tag.Restricted && role != middleware.RoleModerator && role != middleware.RoleAdmin {
+			return nil, http.StatusForbidden, fmt.Errorf("tag '%s' can only be applied by moderators", tag.Name)
+		}
+
+		if seen[strings.ToLower(tag.Name)] {
+			continue
+		}
+		seen[strings.ToLower(tag.Name)] = true
+
+		tags = append(tags, tag)
+	}
+
+	return tags, http.StatusOK, nil
+}
+
+/*
+This function applies the resolved tags to the thread in the given order,
+creating the tags that do not exist yet. The names of the applied tags are returned.
+*/
+func applyThreadTags(ctx context.Context, q *database.Queries, threadID int32, tags []database.Tag) ([]string, error) {
+	names := []string{}
+	var tagIDs []int32
+
+	for _, tag := range tags {
+		if tag.ID == 0 {
+			createdTag, err := q.UpsertTag(ctx, tag.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add tag to database: %v", err)
+			}
+			tag = createdTag
+		}
+
+		if slices.Contains(tagIDs, tag.ID) {
+			continue
+		}
+
+		err := q.CreateThreadTag(ctx, database.CreateThreadTagParams{
+			ThreadID: threadID,
+			TagID:    tag.ID,
+			Position: int16(len(tagIDs)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add thread tag to database: %v", err)
+		}
+
+		tagIDs = append(tagIDs, tag.ID)
+		names = append(names, tag.Name)
+	}
+
+	return names, nil
+}
+
+/*
+This function fills in the tags of the given threads,
+using a single query regardless of the number of threads.
+*/
+func (connection *DatabaseConnection) addThreadsTags(ctx context.Context, threads []database.FormattedThread) error {
+	threadIDs := make([]int32, len(threads))
+	indexes := make(map[int32]int)
+	for i, thread := range threads {
+		threadIDs[i] = thread.ID
+		indexes[thread.ID] = i
+	}
+
+	tags, err := connection.DB.GetThreadsTags(ctx, threadIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get thread tags: %v", err)
+	}
+
+	for _, tag := range tags {
+		i := indexes[tag.ThreadID]
+		threads[i].Tags = append(threads[i].Tags, tag.Name)
+	}
+
+	return nil
+}
+
+/*
+This function gets the number of threads using the tag and its aliases,
+then formats them together with the tag.
+*/
+func getTagDetails(ctx context.Context, q *database.Queries, tag database.Tag) (database.FormattedTagDetails, error) {
+	count, err := q.GetTagThreadsCount(ctx, tag.ID)
+	if err != nil {
+		return database.FormattedTagDetails{}, fmt.Errorf("failed to get tag threads count: %v", err)
+	}
+
+	aliases, err := q.GetTagAliases(ctx, tag.ID)
+	if err != nil {
+		return database.FormattedTagDetails{}, fmt.Errorf("failed to get tag aliases: %v", err)
+	}
+
+	return database.FormatTagDetails(tag, count, aliases), nil
+}
+
+/*
+This function checks if the length of the name is between 1 and 35 characters,
+and that the description is at most 500 characters.
+*/
+func tagDataValidation(tagData tagData) error {
+	if len(tagData.Name) < 1 || len(tagData.Name) > 35 {
+		return errors.New("name must be between 1 and 35 characters long")
+	}
+
+	if len(tagData.Description) > 500 {
+		return errors.New("description must be at most 500 characters long")
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -9,24 +10,48 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/wangyuanchi/shibespace/server/internal/database"
 	"github.com/wangyuanchi/shibespace/server/middleware"
 	"github.com/wangyuanchi/shibespace/server/response"
 )
 
+// The errors for changing a thread in a state that does not allow it, which are also returned from transactions
+var (
+	errThreadArchived = errors.New("the thread is archived and is read-only")
+	errThreadLocked   = errors.New("the thread is locked and does not accept new comments")
+)
+
 type threadData struct {
-	Title   string   `json:"title"`
-	Content string   `json:"content"`
-	Tags    []string `json:"tags"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	Tags       []string  `json:"tags"`
+	CategoryID int32     `json:"category_id"`
+	Poll       *pollData `json:"poll"`
 }
 
 type threadContent struct {
 	Content string `json:"content"`
 }
 
+type threadState struct {
+	Pinned   *bool `json:"pinned"`
+	Locked   *bool `json:"locked"`
+	Archived *bool `json:"archived"`
+}
+
 /*
-This handler parses the title, content and tags from the request.
+This handler parses the title, content, tags and category ID from the request.
 It conducts input validation, then it gets the creator through jwt.
+The tags are resolved through their aliases into canonical tags,
+and restricted tags can only be applied by moderators and admins.
+The posting rules of the category, which are the minimum role and required tags, are checked as well.
+The title and content are checked against the auto-moderation rules, which can reject them, replace text in them,
+report the thread, or hold it for review, in which case the mentioned users are only notified once it is released.
+The content is rendered from Markdown into sanitized HTML, which is stored together with it.
+The optional poll is created together with the thread.
+The creator is subscribed to the thread unless they chose not to be,
+and the mentioned users are notified in the same transaction.
 The entire row for the thread is returned, which additionally includes the
 ID of the thread and the timestamp it was created and last updated.
 */
@@ -45,28 +70,163 @@ func (connection *DatabaseConnection) CreateThreadHandler(w http.ResponseWriter,
 		return
 	}
 
+	if threadData.CategoryID == 0 {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: category_id is required")
+		return
+	}
+
+	if threadData.Poll != nil {
+		err = pollDataValidation(*threadData.Poll)
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input: %v", err))
+			return
+		}
+	}
+
 	userID, statusCode, err := middleware.JWTExtractUserID(connection.DB, r)
 	if err != nil {
 		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
 		return
 	}
 
-	thread, err := connection.DB.CreateThread(r.Context(), database.CreateThreadParams{
-		Title:     threadData.Title,
-		Content:   threadData.Content,
-		Tags:      threadData.Tags,
-		CreatorID: userID,
+	role, err := connection.DB.GetUserRole(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user role: %v", err))
+		return
+	}
+
+	tags, statusCode, err := connection.resolveTags(r.Context(), threadData.Tags, role)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to resolve tags: %v", err))
+		return
+	}
+
+	category, err := connection.DB.GetCategory(r.Context(), threadData.CategoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusBadRequest, "The category does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get category: %v", err))
+		}
+		return
+	}
+
+	statusCode, err = connection.checkCategoryRules(r.Context(), category, role, tags)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed category rules check: %v", err))
+		return
+	}
+
+	moderation, err := connection.moderateContent(r.Context(), userID, threadData.Title, threadData.Content)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to moderate content: %v", err))
+		return
+	}
+	if moderation.Rejected {
+		respondWithRejection(w, moderation)
+		return
+	}
+
+	// Replacements can leave the content invalid, in which case it is rejected as well
+	moderatedData := threadData
+	moderatedData.Title = moderation.Title
+	moderatedData.Content = moderation.Body
+	err = threadDataValidation(moderatedData)
+	if err != nil {
+		response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("The content was rejected: %v", err))
+		return
+	}
+
+	contentHTML, err := connection.renderContent(r.Context(), moderation.Body)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render content: %v", err))
+		return
+	}
+
+	var thread database.Thread
+	var tagNames []string
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		thread, err = q.CreateThread(r.Context(), database.CreateThreadParams{
+			Title:       moderation.Title,
+			Content:     moderation.Body,
+			ContentHTML: contentHTML,
+			CreatorID:   userID,
+			CategoryID:  category.ID,
+			Held:        moderation.Held,
+		})
+		if err != nil {
+			return err
+		}
+
+		tagNames, err = applyThreadTags(r.Context(), q, thread.ID, tags)
+		if err != nil {
+			return err
+		}
+
+		if threadData.Poll != nil {
+			err = createThreadPoll(r.Context(), q, thread.ID, *threadData.Poll)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = linkContentAttachments(r.Context(), q, thread.ID, sql.NullInt32{}, thread.Content)
+		if err != nil {
+			return err
+		}
+
+		err = q.AutoSubscribeThread(r.Context(), database.AutoSubscribeThreadParams{
+			ThreadID: thread.ID,
+			UserID:   userID,
+			Created:  true,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = reportModeratedContent(r.Context(), q, reportTarget{
+			TargetType:    "thread",
+			ThreadID:      sql.NullInt32{Int32: thread.ID, Valid: true},
+			TargetUserID:  userID,
+			TargetTitle:   thread.Title,
+			TargetContent: thread.Content,
+		}, moderation)
+		if err != nil {
+			return err
+		}
+
+		if thread.Held {
+			return nil
+		}
+
+		return createContentNotifications(r.Context(), q, contentNotifications{
+			ActorID:  userID,
+			ThreadID: thread.ID,
+			Content:  thread.Content,
+		})
 	})
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add thread to database: %v", err))
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusCreated, database.FormattedThread(thread))
+	formattedThread := database.FormatThread(thread)
+	formattedThread.Tags = tagNames
+
+	formattedThread.Poll, err = connection.getThreadPoll(r.Context(), thread.ID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get poll: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusCreated, formattedThread)
 }
 
 /*
 This handler gets a thread based on the 'thread_id' path parameter.
+Held threads can only be seen by their creator, moderators and admins.
+The tags and reactions are included, marking the reactions added by the viewer if logged in.
+The poll of the thread is included with its results and the options voted by the viewer, if there is one.
 */
 func (connection *DatabaseConnection) GetThreadHandler(w http.ResponseWriter, r *http.Request) {
 	threadID := chi.URLParam(r, "thread_id")
@@ -86,14 +246,47 @@ func (connection *DatabaseConnection) GetThreadHandler(w http.ResponseWriter, r
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusOK, database.FormattedThread(thread))
+	viewerID, statusCode, err := middleware.JWTExtractOptionalUserID(connection.DB, r)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed to extract username: %v", err))
+		return
+	}
+
+	if thread.Held {
+		visible, err := connection.canViewHeld(r.Context(), viewerID, thread.CreatorID)
+		if err != nil {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user role: %v", err))
+			return
+		}
+		if !visible {
+			response.RespondWithError(w, http.StatusNotFound, "The thread does not exist")
+			return
+		}
+	}
+
+	formattedThreads, err := connection.formatThreads(r.Context(), []database.Thread{thread}, viewerID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format thread: %v", err))
+		return
+	}
+
+	formattedThreads[0].Poll, err = connection.getThreadPoll(r.Context(), thread.ID, viewerID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get poll: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedThreads[0])
 }
 
 /*
-This handler updates a thread's content (and also updated_timestamp).
+This handler updates a thread's content (and also content_html and updated_timestamp).
 It gets the thread based on the 'thread_id' path parameter,
 then parses and conducts input validation on the content.
-Only the creator of the thread is allowed to do update the content.
+Only the creator of the thread is allowed to do update the content,
+and archived threads cannot be updated.
+The content is checked against the auto-moderation rules in the same way as CreateThreadHandler.
+Newly mentioned users are notified in the same transaction, unless the thread is held.
 */
 func (connection *DatabaseConnection) UpdateThreadContentHandler(w http.ResponseWriter, r *http.Request) {
 	threadID := chi.URLParam(r, "thread_id")
@@ -131,27 +324,110 @@ func (connection *DatabaseConnection) UpdateThreadContentHandler(w http.Response
 		return
 	}
 
-	_, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
+	userID, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
 	if err != nil {
 		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed jwt matching check: %v", err))
 		return
 	}
 
-	updatedThread, err := connection.DB.UpdateThreadContent(r.Context(), database.UpdateThreadContentParams{
-		ID:      int32(id),
-		Content: threadContent.Content,
+	statusCode, err = connection.checkThreadWritable(r.Context(), int32(id), userID, false)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	moderation, err := connection.moderateContent(r.Context(), creatorID, "", threadContent.Content)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to moderate content: %v", err))
+		return
+	}
+	if moderation.Rejected {
+		respondWithRejection(w, moderation)
+		return
+	}
+
+	// Replacements can leave the content invalid, in which case it is rejected as well
+	err = threadDataValidation(threadData{
+		Title:   "Valid Title",
+		Content: moderation.Body,
+		Tags:    []string{},
 	})
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update thread content: %v", err))
+		response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("The content was rejected: %v", err))
+		return
+	}
+
+	contentHTML, err := connection.renderContent(r.Context(), moderation.Body)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render content: %v", err))
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusOK, database.FormattedUpdatedThread(updatedThread))
+	var updatedThread database.UpdateThreadContentRow
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err = lockThreadWritable(r.Context(), q, int32(id), false)
+		if err != nil {
+			return err
+		}
+
+		updatedThread, err = q.UpdateThreadContent(r.Context(), database.UpdateThreadContentParams{
+			ID:          int32(id),
+			Content:     moderation.Body,
+			ContentHTML: contentHTML,
+			Held:        moderation.Held,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = linkContentAttachments(r.Context(), q, int32(id), sql.NullInt32{}, updatedThread.Content)
+		if err != nil {
+			return err
+		}
+
+		err = reportModeratedContent(r.Context(), q, reportTarget{
+			TargetType:    "thread",
+			ThreadID:      sql.NullInt32{Int32: int32(id), Valid: true},
+			TargetUserID:  creatorID,
+			TargetTitle:   updatedThread.Title,
+			TargetContent: updatedThread.Content,
+		}, moderation)
+		if err != nil {
+			return err
+		}
+
+		if updatedThread.Held {
+			return nil
+		}
+
+		return createContentNotifications(r.Context(), q, contentNotifications{
+			ActorID:  creatorID,
+			ThreadID: int32(id),
+			Content:  updatedThread.Content,
+		})
+	})
+	if err != nil {
+		if isThreadStateError(err) {
+			response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("Failed thread state check: %v", err))
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update thread content: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormattedUpdatedThread{
+		Content:          updatedThread.Content,
+		ContentHTML:      updatedThread.ContentHTML,
+		UpdatedTimestamp: updatedThread.UpdatedTimestamp,
+		Held:             updatedThread.Held,
+	})
 }
 
 /*
 This handler deletes a thread based on the 'thread_id' path parameter.
-Only the creator of the thread is allowed to do delete the thread.
+Only the creator of the thread is allowed to do delete the thread,
+and archived threads cannot be deleted since they are read-only.
+Moderators can still remove archived threads by resolving a report case about them.
 */
 func (connection *DatabaseConnection) DeleteThreadHandler(w http.ResponseWriter, r *http.Request) {
 	threadID := chi.URLParam(r, "thread_id")
@@ -171,21 +447,245 @@ func (connection *DatabaseConnection) DeleteThreadHandler(w http.ResponseWriter,
 		return
 	}
 
-	_, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
+	userID, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, creatorID.String())
 	if err != nil {
 		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed jwt matching check: %v", err))
 		return
 	}
 
-	_, err = connection.DB.DeleteThread(r.Context(), int32(id))
+	statusCode, err = connection.checkThreadWritable(r.Context(), int32(id), userID, false)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed thread state check: %v", err))
+		return
+	}
+
+	err = connection.withTx(r.Context(), func(q *database.Queries) error {
+		err := lockThreadWritable(r.Context(), q, int32(id), false)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.DeleteThread(r.Context(), int32(id))
+		return err
+	})
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete thread: %v", err))
+		if isThreadStateError(err) {
+			response.RespondWithError(w, http.StatusForbidden, fmt.Sprintf("Failed thread state check: %v", err))
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete thread: %v", err))
+		}
 		return
 	}
 
 	response.RespondWithJSON(w, http.StatusNoContent, struct{}{})
 }
 
+/*
+This handler updates whether the thread based on the 'thread_id' path parameter is pinned, locked or archived.
+Attributes that are not supplied are left unchanged.
+Unarchiving a thread counts as activity, so that it is not archived again automatically right away.
+Only moderators and admins are allowed to update the state of threads.
+*/
+func (connection *DatabaseConnection) UpdateThreadStateHandler(w http.ResponseWriter, r *http.Request) {
+	threadID := chi.URLParam(r, "thread_id")
+	id, err := strconv.Atoi(threadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid thread ID: %v", err))
+		return
+	}
+
+	threadState := threadState{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&threadState)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	moderatorID, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleModerator, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	thread, err := connection.DB.GetThread(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The thread does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get thread: %v", err))
+		}
+		return
+	}
+
+	updateThreadStateParams := database.UpdateThreadStateParams{
+		ID:       thread.ID,
+		Pinned:   thread.Pinned,
+		Locked:   thread.Locked,
+		Archived: thread.Archived,
+	}
+	if threadState.Pinned != nil {
+		updateThreadStateParams.Pinned = *threadState.Pinned
+	}
+	if threadState.Locked != nil {
+		updateThreadStateParams.Locked = *threadState.Locked
+	}
+	if threadState.Archived != nil {
+		updateThreadStateParams.Archived = *threadState.Archived
+	}
+
+	updatedThread, err := connection.DB.UpdateThreadState(r.Context(), updateThreadStateParams)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update thread state: %v", err))
+		return
+	}
+
+	formattedThreads, err := connection.formatThreads(r.Context(), []database.Thread{updatedThread},
+		uuid.NullUUID{UUID: moderatorID, Valid: true})
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to format thread: %v", err))
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, formattedThreads[0])
+}
+
+/*
+This function checks if the thread can be changed by the user based on its state, returning a 403 status code if not.
+Archived threads are read-only, while locked threads only reject new comments.
+Held threads do not exist for users who cannot see them.
+*/
+func (connection *DatabaseConnection) checkThreadWritable(ctx context.Context, threadID int32, userID uuid.UUID, commenting bool) (int, error) {
+	state, err := connection.DB.GetThreadState(ctx, threadID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.New("the thread does not exist")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("failed to get thread state: %v", err)
+	}
+
+	if state.Held {
+		statusCode, err := connection.heldCheck(ctx, userID, state.CreatorID, "thread")
+		if err != nil {
+			return statusCode, err
+		}
+	}
+
+	return threadStateCheck(state.Locked, state.Archived, commenting)
+}
+
+/*
+This function checks if the thread of the comment can be changed by the user based on its state,
+in the same way as checkThreadWritable.
+*/
+func (connection *DatabaseConnection) checkCommentWritable(ctx context.Context, commentID int32, userID uuid.UUID) (int, error) {
+	state, err := connection.DB.GetCommentThreadState(ctx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, errors.New("the comment does not exist")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("failed to get thread state: %v", err)
+	}
+
+	if state.Held {
+		statusCode, err := connection.heldCheck(ctx, userID, state.CreatorID, "comment")
+		if err != nil {
+			return statusCode, err
+		}
+	}
+	if state.CommentHeld {
+		statusCode, err := connection.heldCheck(ctx, userID, state.CommentCreatorID, "comment")
+		if err != nil {
+			return statusCode, err
+		}
+	}
+
+	return threadStateCheck(state.Locked, state.Archived, false)
+}
+
+/*
+This function returns a 404 status code if the user cannot see the held content, as if it does not exist.
+*/
+func (connection *DatabaseConnection) heldCheck(ctx context.Context, userID, creatorID uuid.UUID, target string) (int, error) {
+	visible, err := connection.canViewHeld(ctx, uuid.NullUUID{UUID: userID, Valid: true}, creatorID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to check held content access: %v", err)
+	}
+	if !visible {
+		return http.StatusNotFound, fmt.Errorf("the %s does not exist", target)
+	}
+
+	return http.StatusOK, nil
+}
+
+/*
+This function returns the error for changing a thread in the given state, if any.
+*/
+func threadStateCheck(locked, archived, commenting bool) (int, error) {
+	if archived {
+		return http.StatusForbidden, errThreadArchived
+	}
+	if commenting && locked {
+		return http.StatusForbidden, errThreadLocked
+	}
+
+	return http.StatusOK, nil
+}
+
+/*
+This function locks the thread until the end of the transaction, then checks its state again in the same way as threadStateCheck,
+so that it cannot be archived or locked between the check before the transaction and the change.
+The lock is the one that updating the thread takes, since the transactions that call this also update the thread,
+and taking a weaker lock first could deadlock them.
+*/
+func lockThreadWritable(ctx context.Context, q *database.Queries, threadID int32, commenting bool) error {
+	state, err := q.LockThreadState(ctx, threadID)
+	if err != nil {
+		return err
+	}
+
+	_, err = threadStateCheck(state.Locked, state.Archived, commenting)
+	return err
+}
+
+/*
+This function checks if the error is from a thread that cannot be changed because of its state.
+*/
+func isThreadStateError(err error) bool {
+	return errors.Is(err, errThreadArchived) || errors.Is(err, errThreadLocked)
+}
+
+/*
+This function formats the threads, then fills in their tags, reactions, whether the viewer bookmarked them
+and what the viewer has not read in them.
+Each of them is fetched with a single query regardless of the number of threads.
+*/
+func (connection *DatabaseConnection) formatThreads(ctx context.Context, threads []database.Thread, viewerID uuid.NullUUID) ([]database.FormattedThread, error) {
+	formattedThreads := database.FormatThreads(threads)
+
+	err := connection.addThreadsTags(ctx, formattedThreads)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.addThreadsReactions(ctx, formattedThreads, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.addThreadsBookmarks(ctx, formattedThreads, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.addThreadsReads(ctx, formattedThreads, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return formattedThreads, nil
+}
+
 /*
 This function checks if the length of the title is between 1 and 255 characters.
 It also checks if the length of the content is at least 1 character.
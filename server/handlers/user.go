@@ -25,6 +25,15 @@ type userData struct {
 	Password string `json:"password"`
 }
 
+type userRole struct {
+	Role string `json:"role"`
+}
+
+type userSettings struct {
+	AutoWatchCreated   *bool `json:"auto_watch_created"`
+	AutoWatchCommented *bool `json:"auto_watch_commented"`
+}
+
 /*
 This handler parses the username and password from the request.
 It conducts input validation, then it hashes the password,
@@ -74,7 +83,8 @@ func (connection *DatabaseConnection) CreateUserHandler(w http.ResponseWriter, r
 /*
 This handler authenticates user data sent from the HTTP request.
 Any failed authentication will be responded with "The username or password is incorrect".
-It returns a JSON web token (that stores the user ID) as a cookie if authentication is successful.
+It returns a JSON web token (that stores the user ID) as a cookie if authentication is successful,
+unless the user is banned.
 The response body contains the user's username.
 */
 func (connection *DatabaseConnection) AuthenticateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -108,6 +118,11 @@ func (connection *DatabaseConnection) AuthenticateUserHandler(w http.ResponseWri
 		return
 	}
 
+	if UserIDAndPassHash.Banned {
+		response.RespondWithError(w, http.StatusForbidden, "The user is banned")
+		return
+	}
+
 	jwt, expire, err := middleware.GenerateJWT(UserIDAndPassHash.ID)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate JSON web token: %v", err))
@@ -195,6 +210,129 @@ func (connection *DatabaseConnection) GetUserInfoHandler(w http.ResponseWriter,
 	response.RespondWithJSON(w, http.StatusOK, database.FormattedUserInfo(userInfo))
 }
 
+/*
+This handler updates the role of the user based on the 'user_id' path parameter.
+Only admins are allowed to update roles.
+*/
+func (connection *DatabaseConnection) UpdateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user ID: %v", err))
+		return
+	}
+
+	userRole := userRole{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&userRole)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	if userRole.Role != middleware.RoleUser && userRole.Role != middleware.RoleModerator && userRole.Role != middleware.RoleAdmin {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid input: role must be one of user, moderator, admin")
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckRole(connection.DB, r, middleware.RoleAdmin)
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed role check: %v", err))
+		return
+	}
+
+	userInfo, err := connection.DB.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
+		ID:   id,
+		Role: userRole.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The user does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update user role: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormattedUserInfo(userInfo))
+}
+
+/*
+This handler gets the settings of the user based on the 'user_id' path parameter.
+Users can only get their own settings.
+*/
+func (connection *DatabaseConnection) GetUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user ID: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, id.String())
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed jwt matching check: %v", err))
+		return
+	}
+
+	settings, err := connection.DB.GetUserSettings(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The user does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user settings: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormattedUserSettings(settings))
+}
+
+/*
+This handler updates the settings of the user based on the 'user_id' path parameter.
+Only the settings that are given in the request are updated.
+Users can only update their own settings.
+*/
+func (connection *DatabaseConnection) UpdateUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user ID: %v", err))
+		return
+	}
+
+	userSettings := userSettings{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&userSettings)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse from JSON: %v", err))
+		return
+	}
+
+	_, statusCode, err := middleware.JWTCheckMatching(connection.DB, r, id.String())
+	if err != nil {
+		response.RespondWithError(w, statusCode, fmt.Sprintf("Failed jwt matching check: %v", err))
+		return
+	}
+
+	params := database.UpdateUserSettingsParams{ID: id}
+	if userSettings.AutoWatchCreated != nil {
+		params.AutoWatchCreated = sql.NullBool{Bool: *userSettings.AutoWatchCreated, Valid: true}
+	}
+	if userSettings.AutoWatchCommented != nil {
+		params.AutoWatchCommented = sql.NullBool{Bool: *userSettings.AutoWatchCommented, Valid: true}
+	}
+
+	settings, err := connection.DB.UpdateUserSettings(r.Context(), params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "The user does not exist")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update user settings: %v", err))
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, database.FormattedUserSettings(settings))
+}
+
 /*
 This function checks if the length of the username is between 3 and 20 characters and
 matches the conventional regex. It also checks if the password is at least 8 characters.
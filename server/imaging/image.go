@@ -0,0 +1,189 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// The maximum number of pixels of an image, so that decoding a small file cannot take up a huge amount of memory,
+// which is 16 megapixels or about 64 MB once decoded
+const MaxPixels = 16_000_000
+
+// The sizes of the thumbnails, which are the maximum width and height in pixels
+var ThumbnailSizes = []int{256, 1024}
+
+var ErrTooLarge = errors.New("image has too many pixels")
+
+/*
+This function checks that the image can be decoded and that it is not larger than the maximum number of pixels,
+without decoding the whole image.
+*/
+func CheckImage(data []byte, mediaType string) error {
+	var config image.Config
+	var err error
+	switch mediaType {
+	case "image/jpeg":
+		config, err = jpeg.DecodeConfig(bytes.NewReader(data))
+	case "image/png":
+		config, err = png.DecodeConfig(bytes.NewReader(data))
+	case "image/gif":
+		config, err = gif.DecodeConfig(bytes.NewReader(data))
+	case "image/webp":
+		config, err = webp.DecodeConfig(bytes.NewReader(data))
+	default:
+		return errors.New("unsupported image type")
+	}
+	if err != nil {
+		return err
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return ErrTooLarge
+	}
+
+	return nil
+}
+
+/*
+This function creates a thumbnail of the image that fits within the given size, keeping its aspect ratio.
+Images that are already small enough are not scaled up. Opaque thumbnails are encoded as JPEG
+and thumbnails with transparency are encoded as PNG, returning the encoded thumbnail, its type and its size.
+Only the first frame of animated images is used.
+*/
+func Thumbnail(data []byte, mediaType string, size int) ([]byte, string, image.Rectangle, error) {
+	err := CheckImage(data, mediaType)
+	if err != nil {
+		return nil, "", image.Rectangle{}, err
+	}
+
+	src, err := decode(data, mediaType)
+	if err != nil {
+		return nil, "", image.Rectangle{}, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(height*size/width, 1)
+		} else {
+			width, height = max(width*size/height, 1), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if dst.Opaque() {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", dst.Bounds(), err
+	}
+
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), "image/png", dst.Bounds(), err
+}
+
+/*
+This function rotates and flips a JPEG according to its EXIF orientation, re-encoding it at a high quality.
+It is only called for orientations other than 1, since upright JPEGs do not need to be re-encoded.
+*/
+func applyOrientation(data []byte, orientation int) ([]byte, error) {
+	err := CheckImage(data, "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, orient(src, orientation), &jpeg.Options{Quality: 92})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+/*
+This function transforms the image so that it is upright for the EXIF orientation,
+writing every pixel straight into its place in a single new buffer.
+Orientations 5 to 8 swap the width and the height.
+*/
+func orient(src image.Image, orientation int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	// Decoded JPEGs are almost always YCbCr, which is read directly instead of through At to avoid an allocation per pixel
+	ycbcr, isYCbCr := src.(*image.YCbCr)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			default:
+				dx, dy = x, y
+			}
+
+			d := dst.PixOffset(dx, dy)
+			if isYCbCr {
+				c := ycbcr.YCbCrAt(bounds.Min.X+x, bounds.Min.Y+y)
+				dst.Pix[d], dst.Pix[d+1], dst.Pix[d+2] = color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+				dst.Pix[d+3] = 0xFF
+				continue
+			}
+
+			c := color.RGBAModel.Convert(src.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.RGBA)
+			dst.Pix[d], dst.Pix[d+1], dst.Pix[d+2], dst.Pix[d+3] = c.R, c.G, c.B, c.A
+		}
+	}
+
+	return dst
+}
+
+func decode(data []byte, mediaType string) (image.Image, error) {
+	switch mediaType {
+	case "image/jpeg":
+		return jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		return png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		return gif.Decode(bytes.NewReader(data))
+	case "image/webp":
+		return webp.Decode(bytes.NewReader(data))
+	default:
+		return nil, errors.New("unsupported image type")
+	}
+}
@@ -0,0 +1,266 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformed = errors.New("malformed image")
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// The PNG chunks that hold metadata rather than anything needed to show the image
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+/*
+This function removes the metadata of the image, such as the EXIF data with the GPS location of photos,
+without decoding the image so that its quality is kept.
+JPEG photos that are rotated through their EXIF orientation are rotated for real first,
+since the orientation is lost together with the rest of the EXIF data.
+GIF images do not have any EXIF data, so they are returned as is.
+*/
+func StripMetadata(data []byte, mediaType string) ([]byte, error) {
+	switch mediaType {
+	case "image/jpeg":
+		orientation := jpegOrientation(data)
+		if orientation > 1 {
+			rotated, err := applyOrientation(data, orientation)
+			if err != nil {
+				return nil, err
+			}
+			data = rotated
+		}
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	default:
+		return data, nil
+	}
+}
+
+/*
+This function removes the APP1 (EXIF and XMP), APP13 (IPTC) and comment segments of a JPEG,
+keeping the rest of the segments and the compressed image data after the start of scan as is.
+*/
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, errMalformed
+		}
+
+		// Markers can be padded with any number of fill bytes
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i >= len(data) {
+			return nil, errMalformed
+		}
+		marker := data[i]
+		i++
+
+		// Markers without a length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write([]byte{0xFF, marker})
+			continue
+		}
+		if marker == 0xD9 {
+			out.Write([]byte{0xFF, marker})
+			return out.Bytes(), nil
+		}
+
+		if i+2 > len(data) {
+			return nil, errMalformed
+		}
+		length := int(binary.BigEndian.Uint16(data[i:]))
+		if length < 2 || i+length > len(data) {
+			return nil, errMalformed
+		}
+
+		// The compressed image data follows the start of scan, and contains no more metadata
+		if marker == 0xDA {
+			out.Write([]byte{0xFF, marker})
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			out.Write([]byte{0xFF, marker})
+			out.Write(data[i : i+length])
+		}
+		i += length
+	}
+
+	return nil, errMalformed
+}
+
+/*
+This function removes the metadata chunks of a PNG, keeping the rest of the chunks as is.
+*/
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+
+		// The length and type, the data, and the CRC
+		end := i + 8 + length + 4
+		if end > len(data) || end < i {
+			return nil, errMalformed
+		}
+
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[i:end])
+		}
+		i = end
+
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+
+	return nil, errMalformed
+}
+
+/*
+This function removes the EXIF and XMP chunks of a WebP, clearing their flags in the extended header
+and updating the size of the RIFF container to match.
+*/
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+
+		// Chunks are padded to an even size
+		end := i + 8 + size + size%2
+		if end > len(data) || end < i {
+			return nil, errMalformed
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := bytes.Clone(data[i:end])
+			if size > 0 {
+				// The flags of the EXIF and XMP chunks
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+
+	return stripped, nil
+}
+
+/*
+This function gets the EXIF orientation of a JPEG from its APP1 segment, which is 1 (upright)
+if the JPEG does not have one. The orientations are numbered from 1 to 8 as in the EXIF specification.
+*/
+func jpegOrientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+
+	return 1
+}
+
+/*
+This function reads the orientation tag from the first IFD of the TIFF structure in the EXIF data.
+*/
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		// The orientation tag is a single SHORT stored directly in the entry
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
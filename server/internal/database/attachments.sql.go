@@ -0,0 +1,240 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: attachments.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (blob_sha256, uploader_id, filename)
+VALUES ($1, $2, $3)
+RETURNING id, blob_sha256, uploader_id, filename, created_timestamp
+`
+
+type CreateAttachmentParams struct {
+	BlobSha256 string
+	UploaderID uuid.UUID
+	Filename   string
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, createAttachment, arg.BlobSha256, arg.UploaderID, arg.Filename)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.BlobSha256,
+		&i.UploaderID,
+		&i.Filename,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createAttachmentReferences = `-- name: CreateAttachmentReferences :exec
+INSERT INTO attachment_references (attachment_id, thread_id, comment_id)
+SELECT attachments.id, $1::INTEGER, $2::INTEGER
+FROM attachments
+WHERE attachments.id = ANY($3::INTEGER[])
+ON CONFLICT DO NOTHING
+`
+
+type CreateAttachmentReferencesParams struct {
+	ThreadID      int32
+	CommentID     sql.NullInt32
+	AttachmentIds []int32
+}
+
+// Links to attachments that do not exist are ignored
+func (q *Queries) CreateAttachmentReferences(ctx context.Context, arg CreateAttachmentReferencesParams) error {
+	_, err := q.db.ExecContext(ctx, createAttachmentReferences, arg.ThreadID, arg.CommentID, pq.Array(arg.AttachmentIds))
+	return err
+}
+
+const deleteBlob = `-- name: DeleteBlob :exec
+DELETE FROM blobs
+WHERE sha256 = $1
+`
+
+func (q *Queries) DeleteBlob(ctx context.Context, sha256 string) error {
+	_, err := q.db.ExecContext(ctx, deleteBlob, sha256)
+	return err
+}
+
+const deleteCommentAttachmentReferences = `-- name: DeleteCommentAttachmentReferences :exec
+DELETE FROM attachment_references
+WHERE comment_id = $1
+`
+
+func (q *Queries) DeleteCommentAttachmentReferences(ctx context.Context, commentID sql.NullInt32) error {
+	_, err := q.db.ExecContext(ctx, deleteCommentAttachmentReferences, commentID)
+	return err
+}
+
+const deleteOrphanedAttachments = `-- name: DeleteOrphanedAttachments :execrows
+DELETE FROM attachments
+WHERE attachments.created_timestamp < $1::TIMESTAMPTZ
+AND NOT EXISTS (
+    SELECT 1 FROM attachment_references
+    WHERE attachment_references.attachment_id = attachments.id
+)
+`
+
+func (q *Queries) DeleteOrphanedAttachments(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOrphanedAttachments, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteThreadAttachmentReferences = `-- name: DeleteThreadAttachmentReferences :exec
+DELETE FROM attachment_references
+WHERE thread_id = $1 AND comment_id IS NULL
+`
+
+func (q *Queries) DeleteThreadAttachmentReferences(ctx context.Context, threadID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteThreadAttachmentReferences, threadID)
+	return err
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT attachments.id, attachments.blob_sha256, attachments.uploader_id, attachments.filename, attachments.created_timestamp, blobs.size, blobs.content_type, blobs.thumbnail_status
+FROM attachments
+INNER JOIN blobs ON blobs.sha256 = attachments.blob_sha256
+WHERE attachments.id = $1
+`
+
+type GetAttachmentRow struct {
+	ID               int32
+	BlobSha256       string
+	UploaderID       uuid.UUID
+	Filename         string
+	CreatedTimestamp time.Time
+	Size             int32
+	ContentType      string
+	ThumbnailStatus  string
+}
+
+func (q *Queries) GetAttachment(ctx context.Context, id int32) (GetAttachmentRow, error) {
+	row := q.db.QueryRowContext(ctx, getAttachment, id)
+	var i GetAttachmentRow
+	err := row.Scan(
+		&i.ID,
+		&i.BlobSha256,
+		&i.UploaderID,
+		&i.Filename,
+		&i.CreatedTimestamp,
+		&i.Size,
+		&i.ContentType,
+		&i.ThumbnailStatus,
+	)
+	return i, err
+}
+
+const getOrphanedBlobs = `-- name: GetOrphanedBlobs :many
+SELECT blobs.sha256 FROM blobs
+WHERE blobs.uploaded_timestamp < $1::TIMESTAMPTZ
+AND NOT EXISTS (
+    SELECT 1 FROM attachments
+    WHERE attachments.blob_sha256 = blobs.sha256
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blob_thumbnails
+    WHERE blob_thumbnails.thumbnail_sha256 = blobs.sha256
+)
+LIMIT $2::INTEGER
+`
+
+type GetOrphanedBlobsParams struct {
+	UploadedBefore time.Time
+	ResultLimit    int32
+}
+
+func (q *Queries) GetOrphanedBlobs(ctx context.Context, arg GetOrphanedBlobsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getOrphanedBlobs, arg.UploadedBefore, arg.ResultLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var sha256 string
+		if err := rows.Scan(&sha256); err != nil {
+			return nil, err
+		}
+		items = append(items, sha256)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOrphanedBlob = `-- name: LockOrphanedBlob :one
+SELECT blobs.sha256 FROM blobs
+WHERE blobs.sha256 = $1
+AND blobs.uploaded_timestamp < $2::TIMESTAMPTZ
+AND NOT EXISTS (
+    SELECT 1 FROM attachments
+    WHERE attachments.blob_sha256 = blobs.sha256
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blob_thumbnails
+    WHERE blob_thumbnails.thumbnail_sha256 = blobs.sha256
+)
+FOR UPDATE SKIP LOCKED
+`
+
+type LockOrphanedBlobParams struct {
+	Sha256         string
+	UploadedBefore time.Time
+}
+
+// Blobs that are being uploaded again were refreshed by the upload, so they are skipped until their grace period is over
+func (q *Queries) LockOrphanedBlob(ctx context.Context, arg LockOrphanedBlobParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, lockOrphanedBlob, arg.Sha256, arg.UploadedBefore)
+	var sha256 string
+	err := row.Scan(&sha256)
+	return sha256, err
+}
+
+const upsertBlob = `-- name: UpsertBlob :one
+INSERT INTO blobs (sha256, size, content_type, thumbnail_status)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (sha256) DO UPDATE
+SET uploaded_timestamp = CURRENT_TIMESTAMP,
+thumbnail_status = CASE WHEN blobs.thumbnail_status = 'none' THEN EXCLUDED.thumbnail_status ELSE blobs.thumbnail_status END
+RETURNING thumbnail_status
+`
+
+type UpsertBlobParams struct {
+	Sha256          string
+	Size            int32
+	ContentType     string
+	ThumbnailStatus string
+}
+
+// Uploading the same content again refreshes the blob, so that it is not garbage collected within the grace period of the upload
+func (q *Queries) UpsertBlob(ctx context.Context, arg UpsertBlobParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, upsertBlob,
+		arg.Sha256,
+		arg.Size,
+		arg.ContentType,
+		arg.ThumbnailStatus,
+	)
+	var thumbnail_status string
+	err := row.Scan(&thumbnail_status)
+	return thumbnail_status, err
+}
@@ -0,0 +1,233 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: automod.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createAutomodRule = `-- name: CreateAutomodRule :one
+INSERT INTO automod_rules (name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled, created_timestamp, updated_timestamp
+`
+
+type CreateAutomodRuleParams struct {
+	Name               string
+	Pattern            string
+	Words              []string
+	MaxLinks           sql.NullInt32
+	MaxAccountAgeHours sql.NullInt32
+	Action             string
+	Message            string
+	Replacement        string
+	Enabled            bool
+}
+
+func (q *Queries) CreateAutomodRule(ctx context.Context, arg CreateAutomodRuleParams) (AutomodRule, error) {
+	row := q.db.QueryRowContext(ctx, createAutomodRule,
+		arg.Name,
+		arg.Pattern,
+		pq.Array(arg.Words),
+		arg.MaxLinks,
+		arg.MaxAccountAgeHours,
+		arg.Action,
+		arg.Message,
+		arg.Replacement,
+		arg.Enabled,
+	)
+	var i AutomodRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Pattern,
+		pq.Array(&i.Words),
+		&i.MaxLinks,
+		&i.MaxAccountAgeHours,
+		&i.Action,
+		&i.Message,
+		&i.Replacement,
+		&i.Enabled,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+	)
+	return i, err
+}
+
+const deleteAutomodRule = `-- name: DeleteAutomodRule :one
+DELETE FROM automod_rules
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteAutomodRule(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, deleteAutomodRule, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getAutomodRule = `-- name: GetAutomodRule :one
+SELECT id, name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled, created_timestamp, updated_timestamp FROM automod_rules
+WHERE id = $1
+`
+
+func (q *Queries) GetAutomodRule(ctx context.Context, id int32) (AutomodRule, error) {
+	row := q.db.QueryRowContext(ctx, getAutomodRule, id)
+	var i AutomodRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Pattern,
+		pq.Array(&i.Words),
+		&i.MaxLinks,
+		&i.MaxAccountAgeHours,
+		&i.Action,
+		&i.Message,
+		&i.Replacement,
+		&i.Enabled,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+	)
+	return i, err
+}
+
+const getAutomodRules = `-- name: GetAutomodRules :many
+SELECT id, name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled, created_timestamp, updated_timestamp FROM automod_rules
+ORDER BY id
+`
+
+func (q *Queries) GetAutomodRules(ctx context.Context) ([]AutomodRule, error) {
+	rows, err := q.db.QueryContext(ctx, getAutomodRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AutomodRule
+	for rows.Next() {
+		var i AutomodRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Pattern,
+			pq.Array(&i.Words),
+			&i.MaxLinks,
+			&i.MaxAccountAgeHours,
+			&i.Action,
+			&i.Message,
+			&i.Replacement,
+			&i.Enabled,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnabledAutomodRules = `-- name: GetEnabledAutomodRules :many
+SELECT id, name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled, created_timestamp, updated_timestamp FROM automod_rules
+WHERE enabled
+ORDER BY id
+`
+
+func (q *Queries) GetEnabledAutomodRules(ctx context.Context) ([]AutomodRule, error) {
+	rows, err := q.db.QueryContext(ctx, getEnabledAutomodRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AutomodRule
+	for rows.Next() {
+		var i AutomodRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Pattern,
+			pq.Array(&i.Words),
+			&i.MaxLinks,
+			&i.MaxAccountAgeHours,
+			&i.Action,
+			&i.Message,
+			&i.Replacement,
+			&i.Enabled,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAutomodRule = `-- name: UpdateAutomodRule :one
+UPDATE automod_rules
+SET name = $2, pattern = $3, words = $4, max_links = $5, max_account_age_hours = $6,
+action = $7, message = $8, replacement = $9, enabled = $10, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled, created_timestamp, updated_timestamp
+`
+
+type UpdateAutomodRuleParams struct {
+	ID                 int32
+	Name               string
+	Pattern            string
+	Words              []string
+	MaxLinks           sql.NullInt32
+	MaxAccountAgeHours sql.NullInt32
+	Action             string
+	Message            string
+	Replacement        string
+	Enabled            bool
+}
+
+func (q *Queries) UpdateAutomodRule(ctx context.Context, arg UpdateAutomodRuleParams) (AutomodRule, error) {
+	row := q.db.QueryRowContext(ctx, updateAutomodRule,
+		arg.ID,
+		arg.Name,
+		arg.Pattern,
+		pq.Array(arg.Words),
+		arg.MaxLinks,
+		arg.MaxAccountAgeHours,
+		arg.Action,
+		arg.Message,
+		arg.Replacement,
+		arg.Enabled,
+	)
+	var i AutomodRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Pattern,
+		pq.Array(&i.Words),
+		&i.MaxLinks,
+		&i.MaxAccountAgeHours,
+		&i.Action,
+		&i.Message,
+		&i.Replacement,
+		&i.Enabled,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+	)
+	return i, err
+}
@@ -0,0 +1,107 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: blocks.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createUserBlock = `-- name: CreateUserBlock :one
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+RETURNING blocker_id, blocked_id, created_timestamp
+`
+
+type CreateUserBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) CreateUserBlock(ctx context.Context, arg CreateUserBlockParams) (UserBlock, error) {
+	row := q.db.QueryRowContext(ctx, createUserBlock, arg.BlockerID, arg.BlockedID)
+	var i UserBlock
+	err := row.Scan(&i.BlockerID, &i.BlockedID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const deleteUserBlock = `-- name: DeleteUserBlock :one
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2
+RETURNING blocker_id, blocked_id, created_timestamp
+`
+
+type DeleteUserBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) DeleteUserBlock(ctx context.Context, arg DeleteUserBlockParams) (UserBlock, error) {
+	row := q.db.QueryRowContext(ctx, deleteUserBlock, arg.BlockerID, arg.BlockedID)
+	var i UserBlock
+	err := row.Scan(&i.BlockerID, &i.BlockedID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const getUserBlocks = `-- name: GetUserBlocks :many
+SELECT user_blocks.blocked_id, users.username, user_blocks.created_timestamp
+FROM user_blocks
+JOIN users ON users.id = user_blocks.blocked_id
+WHERE user_blocks.blocker_id = $1
+ORDER BY user_blocks.created_timestamp DESC
+`
+
+type GetUserBlocksRow struct {
+	BlockedID        uuid.UUID
+	Username         string
+	CreatedTimestamp time.Time
+}
+
+func (q *Queries) GetUserBlocks(ctx context.Context, blockerID uuid.UUID) ([]GetUserBlocksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserBlocks, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserBlocksRow
+	for rows.Next() {
+		var i GetUserBlocksRow
+		if err := rows.Scan(&i.BlockedID, &i.Username, &i.CreatedTimestamp); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isBlockedBetween = `-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = $1 AND blocked_id = ANY($2::UUID[]))
+    OR (blocked_id = $1 AND blocker_id = ANY($2::UUID[]))
+)
+`
+
+type IsBlockedBetweenParams struct {
+	UserID   uuid.UUID
+	OtherIds []uuid.UUID
+}
+
+func (q *Queries) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isBlockedBetween, arg.UserID, pq.Array(arg.OtherIds))
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,418 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createCommentBookmark = `-- name: CreateCommentBookmark :one
+INSERT INTO bookmarks (user_id, thread_id, comment_id, note, folder)
+SELECT $1, comments.thread_id, comments.id, $2, $3
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = $4
+AND (NOT threads.held OR threads.creator_id = $1 OR $5::BOOLEAN)
+AND (NOT comments.held OR comments.creator_id = $1 OR $5::BOOLEAN)
+RETURNING id, user_id, thread_id, comment_id, note, folder, created_timestamp
+`
+
+type CreateCommentBookmarkParams struct {
+	UserID      uuid.UUID
+	Note        string
+	Folder      string
+	CommentID   int32
+	IncludeHeld bool
+}
+
+func (q *Queries) CreateCommentBookmark(ctx context.Context, arg CreateCommentBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, createCommentBookmark,
+		arg.UserID,
+		arg.Note,
+		arg.Folder,
+		arg.CommentID,
+		arg.IncludeHeld,
+	)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Note,
+		&i.Folder,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createThreadBookmark = `-- name: CreateThreadBookmark :one
+INSERT INTO bookmarks (user_id, thread_id, note, folder)
+SELECT $1, threads.id, $2, $3
+FROM threads
+WHERE threads.id = $4
+AND (NOT threads.held OR threads.creator_id = $1 OR $5::BOOLEAN)
+RETURNING id, user_id, thread_id, comment_id, note, folder, created_timestamp
+`
+
+type CreateThreadBookmarkParams struct {
+	UserID      uuid.UUID
+	Note        string
+	Folder      string
+	ThreadID    int32
+	IncludeHeld bool
+}
+
+func (q *Queries) CreateThreadBookmark(ctx context.Context, arg CreateThreadBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, createThreadBookmark,
+		arg.UserID,
+		arg.Note,
+		arg.Folder,
+		arg.ThreadID,
+		arg.IncludeHeld,
+	)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Note,
+		&i.Folder,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const deleteCommentBookmark = `-- name: DeleteCommentBookmark :one
+DELETE FROM bookmarks
+WHERE user_id = $1 AND comment_id = $2
+RETURNING id, user_id, thread_id, comment_id, note, folder, created_timestamp
+`
+
+type DeleteCommentBookmarkParams struct {
+	UserID    uuid.UUID
+	CommentID sql.NullInt32
+}
+
+func (q *Queries) DeleteCommentBookmark(ctx context.Context, arg DeleteCommentBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, deleteCommentBookmark, arg.UserID, arg.CommentID)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Note,
+		&i.Folder,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const deleteThreadBookmark = `-- name: DeleteThreadBookmark :one
+DELETE FROM bookmarks
+WHERE user_id = $1 AND thread_id = $2 AND comment_id IS NULL
+RETURNING id, user_id, thread_id, comment_id, note, folder, created_timestamp
+`
+
+type DeleteThreadBookmarkParams struct {
+	UserID   uuid.UUID
+	ThreadID int32
+}
+
+func (q *Queries) DeleteThreadBookmark(ctx context.Context, arg DeleteThreadBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, deleteThreadBookmark, arg.UserID, arg.ThreadID)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Note,
+		&i.Folder,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const getBookmarkedComments = `-- name: GetBookmarkedComments :many
+SELECT comment_id::INTEGER FROM bookmarks
+WHERE user_id = $1
+AND comment_id = ANY($2::INTEGER[])
+`
+
+type GetBookmarkedCommentsParams struct {
+	UserID     uuid.UUID
+	CommentIds []int32
+}
+
+func (q *Queries) GetBookmarkedComments(ctx context.Context, arg GetBookmarkedCommentsParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkedComments, arg.UserID, pq.Array(arg.CommentIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var comment_id int32
+		if err := rows.Scan(&comment_id); err != nil {
+			return nil, err
+		}
+		items = append(items, comment_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarkedThreads = `-- name: GetBookmarkedThreads :many
+SELECT thread_id FROM bookmarks
+WHERE user_id = $1 AND comment_id IS NULL
+AND thread_id = ANY($2::INTEGER[])
+`
+
+type GetBookmarkedThreadsParams struct {
+	UserID    uuid.UUID
+	ThreadIds []int32
+}
+
+func (q *Queries) GetBookmarkedThreads(ctx context.Context, arg GetBookmarkedThreadsParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkedThreads, arg.UserID, pq.Array(arg.ThreadIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var thread_id int32
+		if err := rows.Scan(&thread_id); err != nil {
+			return nil, err
+		}
+		items = append(items, thread_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarks = `-- name: GetBookmarks :many
+SELECT bookmarks.id, bookmarks.user_id, bookmarks.thread_id, bookmarks.comment_id, bookmarks.note, bookmarks.folder, bookmarks.created_timestamp, threads.title AS thread_title,
+COALESCE(comments.content, threads.content)::TEXT AS content,
+COALESCE(comments.content_html, threads.content_html)::TEXT AS content_html
+FROM bookmarks
+JOIN threads ON threads.id = bookmarks.thread_id
+LEFT JOIN comments ON comments.id = bookmarks.comment_id
+WHERE bookmarks.user_id = $1
+AND NOT threads.held AND (comments.id IS NULL OR NOT comments.held)
+AND ($2::VARCHAR(50) IS NULL OR bookmarks.folder = $2::VARCHAR(50))
+AND ($3::INTEGER IS NULL OR bookmarks.id < $3::INTEGER)
+ORDER BY bookmarks.id DESC
+LIMIT $5 OFFSET $4
+`
+
+type GetBookmarksParams struct {
+	UserID       uuid.UUID
+	Folder       sql.NullString
+	AfterID      sql.NullInt32
+	ResultOffset int32
+	ResultLimit  int32
+}
+
+type GetBookmarksRow struct {
+	ID               int32
+	UserID           uuid.UUID
+	ThreadID         int32
+	CommentID        sql.NullInt32
+	Note             string
+	Folder           string
+	CreatedTimestamp time.Time
+	ThreadTitle      string
+	Content          string
+	ContentHTML      string
+}
+
+func (q *Queries) GetBookmarks(ctx context.Context, arg GetBookmarksParams) ([]GetBookmarksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarks,
+		arg.UserID,
+		arg.Folder,
+		arg.AfterID,
+		arg.ResultOffset,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookmarksRow
+	for rows.Next() {
+		var i GetBookmarksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.Note,
+			&i.Folder,
+			&i.CreatedTimestamp,
+			&i.ThreadTitle,
+			&i.Content,
+			&i.ContentHTML,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarksBefore = `-- name: GetBookmarksBefore :many
+SELECT bookmarks.id, bookmarks.user_id, bookmarks.thread_id, bookmarks.comment_id, bookmarks.note, bookmarks.folder, bookmarks.created_timestamp, threads.title AS thread_title,
+COALESCE(comments.content, threads.content)::TEXT AS content,
+COALESCE(comments.content_html, threads.content_html)::TEXT AS content_html
+FROM bookmarks
+JOIN threads ON threads.id = bookmarks.thread_id
+LEFT JOIN comments ON comments.id = bookmarks.comment_id
+WHERE bookmarks.user_id = $1
+AND NOT threads.held AND (comments.id IS NULL OR NOT comments.held)
+AND ($2::VARCHAR(50) IS NULL OR bookmarks.folder = $2::VARCHAR(50))
+AND bookmarks.id > $3::INTEGER
+ORDER BY bookmarks.id ASC
+LIMIT $4
+`
+
+type GetBookmarksBeforeParams struct {
+	UserID      uuid.UUID
+	Folder      sql.NullString
+	BeforeID    int32
+	ResultLimit int32
+}
+
+type GetBookmarksBeforeRow struct {
+	ID               int32
+	UserID           uuid.UUID
+	ThreadID         int32
+	CommentID        sql.NullInt32
+	Note             string
+	Folder           string
+	CreatedTimestamp time.Time
+	ThreadTitle      string
+	Content          string
+	ContentHTML      string
+}
+
+func (q *Queries) GetBookmarksBefore(ctx context.Context, arg GetBookmarksBeforeParams) ([]GetBookmarksBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarksBefore,
+		arg.UserID,
+		arg.Folder,
+		arg.BeforeID,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookmarksBeforeRow
+	for rows.Next() {
+		var i GetBookmarksBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.Note,
+			&i.Folder,
+			&i.CreatedTimestamp,
+			&i.ThreadTitle,
+			&i.Content,
+			&i.ContentHTML,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarksCount = `-- name: GetBookmarksCount :one
+SELECT COUNT(*) FROM bookmarks
+JOIN threads ON threads.id = bookmarks.thread_id
+LEFT JOIN comments ON comments.id = bookmarks.comment_id
+WHERE bookmarks.user_id = $1
+AND NOT threads.held AND (comments.id IS NULL OR NOT comments.held)
+AND ($2::VARCHAR(50) IS NULL OR bookmarks.folder = $2::VARCHAR(50))
+`
+
+type GetBookmarksCountParams struct {
+	UserID uuid.UUID
+	Folder sql.NullString
+}
+
+func (q *Queries) GetBookmarksCount(ctx context.Context, arg GetBookmarksCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getBookmarksCount, arg.UserID, arg.Folder)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const updateBookmark = `-- name: UpdateBookmark :one
+UPDATE bookmarks
+SET note = COALESCE($1, note), folder = COALESCE($2, folder)
+WHERE id = $3 AND user_id = $4
+RETURNING id, user_id, thread_id, comment_id, note, folder, created_timestamp
+`
+
+type UpdateBookmarkParams struct {
+	Note   sql.NullString
+	Folder sql.NullString
+	ID     int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) UpdateBookmark(ctx context.Context, arg UpdateBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmark,
+		arg.Note,
+		arg.Folder,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Note,
+		&i.Folder,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
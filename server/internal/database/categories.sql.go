@@ -0,0 +1,255 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: categories.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (slug, name, description, position, parent_id, post_role)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, slug, name, description, position, parent_id, post_role, created_timestamp
+`
+
+type CreateCategoryParams struct {
+	Slug        string
+	Name        string
+	Description string
+	Position    int32
+	ParentID    sql.NullInt32
+	PostRole    string
+}
+
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, createCategory,
+		arg.Slug,
+		arg.Name,
+		arg.Description,
+		arg.Position,
+		arg.ParentID,
+		arg.PostRole,
+	)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Position,
+		&i.ParentID,
+		&i.PostRole,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createCategoryRequiredTag = `-- name: CreateCategoryRequiredTag :exec
+INSERT INTO category_required_tags (category_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type CreateCategoryRequiredTagParams struct {
+	CategoryID int32
+	TagID      int32
+}
+
+func (q *Queries) CreateCategoryRequiredTag(ctx context.Context, arg CreateCategoryRequiredTagParams) error {
+	_, err := q.db.ExecContext(ctx, createCategoryRequiredTag, arg.CategoryID, arg.TagID)
+	return err
+}
+
+const deleteCategory = `-- name: DeleteCategory :one
+DELETE FROM categories
+WHERE id = $1
+RETURNING id, slug, name, description, position, parent_id, post_role, created_timestamp
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id int32) (Category, error) {
+	row := q.db.QueryRowContext(ctx, deleteCategory, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Position,
+		&i.ParentID,
+		&i.PostRole,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const deleteCategoryRequiredTags = `-- name: DeleteCategoryRequiredTags :exec
+DELETE FROM category_required_tags
+WHERE category_id = $1
+`
+
+func (q *Queries) DeleteCategoryRequiredTags(ctx context.Context, categoryID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCategoryRequiredTags, categoryID)
+	return err
+}
+
+const getCategories = `-- name: GetCategories :many
+SELECT id, slug, name, description, position, parent_id, post_role, created_timestamp FROM categories
+ORDER BY position ASC, name ASC
+`
+
+func (q *Queries) GetCategories(ctx context.Context) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.Description,
+			&i.Position,
+			&i.ParentID,
+			&i.PostRole,
+			&i.CreatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategoriesRequiredTags = `-- name: GetCategoriesRequiredTags :many
+SELECT category_required_tags.category_id, tags.id, tags.name FROM category_required_tags
+JOIN tags ON tags.id = category_required_tags.tag_id
+WHERE category_required_tags.category_id = ANY($1::INTEGER[])
+ORDER BY tags.name ASC
+`
+
+type GetCategoriesRequiredTagsRow struct {
+	CategoryID int32
+	ID         int32
+	Name       string
+}
+
+func (q *Queries) GetCategoriesRequiredTags(ctx context.Context, categoryIds []int32) ([]GetCategoriesRequiredTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoriesRequiredTags, pq.Array(categoryIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoriesRequiredTagsRow
+	for rows.Next() {
+		var i GetCategoriesRequiredTagsRow
+		if err := rows.Scan(&i.CategoryID, &i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategory = `-- name: GetCategory :one
+SELECT id, slug, name, description, position, parent_id, post_role, created_timestamp FROM categories
+WHERE id = $1
+`
+
+func (q *Queries) GetCategory(ctx context.Context, id int32) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategory, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Position,
+		&i.ParentID,
+		&i.PostRole,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const getCategoryBySlug = `-- name: GetCategoryBySlug :one
+SELECT id, slug, name, description, position, parent_id, post_role, created_timestamp FROM categories
+WHERE slug = $1
+`
+
+func (q *Queries) GetCategoryBySlug(ctx context.Context, slug string) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryBySlug, slug)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Position,
+		&i.ParentID,
+		&i.PostRole,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const updateCategory = `-- name: UpdateCategory :one
+UPDATE categories
+SET slug = $2, name = $3, description = $4, position = $5, parent_id = $6, post_role = $7
+WHERE id = $1
+RETURNING id, slug, name, description, position, parent_id, post_role, created_timestamp
+`
+
+type UpdateCategoryParams struct {
+	ID          int32
+	Slug        string
+	Name        string
+	Description string
+	Position    int32
+	ParentID    sql.NullInt32
+	PostRole    string
+}
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, updateCategory,
+		arg.ID,
+		arg.Slug,
+		arg.Name,
+		arg.Description,
+		arg.Position,
+		arg.ParentID,
+		arg.PostRole,
+	)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Position,
+		&i.ParentID,
+		&i.PostRole,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
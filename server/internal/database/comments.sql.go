@@ -7,25 +7,37 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createComment = `-- name: CreateComment :one
-INSERT INTO comments (content, thread_id, creator_id)
-VALUES ($1, $2, $3)
-RETURNING id, content, thread_id, creator_id, created_timestamp, updated_timestamp
+INSERT INTO comments (content, content_html, thread_id, creator_id, parent_id, held)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held
 `
 
 type CreateCommentParams struct {
-	Content   string
-	ThreadID  int32
-	CreatorID uuid.UUID
+	Content     string
+	ContentHTML string
+	ThreadID    int32
+	CreatorID   uuid.UUID
+	ParentID    sql.NullInt32
+	Held        bool
 }
 
 func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error) {
-	row := q.db.QueryRowContext(ctx, createComment, arg.Content, arg.ThreadID, arg.CreatorID)
+	row := q.db.QueryRowContext(ctx, createComment,
+		arg.Content,
+		arg.ContentHTML,
+		arg.ThreadID,
+		arg.CreatorID,
+		arg.ParentID,
+		arg.Held,
+	)
 	var i Comment
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +46,11 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 		&i.CreatorID,
 		&i.CreatedTimestamp,
 		&i.UpdatedTimestamp,
+		&i.SearchVector,
+		&i.ContentHTML,
+		&i.ParentID,
+		&i.SubscribersNotified,
+		&i.Held,
 	)
 	return i, err
 }
@@ -41,7 +58,7 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 const deleteComment = `-- name: DeleteComment :one
 DELETE FROM comments
 WHERE id = $1
-RETURNING id, content, thread_id, creator_id, created_timestamp, updated_timestamp
+RETURNING id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held
 `
 
 func (q *Queries) DeleteComment(ctx context.Context, id int32) (Comment, error) {
@@ -54,6 +71,62 @@ func (q *Queries) DeleteComment(ctx context.Context, id int32) (Comment, error)
 		&i.CreatorID,
 		&i.CreatedTimestamp,
 		&i.UpdatedTimestamp,
+		&i.SearchVector,
+		&i.ContentHTML,
+		&i.ParentID,
+		&i.SubscribersNotified,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getAcceptedComment = `-- name: GetAcceptedComment :one
+
+SELECT id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held FROM comments
+WHERE id = $1 AND NOT held
+`
+
+// The accepted answer of the thread is listed first, but is fetched separately with GetAcceptedComment,
+// so that the rest of the comments are paged on (created_timestamp, id) alone
+func (q *Queries) GetAcceptedComment(ctx context.Context, id int32) (Comment, error) {
+	row := q.db.QueryRowContext(ctx, getAcceptedComment, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.ThreadID,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.SearchVector,
+		&i.ContentHTML,
+		&i.ParentID,
+		&i.SubscribersNotified,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getComment = `-- name: GetComment :one
+SELECT id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held FROM comments
+WHERE id = $1
+`
+
+func (q *Queries) GetComment(ctx context.Context, id int32) (Comment, error) {
+	row := q.db.QueryRowContext(ctx, getComment, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.ThreadID,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.SearchVector,
+		&i.ContentHTML,
+		&i.ParentID,
+		&i.SubscribersNotified,
+		&i.Held,
 	)
 	return i, err
 }
@@ -70,21 +143,187 @@ func (q *Queries) GetCommentCreatorID(ctx context.Context, id int32) (uuid.UUID,
 	return creator_id, err
 }
 
+const getCommentListPosition = `-- name: GetCommentListPosition :one
+SELECT comments.thread_id, (CASE WHEN comments.id = threads.accepted_comment_id THEN 1 ELSE (
+    SELECT COUNT(*) FROM comments AS earlier
+    WHERE earlier.thread_id = comments.thread_id AND NOT earlier.held
+    AND (earlier.id = threads.accepted_comment_id
+    OR (earlier.created_timestamp, earlier.id) <= (comments.created_timestamp, comments.id))
+) END)::INTEGER AS position
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = $1 AND NOT comments.held
+`
+
+type GetCommentListPositionRow struct {
+	ThreadID int32
+	Position int32
+}
+
+// The accepted answer is first, and the rest of the comments are counted up to the comment together with the accepted answer
+func (q *Queries) GetCommentListPosition(ctx context.Context, id int32) (GetCommentListPositionRow, error) {
+	row := q.db.QueryRowContext(ctx, getCommentListPosition, id)
+	var i GetCommentListPositionRow
+	err := row.Scan(&i.ThreadID, &i.Position)
+	return i, err
+}
+
+const getCommentThreadAndCreator = `-- name: GetCommentThreadAndCreator :one
+SELECT thread_id, creator_id, held FROM comments
+WHERE id = $1
+`
+
+type GetCommentThreadAndCreatorRow struct {
+	ThreadID  int32
+	CreatorID uuid.UUID
+	Held      bool
+}
+
+func (q *Queries) GetCommentThreadAndCreator(ctx context.Context, id int32) (GetCommentThreadAndCreatorRow, error) {
+	row := q.db.QueryRowContext(ctx, getCommentThreadAndCreator, id)
+	var i GetCommentThreadAndCreatorRow
+	err := row.Scan(&i.ThreadID, &i.CreatorID, &i.Held)
+	return i, err
+}
+
+const getCommentsAfter = `-- name: GetCommentsAfter :many
+SELECT id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held FROM comments
+WHERE thread_id = $1 AND NOT held
+AND id IS DISTINCT FROM $2::INTEGER
+AND (created_timestamp, id) > ($3::TIMESTAMPTZ, $4::INTEGER)
+ORDER BY created_timestamp ASC, id ASC
+LIMIT $5
+`
+
+type GetCommentsAfterParams struct {
+	ThreadID         int32
+	AcceptedID       sql.NullInt32
+	CreatedTimestamp time.Time
+	ID               int32
+	ResultLimit      int32
+}
+
+func (q *Queries) GetCommentsAfter(ctx context.Context, arg GetCommentsAfterParams) ([]Comment, error) {
+	rows, err := q.db.QueryContext(ctx, getCommentsAfter,
+		arg.ThreadID,
+		arg.AcceptedID,
+		arg.CreatedTimestamp,
+		arg.ID,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Comment
+	for rows.Next() {
+		var i Comment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.ThreadID,
+			&i.CreatorID,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+			&i.SearchVector,
+			&i.ContentHTML,
+			&i.ParentID,
+			&i.SubscribersNotified,
+			&i.Held,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommentsBefore = `-- name: GetCommentsBefore :many
+SELECT id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held FROM comments
+WHERE thread_id = $1 AND NOT held
+AND id IS DISTINCT FROM $2::INTEGER
+AND (created_timestamp, id) < ($3::TIMESTAMPTZ, $4::INTEGER)
+ORDER BY created_timestamp DESC, id DESC
+LIMIT $5
+`
+
+type GetCommentsBeforeParams struct {
+	ThreadID         int32
+	AcceptedID       sql.NullInt32
+	CreatedTimestamp time.Time
+	ID               int32
+	ResultLimit      int32
+}
+
+func (q *Queries) GetCommentsBefore(ctx context.Context, arg GetCommentsBeforeParams) ([]Comment, error) {
+	rows, err := q.db.QueryContext(ctx, getCommentsBefore,
+		arg.ThreadID,
+		arg.AcceptedID,
+		arg.CreatedTimestamp,
+		arg.ID,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Comment
+	for rows.Next() {
+		var i Comment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.ThreadID,
+			&i.CreatorID,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+			&i.SearchVector,
+			&i.ContentHTML,
+			&i.ParentID,
+			&i.SubscribersNotified,
+			&i.Held,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getCommentsPaginated = `-- name: GetCommentsPaginated :many
-SELECT id, content, thread_id, creator_id, created_timestamp, updated_timestamp FROM comments
-WHERE thread_id = $1
-ORDER BY created_timestamp ASC 
-LIMIT $2 OFFSET $3
+SELECT id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held FROM comments
+WHERE thread_id = $1 AND NOT held
+AND id IS DISTINCT FROM $2::INTEGER
+ORDER BY created_timestamp ASC, id ASC
+LIMIT $4 OFFSET $3
 `
 
 type GetCommentsPaginatedParams struct {
-	ThreadID int32
-	Limit    int32
-	Offset   int32
+	ThreadID     int32
+	AcceptedID   sql.NullInt32
+	ResultOffset int32
+	ResultLimit  int32
 }
 
 func (q *Queries) GetCommentsPaginated(ctx context.Context, arg GetCommentsPaginatedParams) ([]Comment, error) {
-	rows, err := q.db.QueryContext(ctx, getCommentsPaginated, arg.ThreadID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getCommentsPaginated,
+		arg.ThreadID,
+		arg.AcceptedID,
+		arg.ResultOffset,
+		arg.ResultLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +338,11 @@ func (q *Queries) GetCommentsPaginated(ctx context.Context, arg GetCommentsPagin
 			&i.CreatorID,
 			&i.CreatedTimestamp,
 			&i.UpdatedTimestamp,
+			&i.SearchVector,
+			&i.ContentHTML,
+			&i.ParentID,
+			&i.SubscribersNotified,
+			&i.Held,
 		); err != nil {
 			return nil, err
 		}
@@ -115,7 +359,7 @@ func (q *Queries) GetCommentsPaginated(ctx context.Context, arg GetCommentsPagin
 
 const getCommentsPaginatedCount = `-- name: GetCommentsPaginatedCount :one
 SELECT COUNT(*) FROM comments
-WHERE thread_id = $1
+WHERE thread_id = $1 AND NOT held
 `
 
 func (q *Queries) GetCommentsPaginatedCount(ctx context.Context, threadID int32) (int64, error) {
@@ -125,26 +369,159 @@ func (q *Queries) GetCommentsPaginatedCount(ctx context.Context, threadID int32)
 	return count, err
 }
 
+const getCommentsWithoutContentHTML = `-- name: GetCommentsWithoutContentHTML :many
+SELECT id, content FROM comments
+WHERE id > $1 AND content_html = '' AND content <> ''
+ORDER BY id ASC
+LIMIT $2
+`
+
+type GetCommentsWithoutContentHTMLParams struct {
+	AfterID   int32
+	BatchSize int32
+}
+
+type GetCommentsWithoutContentHTMLRow struct {
+	ID      int32
+	Content string
+}
+
+func (q *Queries) GetCommentsWithoutContentHTML(ctx context.Context, arg GetCommentsWithoutContentHTMLParams) ([]GetCommentsWithoutContentHTMLRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCommentsWithoutContentHTML, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCommentsWithoutContentHTMLRow
+	for rows.Next() {
+		var i GetCommentsWithoutContentHTMLRow
+		if err := rows.Scan(&i.ID, &i.Content); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQuotedCommentAuthors = `-- name: GetQuotedCommentAuthors :many
+SELECT comments.id, users.username FROM comments
+JOIN users ON users.id = comments.creator_id
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = ANY($1::INTEGER[]) AND NOT comments.held AND NOT threads.held
+`
+
+type GetQuotedCommentAuthorsRow struct {
+	ID       int32
+	Username string
+}
+
+// Comments in held threads cannot be quoted either, so that their authors are not revealed
+func (q *Queries) GetQuotedCommentAuthors(ctx context.Context, commentIds []int32) ([]GetQuotedCommentAuthorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getQuotedCommentAuthors, pq.Array(commentIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetQuotedCommentAuthorsRow
+	for rows.Next() {
+		var i GetQuotedCommentAuthorsRow
+		if err := rows.Scan(&i.ID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseComment = `-- name: ReleaseComment :one
+UPDATE comments
+SET held = FALSE
+WHERE id = $1 AND held
+RETURNING id, content, thread_id, creator_id, created_timestamp, updated_timestamp, search_vector, content_html, parent_id, subscribers_notified, held
+`
+
+func (q *Queries) ReleaseComment(ctx context.Context, id int32) (Comment, error) {
+	row := q.db.QueryRowContext(ctx, releaseComment, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.ThreadID,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.SearchVector,
+		&i.ContentHTML,
+		&i.ParentID,
+		&i.SubscribersNotified,
+		&i.Held,
+	)
+	return i, err
+}
+
 const updateCommentContent = `-- name: UpdateCommentContent :one
 UPDATE comments
-SET content = $2, updated_timestamp = CURRENT_TIMESTAMP
+SET content = $2, content_html = $3, updated_timestamp = CURRENT_TIMESTAMP, held = held OR $4
 WHERE id = $1
-RETURNING content, updated_timestamp
+RETURNING content, content_html, updated_timestamp, held
 `
 
 type UpdateCommentContentParams struct {
-	ID      int32
-	Content string
+	ID          int32
+	Content     string
+	ContentHTML string
+	Held        bool
 }
 
 type UpdateCommentContentRow struct {
 	Content          string
+	ContentHTML      string
 	UpdatedTimestamp time.Time
+	Held             bool
 }
 
+// Edits can hold the comment, but only moderators can release it
 func (q *Queries) UpdateCommentContent(ctx context.Context, arg UpdateCommentContentParams) (UpdateCommentContentRow, error) {
-	row := q.db.QueryRowContext(ctx, updateCommentContent, arg.ID, arg.Content)
+	row := q.db.QueryRowContext(ctx, updateCommentContent,
+		arg.ID,
+		arg.Content,
+		arg.ContentHTML,
+		arg.Held,
+	)
 	var i UpdateCommentContentRow
-	err := row.Scan(&i.Content, &i.UpdatedTimestamp)
+	err := row.Scan(
+		&i.Content,
+		&i.ContentHTML,
+		&i.UpdatedTimestamp,
+		&i.Held,
+	)
 	return i, err
 }
+
+const updateCommentContentHTML = `-- name: UpdateCommentContentHTML :exec
+UPDATE comments
+SET content_html = $2
+WHERE id = $1
+`
+
+type UpdateCommentContentHTMLParams struct {
+	ID          int32
+	ContentHTML string
+}
+
+func (q *Queries) UpdateCommentContentHTML(ctx context.Context, arg UpdateCommentContentHTMLParams) error {
+	_, err := q.db.ExecContext(ctx, updateCommentContentHTML, arg.ID, arg.ContentHTML)
+	return err
+}
@@ -11,9 +11,10 @@ import (
 
 /*
 	This function establishes a connection to the specified DB_URL in environment variables.
-	It returns a pointer that holds the connection and a function to close the connection.
+	It returns a pointer that holds the connection, the underlying database handle
+	for starting transactions, and a function to close the connection.
 */
-func GetConnection() (*Queries, func()) {
+func GetConnection() (*Queries, *sql.DB, func()) {
 	godotenv.Load(".env")
 
 	databaseURL := os.Getenv("DB_URL")
@@ -26,7 +27,7 @@ func GetConnection() (*Queries, func()) {
 		log.Fatal("Cannot connect to database: ", err)
 	}
 
-	return New(connection), func() {
+	return New(connection), connection, func() {
 		connection.Close()
 	}
 }
\ No newline at end of file
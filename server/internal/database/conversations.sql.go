@@ -0,0 +1,545 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: conversations.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addConversationParticipants = `-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id)
+SELECT $1, UNNEST($2::UUID[])
+`
+
+type AddConversationParticipantsParams struct {
+	ConversationID int32
+	UserIds        []uuid.UUID
+}
+
+func (q *Queries) AddConversationParticipants(ctx context.Context, arg AddConversationParticipantsParams) error {
+	_, err := q.db.ExecContext(ctx, addConversationParticipants, arg.ConversationID, pq.Array(arg.UserIds))
+	return err
+}
+
+const clearDirectConversation = `-- name: ClearDirectConversation :exec
+UPDATE conversations
+SET direct_low_id = NULL, direct_high_id = NULL
+WHERE id = $1
+`
+
+// A new direct conversation can be started between the users once either of them leaves
+func (q *Queries) ClearDirectConversation(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, clearDirectConversation, id)
+	return err
+}
+
+const createConversation = `-- name: CreateConversation :one
+INSERT INTO conversations (creator_id, is_group, title)
+VALUES ($1, $2, $3)
+RETURNING id, creator_id, is_group, title, created_timestamp, last_message_timestamp, direct_low_id, direct_high_id
+`
+
+type CreateConversationParams struct {
+	CreatorID uuid.NullUUID
+	IsGroup   bool
+	Title     string
+}
+
+func (q *Queries) CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, createConversation, arg.CreatorID, arg.IsGroup, arg.Title)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.IsGroup,
+		&i.Title,
+		&i.CreatedTimestamp,
+		&i.LastMessageTimestamp,
+		&i.DirectLowID,
+		&i.DirectHighID,
+	)
+	return i, err
+}
+
+const createDirectConversation = `-- name: CreateDirectConversation :one
+INSERT INTO conversations (creator_id, is_group, direct_low_id, direct_high_id)
+VALUES ($1::UUID, FALSE,
+LEAST($1::UUID, $2::UUID), GREATEST($1::UUID, $2::UUID))
+ON CONFLICT (direct_low_id, direct_high_id) DO UPDATE
+SET direct_low_id = conversations.direct_low_id
+RETURNING id, creator_id, is_group, title, created_timestamp, last_message_timestamp, direct_low_id, direct_high_id, (xmax = 0)::BOOLEAN AS created
+`
+
+type CreateDirectConversationParams struct {
+	CreatorID uuid.UUID
+	OtherID   uuid.UUID
+}
+
+type CreateDirectConversationRow struct {
+	ID                   int32
+	CreatorID            uuid.NullUUID
+	IsGroup              bool
+	Title                string
+	CreatedTimestamp     time.Time
+	LastMessageTimestamp time.Time
+	DirectLowID          uuid.NullUUID
+	DirectHighID         uuid.NullUUID
+	Created              bool
+}
+
+// The existing direct conversation between the users is returned instead if there is one, with created set to false
+func (q *Queries) CreateDirectConversation(ctx context.Context, arg CreateDirectConversationParams) (CreateDirectConversationRow, error) {
+	row := q.db.QueryRowContext(ctx, createDirectConversation, arg.CreatorID, arg.OtherID)
+	var i CreateDirectConversationRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.IsGroup,
+		&i.Title,
+		&i.CreatedTimestamp,
+		&i.LastMessageTimestamp,
+		&i.DirectLowID,
+		&i.DirectHighID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createMessage = `-- name: CreateMessage :one
+INSERT INTO messages (conversation_id, sender_id, content, content_html)
+VALUES ($1, $2, $3, $4)
+RETURNING id, conversation_id, sender_id, content, content_html, created_timestamp
+`
+
+type CreateMessageParams struct {
+	ConversationID int32
+	SenderID       uuid.UUID
+	Content        string
+	ContentHTML    string
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, createMessage,
+		arg.ConversationID,
+		arg.SenderID,
+		arg.Content,
+		arg.ContentHTML,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.SenderID,
+		&i.Content,
+		&i.ContentHTML,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const getConversation = `-- name: GetConversation :one
+SELECT conversations.id, conversations.creator_id, conversations.is_group, conversations.title, conversations.created_timestamp, conversations.last_message_timestamp, conversations.direct_low_id, conversations.direct_high_id, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.id > conversation_participants.last_read_message_id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND NOT EXISTS (
+        SELECT 1 FROM user_blocks
+        WHERE user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = messages.sender_id
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = $1
+AND conversation_participants.user_id = $2 AND conversation_participants.left_timestamp IS NULL
+`
+
+type GetConversationParams struct {
+	ID     int32
+	UserID uuid.UUID
+}
+
+type GetConversationRow struct {
+	ID                   int32
+	CreatorID            uuid.NullUUID
+	IsGroup              bool
+	Title                string
+	CreatedTimestamp     time.Time
+	LastMessageTimestamp time.Time
+	DirectLowID          uuid.NullUUID
+	DirectHighID         uuid.NullUUID
+	UnreadCount          int64
+}
+
+func (q *Queries) GetConversation(ctx context.Context, arg GetConversationParams) (GetConversationRow, error) {
+	row := q.db.QueryRowContext(ctx, getConversation, arg.ID, arg.UserID)
+	var i GetConversationRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.IsGroup,
+		&i.Title,
+		&i.CreatedTimestamp,
+		&i.LastMessageTimestamp,
+		&i.DirectLowID,
+		&i.DirectHighID,
+		&i.UnreadCount,
+	)
+	return i, err
+}
+
+const getConversationsParticipants = `-- name: GetConversationsParticipants :many
+SELECT conversation_participants.conversation_id, conversation_participants.user_id, users.username,
+conversation_participants.joined_timestamp, conversation_participants.left_timestamp,
+conversation_participants.last_read_message_id
+FROM conversation_participants
+JOIN users ON users.id = conversation_participants.user_id
+WHERE conversation_participants.conversation_id = ANY($1::INTEGER[])
+ORDER BY conversation_participants.conversation_id, conversation_participants.joined_timestamp, users.username
+`
+
+type GetConversationsParticipantsRow struct {
+	ConversationID    int32
+	UserID            uuid.UUID
+	Username          string
+	JoinedTimestamp   time.Time
+	LeftTimestamp     sql.NullTime
+	LastReadMessageID int32
+}
+
+func (q *Queries) GetConversationsParticipants(ctx context.Context, conversationIds []int32) ([]GetConversationsParticipantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getConversationsParticipants, pq.Array(conversationIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetConversationsParticipantsRow
+	for rows.Next() {
+		var i GetConversationsParticipantsRow
+		if err := rows.Scan(
+			&i.ConversationID,
+			&i.UserID,
+			&i.Username,
+			&i.JoinedTimestamp,
+			&i.LeftTimestamp,
+			&i.LastReadMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestMessageID = `-- name: GetLatestMessageID :one
+SELECT COALESCE(MAX(id), 0)::INTEGER FROM messages
+WHERE conversation_id = $1
+`
+
+func (q *Queries) GetLatestMessageID(ctx context.Context, conversationID int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getLatestMessageID, conversationID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getMessageConversationID = `-- name: GetMessageConversationID :one
+SELECT conversation_id FROM messages
+WHERE id = $1
+`
+
+func (q *Queries) GetMessageConversationID(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getMessageConversationID, id)
+	var conversation_id int32
+	err := row.Scan(&conversation_id)
+	return conversation_id, err
+}
+
+const getMessages = `-- name: GetMessages :many
+SELECT messages.id, messages.conversation_id, messages.sender_id, messages.content, messages.content_html, messages.created_timestamp FROM messages
+WHERE messages.conversation_id = $1
+AND ($2::INTEGER IS NULL OR messages.id < $2::INTEGER)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = $3 AND user_blocks.blocked_id = messages.sender_id
+)
+ORDER BY messages.id DESC
+LIMIT $4
+`
+
+type GetMessagesParams struct {
+	ConversationID int32
+	AfterID        sql.NullInt32
+	ViewerID       uuid.UUID
+	ResultLimit    int32
+}
+
+func (q *Queries) GetMessages(ctx context.Context, arg GetMessagesParams) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, getMessages,
+		arg.ConversationID,
+		arg.AfterID,
+		arg.ViewerID,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.SenderID,
+			&i.Content,
+			&i.ContentHTML,
+			&i.CreatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesBefore = `-- name: GetMessagesBefore :many
+SELECT messages.id, messages.conversation_id, messages.sender_id, messages.content, messages.content_html, messages.created_timestamp FROM messages
+WHERE messages.conversation_id = $1
+AND messages.id > $2::INTEGER
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = $3 AND user_blocks.blocked_id = messages.sender_id
+)
+ORDER BY messages.id ASC
+LIMIT $4
+`
+
+type GetMessagesBeforeParams struct {
+	ConversationID int32
+	BeforeID       int32
+	ViewerID       uuid.UUID
+	ResultLimit    int32
+}
+
+func (q *Queries) GetMessagesBefore(ctx context.Context, arg GetMessagesBeforeParams) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, getMessagesBefore,
+		arg.ConversationID,
+		arg.BeforeID,
+		arg.ViewerID,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.SenderID,
+			&i.Content,
+			&i.ContentHTML,
+			&i.CreatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadMessagesCount = `-- name: GetUnreadMessagesCount :one
+SELECT COUNT(*) FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+WHERE conversation_participants.user_id = $1 AND conversation_participants.left_timestamp IS NULL
+AND messages.id > conversation_participants.last_read_message_id
+AND messages.sender_id <> conversation_participants.user_id
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = messages.sender_id
+)
+`
+
+func (q *Queries) GetUnreadMessagesCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUnreadMessagesCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getUserConversations = `-- name: GetUserConversations :many
+SELECT conversations.id, conversations.creator_id, conversations.is_group, conversations.title, conversations.created_timestamp, conversations.last_message_timestamp, conversations.direct_low_id, conversations.direct_high_id, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.id > conversation_participants.last_read_message_id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND NOT EXISTS (
+        SELECT 1 FROM user_blocks
+        WHERE user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = messages.sender_id
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = $1 AND conversation_participants.left_timestamp IS NULL
+ORDER BY conversations.last_message_timestamp DESC, conversations.id DESC
+LIMIT $3 OFFSET $2
+`
+
+type GetUserConversationsParams struct {
+	UserID       uuid.UUID
+	ResultOffset int32
+	ResultLimit  int32
+}
+
+type GetUserConversationsRow struct {
+	ID                   int32
+	CreatorID            uuid.NullUUID
+	IsGroup              bool
+	Title                string
+	CreatedTimestamp     time.Time
+	LastMessageTimestamp time.Time
+	DirectLowID          uuid.NullUUID
+	DirectHighID         uuid.NullUUID
+	UnreadCount          int64
+}
+
+func (q *Queries) GetUserConversations(ctx context.Context, arg GetUserConversationsParams) ([]GetUserConversationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserConversations, arg.UserID, arg.ResultOffset, arg.ResultLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserConversationsRow
+	for rows.Next() {
+		var i GetUserConversationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatorID,
+			&i.IsGroup,
+			&i.Title,
+			&i.CreatedTimestamp,
+			&i.LastMessageTimestamp,
+			&i.DirectLowID,
+			&i.DirectHighID,
+			&i.UnreadCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserConversationsCount = `-- name: GetUserConversationsCount :one
+SELECT COUNT(*) FROM conversation_participants
+WHERE user_id = $1 AND left_timestamp IS NULL
+`
+
+func (q *Queries) GetUserConversationsCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUserConversationsCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const leaveConversation = `-- name: LeaveConversation :one
+UPDATE conversation_participants
+SET left_timestamp = CURRENT_TIMESTAMP
+WHERE conversation_id = $1 AND user_id = $2 AND left_timestamp IS NULL
+RETURNING conversation_id, user_id, joined_timestamp, left_timestamp, last_read_message_id
+`
+
+type LeaveConversationParams struct {
+	ConversationID int32
+	UserID         uuid.UUID
+}
+
+func (q *Queries) LeaveConversation(ctx context.Context, arg LeaveConversationParams) (ConversationParticipant, error) {
+	row := q.db.QueryRowContext(ctx, leaveConversation, arg.ConversationID, arg.UserID)
+	var i ConversationParticipant
+	err := row.Scan(
+		&i.ConversationID,
+		&i.UserID,
+		&i.JoinedTimestamp,
+		&i.LeftTimestamp,
+		&i.LastReadMessageID,
+	)
+	return i, err
+}
+
+const markConversationRead = `-- name: MarkConversationRead :one
+UPDATE conversation_participants
+SET last_read_message_id = GREATEST(last_read_message_id, $1::INTEGER)
+WHERE conversation_id = $2 AND user_id = $3 AND left_timestamp IS NULL
+RETURNING conversation_id, user_id, joined_timestamp, left_timestamp, last_read_message_id
+`
+
+type MarkConversationReadParams struct {
+	MessageID      int32
+	ConversationID int32
+	UserID         uuid.UUID
+}
+
+func (q *Queries) MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) (ConversationParticipant, error) {
+	row := q.db.QueryRowContext(ctx, markConversationRead, arg.MessageID, arg.ConversationID, arg.UserID)
+	var i ConversationParticipant
+	err := row.Scan(
+		&i.ConversationID,
+		&i.UserID,
+		&i.JoinedTimestamp,
+		&i.LeftTimestamp,
+		&i.LastReadMessageID,
+	)
+	return i, err
+}
+
+const updateConversationLastMessage = `-- name: UpdateConversationLastMessage :exec
+UPDATE conversations
+SET last_message_timestamp = $2
+WHERE id = $1
+`
+
+type UpdateConversationLastMessageParams struct {
+	ID                   int32
+	LastMessageTimestamp time.Time
+}
+
+func (q *Queries) UpdateConversationLastMessage(ctx context.Context, arg UpdateConversationLastMessageParams) error {
+	_, err := q.db.ExecContext(ctx, updateConversationLastMessage, arg.ID, arg.LastMessageTimestamp)
+	return err
+}
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,35 +12,358 @@ import (
 type FormattedUserInfo struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
+	Role     string    `json:"role"`
+}
+
+type FormattedUserSettings struct {
+	AutoWatchCreated   bool `json:"auto_watch_created"`
+	AutoWatchCommented bool `json:"auto_watch_commented"`
 }
 
 type FormattedThread struct {
-	ID               int32     `json:"id"`
-	Title            string    `json:"title"`
-	Content          string    `json:"content"`
-	Tags             []string  `json:"tags"`
-	CreatorID        uuid.UUID `json:"creator_id"`
-	CreatedTimestamp time.Time `json:"created_timestamp"`
-	UpdatedTimestamp time.Time `json:"updated_timestamp"`
+	ID                    int32               `json:"id"`
+	Title                 string              `json:"title"`
+	Content               string              `json:"content"`
+	ContentHTML           string              `json:"content_html"`
+	Tags                  []string            `json:"tags"`
+	CategoryID            int32               `json:"category_id"`
+	CreatorID             uuid.UUID           `json:"creator_id"`
+	CreatedTimestamp      time.Time           `json:"created_timestamp"`
+	UpdatedTimestamp      time.Time           `json:"updated_timestamp"`
+	LastActivityTimestamp time.Time           `json:"last_activity_timestamp"`
+	CommentCount          int32               `json:"comment_count"`
+	LastCommenterID       uuid.NullUUID       `json:"last_commenter_id"`
+	Score                 int32               `json:"score"`
+	Pinned                bool                `json:"pinned"`
+	Locked                bool                `json:"locked"`
+	Archived              bool                `json:"archived"`
+	AcceptedCommentID     *int32              `json:"accepted_comment_id"`
+	Held                  bool                `json:"held"`
+	Bookmarked            bool                `json:"bookmarked"`
+	UnreadComments        *int32              `json:"unread_comments,omitempty"`
+	HasNew                *bool               `json:"has_new,omitempty"`
+	Reactions             []FormattedReaction `json:"reactions"`
+	Poll                  *FormattedPoll      `json:"poll,omitempty"`
 }
 
 type FormattedUpdatedThread struct {
 	Content          string    `json:"content"`
+	ContentHTML      string    `json:"content_html"`
 	UpdatedTimestamp time.Time `json:"updated_timestamp"`
+	Held             bool      `json:"held"`
 }
 
 type FormattedComment struct {
-	ID               int32     `json:"id"`
+	ID               int32               `json:"id"`
+	Content          string              `json:"content"`
+	ContentHTML      string              `json:"content_html"`
+	ThreadID         int32               `json:"thread_id"`
+	ParentID         *int32              `json:"parent_id"`
+	CreatorID        uuid.UUID           `json:"creator_id"`
+	CreatedTimestamp time.Time           `json:"created_timestamp"`
+	UpdatedTimestamp time.Time           `json:"updated_timestamp"`
+	Accepted         bool                `json:"accepted"`
+	Held             bool                `json:"held"`
+	Bookmarked       bool                `json:"bookmarked"`
+	Reactions        []FormattedReaction `json:"reactions"`
+}
+
+type FormattedUpdatedComment struct {
 	Content          string    `json:"content"`
+	ContentHTML      string    `json:"content_html"`
+	UpdatedTimestamp time.Time `json:"updated_timestamp"`
+	Held             bool      `json:"held"`
+}
+
+type FormattedReaction struct {
+	Reaction string `json:"reaction"`
+	Count    int64  `json:"count"`
+	Reacted  bool   `json:"reacted"`
+}
+
+type FormattedThreadReaction struct {
+	ThreadID         int32     `json:"thread_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Reaction         string    `json:"reaction"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedCommentReaction struct {
+	CommentID        int32     `json:"comment_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Reaction         string    `json:"reaction"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedTag struct {
+	ID               int32     `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Canonical        bool      `json:"canonical"`
+	Restricted       bool      `json:"restricted"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+	Count            int64     `json:"count"`
+}
+
+type FormattedTagDetails struct {
+	ID               int32     `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Canonical        bool      `json:"canonical"`
+	Restricted       bool      `json:"restricted"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+	Count            int64     `json:"count"`
+	Aliases          []string  `json:"aliases"`
+}
+
+type FormattedTagAlias struct {
+	Alias            string    `json:"alias"`
+	TagID            int32     `json:"tag_id"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedSearchResult struct {
+	Type             string    `json:"type"`
 	ThreadID         int32     `json:"thread_id"`
+	CommentID        *int32    `json:"comment_id"`
+	Title            string    `json:"title"`
+	Snippet          string    `json:"snippet"`
 	CreatorID        uuid.UUID `json:"creator_id"`
 	CreatedTimestamp time.Time `json:"created_timestamp"`
-	UpdatedTimestamp time.Time `json:"updated_timestamp"`
+	Rank             float32   `json:"rank"`
 }
 
-type FormattedUpdatedComment struct {
+type FormattedCategory struct {
+	ID               int32     `json:"id"`
+	Slug             string    `json:"slug"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Position         int32     `json:"position"`
+	ParentID         *int32    `json:"parent_id"`
+	PostRole         string    `json:"post_role"`
+	RequiredTags     []string  `json:"required_tags"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedThreadSubscription struct {
+	UserID           uuid.UUID `json:"user_id"`
+	ThreadID         int32     `json:"thread_id"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedBookmark struct {
+	ID               int32     `json:"id"`
+	ThreadID         int32     `json:"thread_id"`
+	CommentID        *int32    `json:"comment_id"`
+	Note             string    `json:"note"`
+	Folder           string    `json:"folder"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedSavedBookmark struct {
+	ID               int32     `json:"id"`
+	ThreadID         int32     `json:"thread_id"`
+	ThreadTitle      string    `json:"thread_title"`
+	CommentID        *int32    `json:"comment_id"`
 	Content          string    `json:"content"`
-	UpdatedTimestamp time.Time `json:"updated_timestamp"`
+	ContentHTML      string    `json:"content_html"`
+	Note             string    `json:"note"`
+	Folder           string    `json:"folder"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedThreadRead struct {
+	ThreadID          int32     `json:"thread_id"`
+	ReadCommentCount  int32     `json:"read_comment_count"`
+	LastReadCommentID *int32    `json:"last_read_comment_id"`
+	ReadTimestamp     time.Time `json:"read_timestamp"`
+}
+
+type FormattedAttachment struct {
+	ID               int32                `json:"id"`
+	URL              string               `json:"url"`
+	Filename         string               `json:"filename"`
+	ContentType      string               `json:"content_type"`
+	Size             int32                `json:"size"`
+	ThumbnailStatus  string               `json:"thumbnail_status"`
+	Thumbnails       []FormattedThumbnail `json:"thumbnails"`
+	CreatedTimestamp time.Time            `json:"created_timestamp"`
+}
+
+type FormattedThumbnail struct {
+	Size   int32  `json:"size"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+	URL    string `json:"url"`
+}
+
+type FormattedPoll struct {
+	ID              int32                 `json:"id"`
+	Question        string                `json:"question"`
+	MultipleChoice  bool                  `json:"multiple_choice"`
+	HideResults     bool                  `json:"hide_results"`
+	ClosesTimestamp *time.Time            `json:"closes_timestamp"`
+	Closed          bool                  `json:"closed"`
+	VotersCount     int64                 `json:"voters_count"`
+	Options         []FormattedPollOption `json:"options"`
+	VotedOptionIDs  []int32               `json:"voted_option_ids"`
+}
+
+type FormattedPollOption struct {
+	ID    int32  `json:"id"`
+	Text  string `json:"text"`
+	Votes *int64 `json:"votes"`
+}
+
+type FormattedConversation struct {
+	ID                   int32                  `json:"id"`
+	Title                string                 `json:"title"`
+	IsGroup              bool                   `json:"is_group"`
+	CreatorID            uuid.NullUUID          `json:"creator_id"`
+	CreatedTimestamp     time.Time              `json:"created_timestamp"`
+	LastMessageTimestamp time.Time              `json:"last_message_timestamp"`
+	UnreadCount          int64                  `json:"unread_count"`
+	Participants         []FormattedParticipant `json:"participants"`
+}
+
+type FormattedParticipant struct {
+	UserID            uuid.UUID  `json:"user_id"`
+	Username          string     `json:"username"`
+	JoinedTimestamp   time.Time  `json:"joined_timestamp"`
+	LeftTimestamp     *time.Time `json:"left_timestamp"`
+	LastReadMessageID int32      `json:"last_read_message_id"`
+}
+
+type FormattedMessage struct {
+	ID               int32     `json:"id"`
+	ConversationID   int32     `json:"conversation_id"`
+	SenderID         uuid.UUID `json:"sender_id"`
+	Content          string    `json:"content"`
+	ContentHTML      string    `json:"content_html"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedUserBlock struct {
+	BlockedID        uuid.UUID `json:"blocked_id"`
+	Username         string    `json:"username"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedNotification struct {
+	ID               int32     `json:"id"`
+	Type             string    `json:"type"`
+	ActorID          uuid.UUID `json:"actor_id"`
+	ActorUsername    string    `json:"actor_username"`
+	ThreadID         *int32    `json:"thread_id"`
+	ThreadTitle      *string   `json:"thread_title"`
+	CommentID        *int32    `json:"comment_id"`
+	CaseID           *int32    `json:"case_id"`
+	Read             bool      `json:"read"`
+	CreatedTimestamp time.Time `json:"created_timestamp"`
+}
+
+type FormattedReport struct {
+	ID               int32         `json:"id"`
+	CaseID           int32         `json:"case_id"`
+	ReporterID       uuid.NullUUID `json:"reporter_id"`
+	Reason           string        `json:"reason"`
+	Details          string        `json:"details"`
+	CreatedTimestamp time.Time     `json:"created_timestamp"`
+}
+
+type FormattedCaseReport struct {
+	ID               int32         `json:"id"`
+	ReporterID       uuid.NullUUID `json:"reporter_id"`
+	ReporterUsername *string       `json:"reporter_username"`
+	Reason           string        `json:"reason"`
+	Details          string        `json:"details"`
+	CreatedTimestamp time.Time     `json:"created_timestamp"`
+}
+
+type FormattedReportCase struct {
+	ID                int32                 `json:"id"`
+	TargetType        string                `json:"target_type"`
+	ThreadID          *int32                `json:"thread_id"`
+	CommentID         *int32                `json:"comment_id"`
+	TargetUserID      uuid.UUID             `json:"target_user_id"`
+	TargetUsername    string                `json:"target_username"`
+	TargetTitle       string                `json:"target_title"`
+	TargetContent     string                `json:"target_content"`
+	Status            string                `json:"status"`
+	Reasons           []string              `json:"reasons"`
+	ReportCount       int32                 `json:"report_count"`
+	AssigneeID        uuid.NullUUID         `json:"assignee_id"`
+	ResolverID        uuid.NullUUID         `json:"resolver_id"`
+	ResolutionNote    string                `json:"resolution_note"`
+	CreatedTimestamp  time.Time             `json:"created_timestamp"`
+	UpdatedTimestamp  time.Time             `json:"updated_timestamp"`
+	ResolvedTimestamp *time.Time            `json:"resolved_timestamp"`
+	Reports           []FormattedCaseReport `json:"reports,omitempty"`
+}
+
+type FormattedSpamSample struct {
+	ID               int32         `json:"id"`
+	ThreadID         *int32        `json:"thread_id"`
+	CommentID        *int32        `json:"comment_id"`
+	Content          string        `json:"content"`
+	Spam             bool          `json:"spam"`
+	TrainerID        uuid.NullUUID `json:"trainer_id"`
+	CreatedTimestamp time.Time     `json:"created_timestamp"`
+	UpdatedTimestamp time.Time     `json:"updated_timestamp"`
+}
+
+type FormattedSpamToken struct {
+	Token     string  `json:"token"`
+	SpamCount int32   `json:"spam_count"`
+	HamCount  int32   `json:"ham_count"`
+	Weight    float64 `json:"weight"`
+}
+
+type FormattedAutomodRule struct {
+	ID                 int32     `json:"id"`
+	Name               string    `json:"name"`
+	Pattern            string    `json:"pattern"`
+	Words              []string  `json:"words"`
+	MaxLinks           *int32    `json:"max_links"`
+	MaxAccountAgeHours *int32    `json:"max_account_age_hours"`
+	Action             string    `json:"action"`
+	Message            string    `json:"message"`
+	Replacement        string    `json:"replacement"`
+	Enabled            bool      `json:"enabled"`
+	CreatedTimestamp   time.Time `json:"created_timestamp"`
+	UpdatedTimestamp   time.Time `json:"updated_timestamp"`
+}
+
+/*
+This function formats a single thread. The tags and reactions start off empty,
+they are filled in separately since they are stored in other tables.
+*/
+func FormatThread(thread Thread) FormattedThread {
+	formattedThread := FormattedThread{
+		ID:                    thread.ID,
+		Title:                 thread.Title,
+		Content:               thread.Content,
+		ContentHTML:           thread.ContentHTML,
+		Tags:                  []string{},
+		CategoryID:            thread.CategoryID,
+		CreatorID:             thread.CreatorID,
+		CreatedTimestamp:      thread.CreatedTimestamp,
+		UpdatedTimestamp:      thread.UpdatedTimestamp,
+		LastActivityTimestamp: thread.LastActivityTimestamp,
+		CommentCount:          thread.CommentCount,
+		LastCommenterID:       thread.LastCommenterID,
+		Score:                 thread.Score,
+		Pinned:                thread.Pinned,
+		Locked:                thread.Locked,
+		Archived:              thread.Archived,
+		Held:                  thread.Held,
+		Reactions:             []FormattedReaction{},
+	}
+	if thread.AcceptedCommentID.Valid {
+		formattedThread.AcceptedCommentID = &thread.AcceptedCommentID.Int32
+	}
+
+	return formattedThread
 }
 
 /*
@@ -47,13 +373,36 @@ func FormatThreads(threads []Thread) []FormattedThread {
 	var formattedThreads []FormattedThread
 
 	for _, thread := range threads {
-		formattedThread := FormattedThread(thread)
+		formattedThread := FormatThread(thread)
 		formattedThreads = append(formattedThreads, formattedThread)
 	}
 
 	return formattedThreads
 }
 
+/*
+This function formats a single comment. The reactions start off empty,
+they are filled in separately since they are aggregated from another table.
+*/
+func FormatComment(comment Comment) FormattedComment {
+	formattedComment := FormattedComment{
+		ID:               comment.ID,
+		Content:          comment.Content,
+		ContentHTML:      comment.ContentHTML,
+		ThreadID:         comment.ThreadID,
+		CreatorID:        comment.CreatorID,
+		CreatedTimestamp: comment.CreatedTimestamp,
+		UpdatedTimestamp: comment.UpdatedTimestamp,
+		Held:             comment.Held,
+		Reactions:        []FormattedReaction{},
+	}
+	if comment.ParentID.Valid {
+		formattedComment.ParentID = &comment.ParentID.Int32
+	}
+
+	return formattedComment
+}
+
 /*
 This function loops through the slice of comments and formats each comment element.
 */
@@ -61,9 +410,497 @@ func FormatComments(comments []Comment) []FormattedComment {
 	var formattedComments []FormattedComment
 
 	for _, comment := range comments {
-		formattedComment := FormattedComment(comment)
+		formattedComment := FormatComment(comment)
 		formattedComments = append(formattedComments, formattedComment)
 	}
 
 	return formattedComments
 }
+
+/*
+This function loops through the slice of tags and formats each tag element.
+*/
+func FormatTags(tags []GetTagsRow) []FormattedTag {
+	var formattedTags []FormattedTag
+
+	for _, tag := range tags {
+		formattedTag := FormattedTag(tag)
+		formattedTags = append(formattedTags, formattedTag)
+	}
+
+	return formattedTags
+}
+
+/*
+This function formats a tag together with the number of threads using it and its aliases.
+*/
+func FormatTagDetails(tag Tag, count int64, aliases []string) FormattedTagDetails {
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	return FormattedTagDetails{
+		ID:               tag.ID,
+		Name:             tag.Name,
+		Description:      tag.Description,
+		Canonical:        tag.Canonical,
+		Restricted:       tag.Restricted,
+		CreatedTimestamp: tag.CreatedTimestamp,
+		Count:            count,
+		Aliases:          aliases,
+	}
+}
+
+/*
+This function loops through the slice of search results and formats each result element.
+The snippet is HTML escaped, then the matches that were marked by the query
+(with the U+E000 and U+E001 private use characters) are wrapped in <mark> tags.
+*/
+func FormatSearchResults(results []SearchPostsRow) []FormattedSearchResult {
+	var formattedResults []FormattedSearchResult
+
+	highlighter := strings.NewReplacer("\uE000", "<mark>", "\uE001", "</mark>")
+
+	for _, result := range results {
+		formattedResult := FormattedSearchResult{
+			Type:             result.Type,
+			ThreadID:         result.ThreadID,
+			Title:            result.Title,
+			Snippet:          highlighter.Replace(html.EscapeString(result.Snippet)),
+			CreatorID:        result.CreatorID,
+			CreatedTimestamp: result.CreatedTimestamp,
+			Rank:             result.Rank,
+		}
+		if result.CommentID.Valid {
+			formattedResult.CommentID = &result.CommentID.Int32
+		}
+		formattedResults = append(formattedResults, formattedResult)
+	}
+
+	return formattedResults
+}
+
+/*
+This function formats a single category. The required tags start off empty,
+they are filled in separately since they are stored in another table.
+*/
+func FormatCategory(category Category) FormattedCategory {
+	formattedCategory := FormattedCategory{
+		ID:               category.ID,
+		Slug:             category.Slug,
+		Name:             category.Name,
+		Description:      category.Description,
+		Position:         category.Position,
+		PostRole:         category.PostRole,
+		RequiredTags:     []string{},
+		CreatedTimestamp: category.CreatedTimestamp,
+	}
+	if category.ParentID.Valid {
+		formattedCategory.ParentID = &category.ParentID.Int32
+	}
+
+	return formattedCategory
+}
+
+/*
+This function loops through the slice of categories and formats each category element.
+*/
+func FormatCategories(categories []Category) []FormattedCategory {
+	var formattedCategories []FormattedCategory
+
+	for _, category := range categories {
+		formattedCategories = append(formattedCategories, FormatCategory(category))
+	}
+
+	return formattedCategories
+}
+
+/*
+This function loops through the slice of notifications and formats each notification element.
+The comment ID is null for notifications about the thread itself,
+and the thread is null for notifications about reports of users or deleted content.
+*/
+func FormatNotifications(notifications []GetNotificationsRow) []FormattedNotification {
+	formattedNotifications := []FormattedNotification{}
+
+	for _, notification := range notifications {
+		formattedNotification := FormattedNotification{
+			ID:               notification.ID,
+			Type:             notification.Type,
+			ActorID:          notification.ActorID,
+			ActorUsername:    notification.ActorUsername,
+			Read:             notification.Read,
+			CreatedTimestamp: notification.CreatedTimestamp,
+		}
+		if notification.ThreadID.Valid {
+			formattedNotification.ThreadID = &notification.ThreadID.Int32
+		}
+		if notification.ThreadTitle.Valid {
+			formattedNotification.ThreadTitle = &notification.ThreadTitle.String
+		}
+		if notification.CommentID.Valid {
+			formattedNotification.CommentID = &notification.CommentID.Int32
+		}
+		if notification.CaseID.Valid {
+			formattedNotification.CaseID = &notification.CaseID.Int32
+		}
+		formattedNotifications = append(formattedNotifications, formattedNotification)
+	}
+
+	return formattedNotifications
+}
+
+/*
+This function formats a single bookmark. The comment ID is null for bookmarks of threads.
+*/
+func FormatBookmark(bookmark Bookmark) FormattedBookmark {
+	formattedBookmark := FormattedBookmark{
+		ID:               bookmark.ID,
+		ThreadID:         bookmark.ThreadID,
+		Note:             bookmark.Note,
+		Folder:           bookmark.Folder,
+		CreatedTimestamp: bookmark.CreatedTimestamp,
+	}
+	if bookmark.CommentID.Valid {
+		formattedBookmark.CommentID = &bookmark.CommentID.Int32
+	}
+
+	return formattedBookmark
+}
+
+/*
+This function loops through the slice of saved bookmarks and formats each bookmark element,
+which includes the content of the bookmarked thread or comment.
+*/
+func FormatSavedBookmarks(bookmarks []GetBookmarksRow) []FormattedSavedBookmark {
+	formattedBookmarks := []FormattedSavedBookmark{}
+
+	for _, bookmark := range bookmarks {
+		formattedBookmark := FormattedSavedBookmark{
+			ID:               bookmark.ID,
+			ThreadID:         bookmark.ThreadID,
+			ThreadTitle:      bookmark.ThreadTitle,
+			Content:          bookmark.Content,
+			ContentHTML:      bookmark.ContentHTML,
+			Note:             bookmark.Note,
+			Folder:           bookmark.Folder,
+			CreatedTimestamp: bookmark.CreatedTimestamp,
+		}
+		if bookmark.CommentID.Valid {
+			formattedBookmark.CommentID = &bookmark.CommentID.Int32
+		}
+		formattedBookmarks = append(formattedBookmarks, formattedBookmark)
+	}
+
+	return formattedBookmarks
+}
+
+/*
+This function formats the read marker of a thread.
+The last read comment is null if the thread had no comments when it was read.
+*/
+func FormatThreadRead(threadRead ThreadRead) FormattedThreadRead {
+	formattedThreadRead := FormattedThreadRead{
+		ThreadID:         threadRead.ThreadID,
+		ReadCommentCount: threadRead.ReadCommentCount,
+		ReadTimestamp:    threadRead.ReadTimestamp,
+	}
+	if threadRead.LastReadCommentID.Valid {
+		formattedThreadRead.LastReadCommentID = &threadRead.LastReadCommentID.Int32
+	}
+
+	return formattedThreadRead
+}
+
+/*
+This function loops through the slice of conversations and formats each conversation element.
+The participants start off empty, they are filled in separately since they are stored in another table.
+*/
+func FormatConversations(conversations []GetUserConversationsRow) []FormattedConversation {
+	formattedConversations := []FormattedConversation{}
+
+	for _, conversation := range conversations {
+		formattedConversations = append(formattedConversations, FormattedConversation{
+			ID:                   conversation.ID,
+			Title:                conversation.Title,
+			IsGroup:              conversation.IsGroup,
+			CreatorID:            conversation.CreatorID,
+			CreatedTimestamp:     conversation.CreatedTimestamp,
+			LastMessageTimestamp: conversation.LastMessageTimestamp,
+			UnreadCount:          conversation.UnreadCount,
+			Participants:         []FormattedParticipant{},
+		})
+	}
+
+	return formattedConversations
+}
+
+/*
+This function formats a single participant of a conversation.
+The left timestamp is null if the participant is still in the conversation.
+*/
+func FormatParticipant(participant GetConversationsParticipantsRow) FormattedParticipant {
+	formattedParticipant := FormattedParticipant{
+		UserID:            participant.UserID,
+		Username:          participant.Username,
+		JoinedTimestamp:   participant.JoinedTimestamp,
+		LastReadMessageID: participant.LastReadMessageID,
+	}
+	if participant.LeftTimestamp.Valid {
+		formattedParticipant.LeftTimestamp = &participant.LeftTimestamp.Time
+	}
+
+	return formattedParticipant
+}
+
+/*
+This function loops through the slice of messages and formats each message element.
+*/
+func FormatMessages(messages []Message) []FormattedMessage {
+	formattedMessages := []FormattedMessage{}
+
+	for _, message := range messages {
+		formattedMessages = append(formattedMessages, FormattedMessage(message))
+	}
+
+	return formattedMessages
+}
+
+/*
+This function loops through the slice of blocked users and formats each element.
+*/
+func FormatUserBlocks(blocks []GetUserBlocksRow) []FormattedUserBlock {
+	formattedBlocks := []FormattedUserBlock{}
+
+	for _, block := range blocks {
+		formattedBlocks = append(formattedBlocks, FormattedUserBlock(block))
+	}
+
+	return formattedBlocks
+}
+
+/*
+This function formats a poll together with the results of its options and the options voted by the viewer.
+The votes of each option are null if the results are hidden until the poll closes.
+*/
+func FormatPoll(poll Poll, results []GetPollResultsRow, votersCount int64, votedOptionIDs []int32, closed bool) FormattedPoll {
+	formattedPoll := FormattedPoll{
+		ID:             poll.ID,
+		Question:       poll.Question,
+		MultipleChoice: poll.MultipleChoice,
+		HideResults:    poll.HideResults,
+		Closed:         closed,
+		VotersCount:    votersCount,
+		Options:        []FormattedPollOption{},
+		VotedOptionIDs: votedOptionIDs,
+	}
+	if poll.ClosesTimestamp.Valid {
+		formattedPoll.ClosesTimestamp = &poll.ClosesTimestamp.Time
+	}
+
+	for _, result := range results {
+		option := FormattedPollOption{
+			ID:   result.ID,
+			Text: result.Text,
+		}
+		if !poll.HideResults || closed {
+			option.Votes = &result.Votes
+		}
+		formattedPoll.Options = append(formattedPoll.Options, option)
+	}
+
+	return formattedPoll
+}
+
+/*
+This function formats an attachment together with the URL that threads and comments link to it with,
+and the URLs of its thumbnails once they are ready.
+*/
+func FormatAttachment(attachment GetAttachmentRow, thumbnails []GetBlobThumbnailsRow) FormattedAttachment {
+	formattedAttachment := FormattedAttachment{
+		ID:               attachment.ID,
+		URL:              fmt.Sprintf("/v1/attachments/%d", attachment.ID),
+		Filename:         attachment.Filename,
+		ContentType:      attachment.ContentType,
+		Size:             attachment.Size,
+		ThumbnailStatus:  attachment.ThumbnailStatus,
+		Thumbnails:       []FormattedThumbnail{},
+		CreatedTimestamp: attachment.CreatedTimestamp,
+	}
+
+	for _, thumbnail := range thumbnails {
+		formattedAttachment.Thumbnails = append(formattedAttachment.Thumbnails, FormattedThumbnail{
+			Size:   thumbnail.Size,
+			Width:  thumbnail.Width,
+			Height: thumbnail.Height,
+			URL:    fmt.Sprintf("/v1/attachments/%d/thumbnails/%d", attachment.ID, thumbnail.Size),
+		})
+	}
+
+	return formattedAttachment
+}
+
+/*
+This function formats a single report, as seen by the user who made it.
+*/
+func FormatReport(report Report) FormattedReport {
+	return FormattedReport{
+		ID:               report.ID,
+		CaseID:           report.CaseID,
+		ReporterID:       report.ReporterID,
+		Reason:           report.Reason,
+		Details:          report.Details,
+		CreatedTimestamp: report.CreatedTimestamp,
+	}
+}
+
+/*
+This function formats a single report case. The thread and comment are null if they do not apply
+or were deleted, and the reports start off empty since they are only included for a single case.
+*/
+func FormatReportCase(reportCase GetReportCaseRow) FormattedReportCase {
+	formattedReportCase := FormattedReportCase{
+		ID:               reportCase.ID,
+		TargetType:       reportCase.TargetType,
+		TargetUserID:     reportCase.TargetUserID,
+		TargetUsername:   reportCase.TargetUsername,
+		TargetTitle:      reportCase.TargetTitle,
+		TargetContent:    reportCase.TargetContent,
+		Status:           reportCase.Status,
+		Reasons:          reportCase.Reasons,
+		ReportCount:      reportCase.ReportCount,
+		AssigneeID:       reportCase.AssigneeID,
+		ResolverID:       reportCase.ResolverID,
+		ResolutionNote:   reportCase.ResolutionNote,
+		CreatedTimestamp: reportCase.CreatedTimestamp,
+		UpdatedTimestamp: reportCase.UpdatedTimestamp,
+	}
+	if reportCase.ThreadID.Valid {
+		formattedReportCase.ThreadID = &reportCase.ThreadID.Int32
+	}
+	if reportCase.CommentID.Valid {
+		formattedReportCase.CommentID = &reportCase.CommentID.Int32
+	}
+	if reportCase.ResolvedTimestamp.Valid {
+		formattedReportCase.ResolvedTimestamp = &reportCase.ResolvedTimestamp.Time
+	}
+	if formattedReportCase.Reasons == nil {
+		formattedReportCase.Reasons = []string{}
+	}
+
+	return formattedReportCase
+}
+
+/*
+This function loops through the slice of report cases and formats each report case element.
+*/
+func FormatReportCases(reportCases []GetReportCasesRow) []FormattedReportCase {
+	formattedReportCases := []FormattedReportCase{}
+
+	for _, reportCase := range reportCases {
+		formattedReportCases = append(formattedReportCases, FormatReportCase(GetReportCaseRow(reportCase)))
+	}
+
+	return formattedReportCases
+}
+
+/*
+This function loops through the slice of the reports of a case and formats each report element.
+The reporter is null for reports created by auto-moderation.
+*/
+func FormatCaseReports(reports []GetCaseReportsRow) []FormattedCaseReport {
+	formattedReports := []FormattedCaseReport{}
+
+	for _, report := range reports {
+		formattedReport := FormattedCaseReport{
+			ID:               report.ID,
+			ReporterID:       report.ReporterID,
+			Reason:           report.Reason,
+			Details:          report.Details,
+			CreatedTimestamp: report.CreatedTimestamp,
+		}
+		if report.ReporterUsername.Valid {
+			formattedReport.ReporterUsername = &report.ReporterUsername.String
+		}
+		formattedReports = append(formattedReports, formattedReport)
+	}
+
+	return formattedReports
+}
+
+/*
+This function formats a single auto-moderation rule, where the conditions that are not used are null.
+*/
+func FormatAutomodRule(rule AutomodRule) FormattedAutomodRule {
+	formattedRule := FormattedAutomodRule{
+		ID:               rule.ID,
+		Name:             rule.Name,
+		Pattern:          rule.Pattern,
+		Words:            rule.Words,
+		Action:           rule.Action,
+		Message:          rule.Message,
+		Replacement:      rule.Replacement,
+		Enabled:          rule.Enabled,
+		CreatedTimestamp: rule.CreatedTimestamp,
+		UpdatedTimestamp: rule.UpdatedTimestamp,
+	}
+	if rule.MaxLinks.Valid {
+		formattedRule.MaxLinks = &rule.MaxLinks.Int32
+	}
+	if rule.MaxAccountAgeHours.Valid {
+		formattedRule.MaxAccountAgeHours = &rule.MaxAccountAgeHours.Int32
+	}
+	if formattedRule.Words == nil {
+		formattedRule.Words = []string{}
+	}
+
+	return formattedRule
+}
+
+/*
+This function loops through the slice of auto-moderation rules and formats each rule element.
+*/
+func FormatAutomodRules(rules []AutomodRule) []FormattedAutomodRule {
+	formattedRules := []FormattedAutomodRule{}
+
+	for _, rule := range rules {
+		formattedRules = append(formattedRules, FormatAutomodRule(rule))
+	}
+
+	return formattedRules
+}
+
+/*
+This function formats a single spam sample. The thread and comment are null if they were deleted.
+*/
+func FormatSpamSample(sample SpamSample) FormattedSpamSample {
+	formattedSample := FormattedSpamSample{
+		ID:               sample.ID,
+		Content:          sample.Content,
+		Spam:             sample.Spam,
+		TrainerID:        sample.TrainerID,
+		CreatedTimestamp: sample.CreatedTimestamp,
+		UpdatedTimestamp: sample.UpdatedTimestamp,
+	}
+	if sample.ThreadID.Valid {
+		formattedSample.ThreadID = &sample.ThreadID.Int32
+	}
+	if sample.CommentID.Valid {
+		formattedSample.CommentID = &sample.CommentID.Int32
+	}
+
+	return formattedSample
+}
+
+/*
+This function loops through the slice of spam tokens and formats each token element.
+*/
+func FormatSpamTokens(tokens []GetSpamTokenWeightsRow) []FormattedSpamToken {
+	formattedTokens := []FormattedSpamToken{}
+
+	for _, token := range tokens {
+		formattedTokens = append(formattedTokens, FormattedSpamToken(token))
+	}
+
+	return formattedTokens
+}
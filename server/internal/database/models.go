@@ -5,32 +5,316 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Attachment struct {
+	ID               int32
+	BlobSha256       string
+	UploaderID       uuid.UUID
+	Filename         string
+	CreatedTimestamp time.Time
+}
+
+type AttachmentReference struct {
+	AttachmentID int32
+	ThreadID     int32
+	CommentID    sql.NullInt32
+}
+
+type AutomodRule struct {
+	ID                 int32
+	Name               string
+	Pattern            string
+	Words              []string
+	MaxLinks           sql.NullInt32
+	MaxAccountAgeHours sql.NullInt32
+	Action             string
+	Message            string
+	Replacement        string
+	Enabled            bool
+	CreatedTimestamp   time.Time
+	UpdatedTimestamp   time.Time
+}
+
+type Blob struct {
+	Sha256            string
+	Size              int32
+	ContentType       string
+	UploadedTimestamp time.Time
+	ThumbnailStatus   string
+	ThumbnailAttempts int32
+}
+
+type BlobThumbnail struct {
+	SourceSha256    string
+	Size            int32
+	ThumbnailSha256 string
+	Width           int32
+	Height          int32
+}
+
+type Bookmark struct {
+	ID               int32
+	UserID           uuid.UUID
+	ThreadID         int32
+	CommentID        sql.NullInt32
+	Note             string
+	Folder           string
+	CreatedTimestamp time.Time
+}
+
+type Category struct {
+	ID               int32
+	Slug             string
+	Name             string
+	Description      string
+	Position         int32
+	ParentID         sql.NullInt32
+	PostRole         string
+	CreatedTimestamp time.Time
+}
+
+type CategoryRequiredTag struct {
+	CategoryID int32
+	TagID      int32
+}
+
 type Comment struct {
+	ID                  int32
+	Content             string
+	ThreadID            int32
+	CreatorID           uuid.UUID
+	CreatedTimestamp    time.Time
+	UpdatedTimestamp    time.Time
+	SearchVector        interface{}
+	ContentHTML         string
+	ParentID            sql.NullInt32
+	SubscribersNotified bool
+	Held                bool
+}
+
+type CommentReaction struct {
+	CommentID        int32
+	UserID           uuid.UUID
+	Reaction         string
+	CreatedTimestamp time.Time
+}
+
+type Conversation struct {
+	ID                   int32
+	CreatorID            uuid.NullUUID
+	IsGroup              bool
+	Title                string
+	CreatedTimestamp     time.Time
+	LastMessageTimestamp time.Time
+	DirectLowID          uuid.NullUUID
+	DirectHighID         uuid.NullUUID
+}
+
+type ConversationParticipant struct {
+	ConversationID    int32
+	UserID            uuid.UUID
+	JoinedTimestamp   time.Time
+	LeftTimestamp     sql.NullTime
+	LastReadMessageID int32
+}
+
+type Message struct {
 	ID               int32
+	ConversationID   int32
+	SenderID         uuid.UUID
 	Content          string
+	ContentHTML      string
+	CreatedTimestamp time.Time
+}
+
+type Notification struct {
+	ID               int32
+	UserID           uuid.UUID
+	Type             string
+	ActorID          uuid.UUID
+	ThreadID         sql.NullInt32
+	CommentID        sql.NullInt32
+	Read             bool
+	CreatedTimestamp time.Time
+	CaseID           sql.NullInt32
+}
+
+type Poll struct {
+	ID               int32
 	ThreadID         int32
-	CreatorID        uuid.UUID
+	Question         string
+	MultipleChoice   bool
+	HideResults      bool
+	ClosesTimestamp  sql.NullTime
 	CreatedTimestamp time.Time
-	UpdatedTimestamp time.Time
 }
 
-type Thread struct {
+type PollBallot struct {
+	PollID           int32
+	UserID           uuid.UUID
+	CreatedTimestamp time.Time
+}
+
+type PollOption struct {
+	ID       int32
+	PollID   int32
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	PollID   int32
+	UserID   uuid.UUID
+	OptionID int32
+}
+
+type Report struct {
 	ID               int32
+	CaseID           int32
+	ReporterID       uuid.NullUUID
+	Reason           string
+	Details          string
+	CreatedTimestamp time.Time
+}
+
+type ReportCase struct {
+	ID                int32
+	TargetType        string
+	ThreadID          sql.NullInt32
+	CommentID         sql.NullInt32
+	TargetUserID      uuid.UUID
+	TargetTitle       string
+	TargetContent     string
+	Status            string
+	ReportCount       int32
+	AssigneeID        uuid.NullUUID
+	ResolverID        uuid.NullUUID
+	ResolutionNote    string
+	CreatedTimestamp  time.Time
+	UpdatedTimestamp  time.Time
+	ResolvedTimestamp sql.NullTime
+}
+
+type SearchPost struct {
+	Type             string
+	ThreadID         int32
+	CommentID        sql.NullInt32
 	Title            string
 	Content          string
-	Tags             []string
 	CreatorID        uuid.UUID
 	CreatedTimestamp time.Time
+	Document         interface{}
+}
+
+type SpamModel struct {
+	ID             bool
+	SpamSamples    int64
+	HamSamples     int64
+	Vocabulary     int64
+	SpamTokenCount int64
+	HamTokenCount  int64
+}
+
+type SpamSample struct {
+	ID               int32
+	ThreadID         sql.NullInt32
+	CommentID        sql.NullInt32
+	Content          string
+	Spam             bool
+	TrainerID        uuid.NullUUID
+	CreatedTimestamp time.Time
 	UpdatedTimestamp time.Time
 }
 
+type SpamToken struct {
+	Token     string
+	SpamCount int32
+	HamCount  int32
+}
+
+type Tag struct {
+	ID               int32
+	Name             string
+	Description      string
+	Canonical        bool
+	Restricted       bool
+	CreatedTimestamp time.Time
+}
+
+type TagAlias struct {
+	Alias            string
+	TagID            int32
+	CreatedTimestamp time.Time
+}
+
+type Thread struct {
+	ID                    int32
+	Title                 string
+	Content               string
+	CreatorID             uuid.UUID
+	CreatedTimestamp      time.Time
+	UpdatedTimestamp      time.Time
+	Score                 int32
+	HotRank               float64
+	LastActivityTimestamp time.Time
+	CommentCount          int32
+	LastCommenterID       uuid.NullUUID
+	SearchVector          interface{}
+	Pinned                bool
+	Locked                bool
+	Archived              bool
+	CategoryID            int32
+	ContentHTML           string
+	AcceptedCommentID     sql.NullInt32
+	Held                  bool
+}
+
+type ThreadReaction struct {
+	ThreadID         int32
+	UserID           uuid.UUID
+	Reaction         string
+	CreatedTimestamp time.Time
+}
+
+type ThreadRead struct {
+	UserID                   uuid.UUID
+	ThreadID                 int32
+	ReadCommentCount         int32
+	LastReadCommentID        sql.NullInt32
+	LastReadCommentTimestamp sql.NullTime
+	ReadTimestamp            time.Time
+}
+
+type ThreadSubscription struct {
+	UserID           uuid.UUID
+	ThreadID         int32
+	CreatedTimestamp time.Time
+}
+
+type ThreadTag struct {
+	ThreadID int32
+	TagID    int32
+	Position int16
+}
+
 type User struct {
-	ID       uuid.UUID
-	Username string
-	Password string
+	ID                 uuid.UUID
+	Username           string
+	Password           string
+	Role               string
+	AutoWatchCreated   bool
+	AutoWatchCommented bool
+	BannedUntil        sql.NullTime
+	CreatedTimestamp   time.Time
+}
+
+type UserBlock struct {
+	BlockerID        uuid.UUID
+	BlockedID        uuid.UUID
+	CreatedTimestamp time.Time
 }
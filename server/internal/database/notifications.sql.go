@@ -0,0 +1,262 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: notifications.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createMentionNotifications = `-- name: CreateMentionNotifications :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id)
+SELECT users.id, 'mention', $1::UUID, $2::INTEGER, $3::INTEGER
+FROM users
+WHERE users.username = ANY($4::VARCHAR(20)[])
+AND users.id <> $1::UUID
+AND NOT EXISTS (
+    SELECT 1 FROM notifications
+    WHERE notifications.user_id = users.id
+    AND notifications.thread_id = $2::INTEGER
+    AND notifications.comment_id IS NOT DISTINCT FROM $3::INTEGER
+)
+ON CONFLICT (user_id, thread_id, COALESCE(comment_id, 0)) WHERE type = 'mention' DO NOTHING
+`
+
+type CreateMentionNotificationsParams struct {
+	ActorID   uuid.UUID
+	ThreadID  int32
+	CommentID sql.NullInt32
+	Usernames []string
+}
+
+func (q *Queries) CreateMentionNotifications(ctx context.Context, arg CreateMentionNotificationsParams) error {
+	_, err := q.db.ExecContext(ctx, createMentionNotifications,
+		arg.ActorID,
+		arg.ThreadID,
+		arg.CommentID,
+		pq.Array(arg.Usernames),
+	)
+	return err
+}
+
+const createNotification = `-- name: CreateNotification :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id)
+VALUES ($1, $2, $3, $4::INTEGER, $5)
+`
+
+type CreateNotificationParams struct {
+	UserID    uuid.UUID
+	Type      string
+	ActorID   uuid.UUID
+	ThreadID  int32
+	CommentID sql.NullInt32
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, createNotification,
+		arg.UserID,
+		arg.Type,
+		arg.ActorID,
+		arg.ThreadID,
+		arg.CommentID,
+	)
+	return err
+}
+
+const getNotifications = `-- name: GetNotifications :many
+SELECT notifications.id, notifications.user_id, notifications.type, notifications.actor_id, notifications.thread_id, notifications.comment_id, notifications.read, notifications.created_timestamp, notifications.case_id, users.username AS actor_username, threads.title AS thread_title
+FROM notifications
+JOIN users ON users.id = notifications.actor_id
+LEFT JOIN threads ON threads.id = notifications.thread_id
+WHERE notifications.user_id = $1
+AND ($2::INTEGER IS NULL OR notifications.id < $2::INTEGER)
+AND (NOT $3::BOOLEAN OR NOT notifications.read)
+ORDER BY notifications.id DESC
+LIMIT $4
+`
+
+type GetNotificationsParams struct {
+	UserID      uuid.UUID
+	AfterID     sql.NullInt32
+	UnreadOnly  bool
+	ResultLimit int32
+}
+
+type GetNotificationsRow struct {
+	ID               int32
+	UserID           uuid.UUID
+	Type             string
+	ActorID          uuid.UUID
+	ThreadID         sql.NullInt32
+	CommentID        sql.NullInt32
+	Read             bool
+	CreatedTimestamp time.Time
+	CaseID           sql.NullInt32
+	ActorUsername    string
+	ThreadTitle      sql.NullString
+}
+
+func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]GetNotificationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNotifications,
+		arg.UserID,
+		arg.AfterID,
+		arg.UnreadOnly,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsRow
+	for rows.Next() {
+		var i GetNotificationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.ActorID,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.Read,
+			&i.CreatedTimestamp,
+			&i.CaseID,
+			&i.ActorUsername,
+			&i.ThreadTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationsBefore = `-- name: GetNotificationsBefore :many
+SELECT notifications.id, notifications.user_id, notifications.type, notifications.actor_id, notifications.thread_id, notifications.comment_id, notifications.read, notifications.created_timestamp, notifications.case_id, users.username AS actor_username, threads.title AS thread_title
+FROM notifications
+JOIN users ON users.id = notifications.actor_id
+LEFT JOIN threads ON threads.id = notifications.thread_id
+WHERE notifications.user_id = $1
+AND notifications.id > $2::INTEGER
+AND (NOT $3::BOOLEAN OR NOT notifications.read)
+ORDER BY notifications.id ASC
+LIMIT $4
+`
+
+type GetNotificationsBeforeParams struct {
+	UserID      uuid.UUID
+	BeforeID    int32
+	UnreadOnly  bool
+	ResultLimit int32
+}
+
+type GetNotificationsBeforeRow struct {
+	ID               int32
+	UserID           uuid.UUID
+	Type             string
+	ActorID          uuid.UUID
+	ThreadID         sql.NullInt32
+	CommentID        sql.NullInt32
+	Read             bool
+	CreatedTimestamp time.Time
+	CaseID           sql.NullInt32
+	ActorUsername    string
+	ThreadTitle      sql.NullString
+}
+
+func (q *Queries) GetNotificationsBefore(ctx context.Context, arg GetNotificationsBeforeParams) ([]GetNotificationsBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationsBefore,
+		arg.UserID,
+		arg.BeforeID,
+		arg.UnreadOnly,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsBeforeRow
+	for rows.Next() {
+		var i GetNotificationsBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.ActorID,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.Read,
+			&i.CreatedTimestamp,
+			&i.CaseID,
+			&i.ActorUsername,
+			&i.ThreadTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadNotificationsCount = `-- name: GetUnreadNotificationsCount :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND NOT read
+`
+
+func (q *Queries) GetUnreadNotificationsCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUnreadNotificationsCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read = TRUE
+WHERE user_id = $1 AND NOT read
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markAllNotificationsRead, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markNotificationRead = `-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read = TRUE
+WHERE id = $1 AND user_id = $2
+RETURNING id
+`
+
+type MarkNotificationReadParams struct {
+	ID     int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, markNotificationRead, arg.ID, arg.UserID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
@@ -0,0 +1,218 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: polls.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createPoll = `-- name: CreatePoll :one
+INSERT INTO polls (thread_id, question, multiple_choice, hide_results, closes_timestamp)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, thread_id, question, multiple_choice, hide_results, closes_timestamp, created_timestamp
+`
+
+type CreatePollParams struct {
+	ThreadID        int32
+	Question        string
+	MultipleChoice  bool
+	HideResults     bool
+	ClosesTimestamp sql.NullTime
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, createPoll,
+		arg.ThreadID,
+		arg.Question,
+		arg.MultipleChoice,
+		arg.HideResults,
+		arg.ClosesTimestamp,
+	)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.Question,
+		&i.MultipleChoice,
+		&i.HideResults,
+		&i.ClosesTimestamp,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createPollBallot = `-- name: CreatePollBallot :exec
+INSERT INTO poll_ballots (poll_id, user_id)
+VALUES ($1, $2)
+`
+
+type CreatePollBallotParams struct {
+	PollID int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) CreatePollBallot(ctx context.Context, arg CreatePollBallotParams) error {
+	_, err := q.db.ExecContext(ctx, createPollBallot, arg.PollID, arg.UserID)
+	return err
+}
+
+const createPollOptions = `-- name: CreatePollOptions :exec
+INSERT INTO poll_options (poll_id, position, text)
+SELECT $1::INTEGER, options.position::INTEGER, options.text
+FROM UNNEST($2::TEXT[]) WITH ORDINALITY AS options(text, position)
+`
+
+type CreatePollOptionsParams struct {
+	PollID int32
+	Texts  []string
+}
+
+func (q *Queries) CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error {
+	_, err := q.db.ExecContext(ctx, createPollOptions, arg.PollID, pq.Array(arg.Texts))
+	return err
+}
+
+const createPollVotes = `-- name: CreatePollVotes :exec
+INSERT INTO poll_votes (poll_id, user_id, option_id)
+SELECT $1::INTEGER, $2::UUID, UNNEST($3::INTEGER[])
+`
+
+type CreatePollVotesParams struct {
+	PollID    int32
+	UserID    uuid.UUID
+	OptionIds []int32
+}
+
+func (q *Queries) CreatePollVotes(ctx context.Context, arg CreatePollVotesParams) error {
+	_, err := q.db.ExecContext(ctx, createPollVotes, arg.PollID, arg.UserID, pq.Array(arg.OptionIds))
+	return err
+}
+
+const deletePollBallot = `-- name: DeletePollBallot :execrows
+DELETE FROM poll_ballots
+WHERE poll_id = $1 AND user_id = $2
+`
+
+type DeletePollBallotParams struct {
+	PollID int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeletePollBallot(ctx context.Context, arg DeletePollBallotParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePollBallot, arg.PollID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPollResults = `-- name: GetPollResults :many
+SELECT poll_options.id, poll_options.text, COUNT(poll_votes.user_id) AS votes
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.poll_id = $1
+GROUP BY poll_options.id
+ORDER BY poll_options.position
+`
+
+type GetPollResultsRow struct {
+	ID    int32
+	Text  string
+	Votes int64
+}
+
+func (q *Queries) GetPollResults(ctx context.Context, pollID int32) ([]GetPollResultsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollResults, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollResultsRow
+	for rows.Next() {
+		var i GetPollResultsRow
+		if err := rows.Scan(&i.ID, &i.Text, &i.Votes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPollVotersCount = `-- name: GetPollVotersCount :one
+SELECT COUNT(*) FROM poll_ballots
+WHERE poll_id = $1
+`
+
+func (q *Queries) GetPollVotersCount(ctx context.Context, pollID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getPollVotersCount, pollID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getThreadPoll = `-- name: GetThreadPoll :one
+SELECT id, thread_id, question, multiple_choice, hide_results, closes_timestamp, created_timestamp FROM polls
+WHERE thread_id = $1
+`
+
+func (q *Queries) GetThreadPoll(ctx context.Context, threadID int32) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, getThreadPoll, threadID)
+	var i Poll
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.Question,
+		&i.MultipleChoice,
+		&i.HideResults,
+		&i.ClosesTimestamp,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const getUserPollVotes = `-- name: GetUserPollVotes :many
+SELECT option_id FROM poll_votes
+WHERE poll_id = $1 AND user_id = $2
+ORDER BY option_id
+`
+
+type GetUserPollVotesParams struct {
+	PollID int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetUserPollVotes(ctx context.Context, arg GetUserPollVotesParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getUserPollVotes, arg.PollID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var option_id int32
+		if err := rows.Scan(&option_id); err != nil {
+			return nil, err
+		}
+		items = append(items, option_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,207 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: reactions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createCommentReaction = `-- name: CreateCommentReaction :one
+INSERT INTO comment_reactions (comment_id, user_id, reaction)
+VALUES ($1, $2, $3)
+RETURNING comment_id, user_id, reaction, created_timestamp
+`
+
+type CreateCommentReactionParams struct {
+	CommentID int32
+	UserID    uuid.UUID
+	Reaction  string
+}
+
+func (q *Queries) CreateCommentReaction(ctx context.Context, arg CreateCommentReactionParams) (CommentReaction, error) {
+	row := q.db.QueryRowContext(ctx, createCommentReaction, arg.CommentID, arg.UserID, arg.Reaction)
+	var i CommentReaction
+	err := row.Scan(
+		&i.CommentID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createThreadReaction = `-- name: CreateThreadReaction :one
+INSERT INTO thread_reactions (thread_id, user_id, reaction)
+VALUES ($1, $2, $3)
+RETURNING thread_id, user_id, reaction, created_timestamp
+`
+
+type CreateThreadReactionParams struct {
+	ThreadID int32
+	UserID   uuid.UUID
+	Reaction string
+}
+
+func (q *Queries) CreateThreadReaction(ctx context.Context, arg CreateThreadReactionParams) (ThreadReaction, error) {
+	row := q.db.QueryRowContext(ctx, createThreadReaction, arg.ThreadID, arg.UserID, arg.Reaction)
+	var i ThreadReaction
+	err := row.Scan(
+		&i.ThreadID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const deleteCommentReaction = `-- name: DeleteCommentReaction :one
+DELETE FROM comment_reactions
+WHERE comment_id = $1 AND user_id = $2 AND reaction = $3
+RETURNING comment_id, user_id, reaction, created_timestamp
+`
+
+type DeleteCommentReactionParams struct {
+	CommentID int32
+	UserID    uuid.UUID
+	Reaction  string
+}
+
+func (q *Queries) DeleteCommentReaction(ctx context.Context, arg DeleteCommentReactionParams) (CommentReaction, error) {
+	row := q.db.QueryRowContext(ctx, deleteCommentReaction, arg.CommentID, arg.UserID, arg.Reaction)
+	var i CommentReaction
+	err := row.Scan(
+		&i.CommentID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const deleteThreadReaction = `-- name: DeleteThreadReaction :one
+DELETE FROM thread_reactions
+WHERE thread_id = $1 AND user_id = $2 AND reaction = $3
+RETURNING thread_id, user_id, reaction, created_timestamp
+`
+
+type DeleteThreadReactionParams struct {
+	ThreadID int32
+	UserID   uuid.UUID
+	Reaction string
+}
+
+func (q *Queries) DeleteThreadReaction(ctx context.Context, arg DeleteThreadReactionParams) (ThreadReaction, error) {
+	row := q.db.QueryRowContext(ctx, deleteThreadReaction, arg.ThreadID, arg.UserID, arg.Reaction)
+	var i ThreadReaction
+	err := row.Scan(
+		&i.ThreadID,
+		&i.UserID,
+		&i.Reaction,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const getCommentsReactions = `-- name: GetCommentsReactions :many
+SELECT comment_id, reaction, COUNT(*) AS count,
+COALESCE(BOOL_OR(user_id = $1), FALSE)::BOOLEAN AS reacted
+FROM comment_reactions
+WHERE comment_id = ANY($2::INTEGER[])
+GROUP BY comment_id, reaction
+ORDER BY comment_id, MIN(created_timestamp), reaction
+`
+
+type GetCommentsReactionsParams struct {
+	ViewerID   uuid.NullUUID
+	CommentIds []int32
+}
+
+type GetCommentsReactionsRow struct {
+	CommentID int32
+	Reaction  string
+	Count     int64
+	Reacted   bool
+}
+
+func (q *Queries) GetCommentsReactions(ctx context.Context, arg GetCommentsReactionsParams) ([]GetCommentsReactionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCommentsReactions, arg.ViewerID, pq.Array(arg.CommentIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCommentsReactionsRow
+	for rows.Next() {
+		var i GetCommentsReactionsRow
+		if err := rows.Scan(
+			&i.CommentID,
+			&i.Reaction,
+			&i.Count,
+			&i.Reacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThreadsReactions = `-- name: GetThreadsReactions :many
+SELECT thread_id, reaction, COUNT(*) AS count,
+COALESCE(BOOL_OR(user_id = $1), FALSE)::BOOLEAN AS reacted
+FROM thread_reactions
+WHERE thread_id = ANY($2::INTEGER[])
+GROUP BY thread_id, reaction
+ORDER BY thread_id, MIN(created_timestamp), reaction
+`
+
+type GetThreadsReactionsParams struct {
+	ViewerID  uuid.NullUUID
+	ThreadIds []int32
+}
+
+type GetThreadsReactionsRow struct {
+	ThreadID int32
+	Reaction string
+	Count    int64
+	Reacted  bool
+}
+
+func (q *Queries) GetThreadsReactions(ctx context.Context, arg GetThreadsReactionsParams) ([]GetThreadsReactionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadsReactions, arg.ViewerID, pq.Array(arg.ThreadIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadsReactionsRow
+	for rows.Next() {
+		var i GetThreadsReactionsRow
+		if err := rows.Scan(
+			&i.ThreadID,
+			&i.Reaction,
+			&i.Count,
+			&i.Reacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,192 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: reads.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getCommentReadPosition = `-- name: GetCommentReadPosition :one
+SELECT comments.id, comments.thread_id, comments.created_timestamp, (
+    SELECT COUNT(*) FROM comments AS earlier
+    WHERE earlier.thread_id = comments.thread_id AND NOT earlier.held
+    AND (earlier.created_timestamp, earlier.id) <= (comments.created_timestamp, comments.id)
+)::INTEGER AS position
+FROM comments
+WHERE comments.id = $1 AND NOT comments.held
+`
+
+type GetCommentReadPositionRow struct {
+	ID               int32
+	ThreadID         int32
+	CreatedTimestamp time.Time
+	Position         int32
+}
+
+func (q *Queries) GetCommentReadPosition(ctx context.Context, id int32) (GetCommentReadPositionRow, error) {
+	row := q.db.QueryRowContext(ctx, getCommentReadPosition, id)
+	var i GetCommentReadPositionRow
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CreatedTimestamp,
+		&i.Position,
+	)
+	return i, err
+}
+
+const getLatestCommentReadPosition = `-- name: GetLatestCommentReadPosition :one
+SELECT comments.id, comments.thread_id, comments.created_timestamp, (
+    SELECT COUNT(*) FROM comments AS earlier
+    WHERE earlier.thread_id = comments.thread_id AND NOT earlier.held
+)::INTEGER AS position
+FROM comments
+WHERE comments.thread_id = $1 AND NOT comments.held
+ORDER BY comments.created_timestamp DESC, comments.id DESC
+LIMIT 1
+`
+
+type GetLatestCommentReadPositionRow struct {
+	ID               int32
+	ThreadID         int32
+	CreatedTimestamp time.Time
+	Position         int32
+}
+
+func (q *Queries) GetLatestCommentReadPosition(ctx context.Context, threadID int32) (GetLatestCommentReadPositionRow, error) {
+	row := q.db.QueryRowContext(ctx, getLatestCommentReadPosition, threadID)
+	var i GetLatestCommentReadPositionRow
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CreatedTimestamp,
+		&i.Position,
+	)
+	return i, err
+}
+
+const getReadCommentsCount = `-- name: GetReadCommentsCount :one
+SELECT COUNT(*) FROM comments
+JOIN thread_reads ON thread_reads.thread_id = comments.thread_id
+JOIN threads ON threads.id = comments.thread_id
+WHERE thread_reads.user_id = $1 AND comments.thread_id = $2 AND NOT comments.held
+AND ((comments.created_timestamp, comments.id) <= (thread_reads.last_read_comment_timestamp, thread_reads.last_read_comment_id)
+OR comments.id = threads.accepted_comment_id)
+`
+
+type GetReadCommentsCountParams struct {
+	UserID   uuid.UUID
+	ThreadID int32
+}
+
+// The accepted answer is counted even if it is unread, since it is listed before the rest of the comments
+func (q *Queries) GetReadCommentsCount(ctx context.Context, arg GetReadCommentsCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getReadCommentsCount, arg.UserID, arg.ThreadID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getThreadRead = `-- name: GetThreadRead :one
+SELECT user_id, thread_id, read_comment_count, last_read_comment_id, last_read_comment_timestamp, read_timestamp FROM thread_reads
+WHERE user_id = $1 AND thread_id = $2
+`
+
+type GetThreadReadParams struct {
+	UserID   uuid.UUID
+	ThreadID int32
+}
+
+func (q *Queries) GetThreadRead(ctx context.Context, arg GetThreadReadParams) (ThreadRead, error) {
+	row := q.db.QueryRowContext(ctx, getThreadRead, arg.UserID, arg.ThreadID)
+	var i ThreadRead
+	err := row.Scan(
+		&i.UserID,
+		&i.ThreadID,
+		&i.ReadCommentCount,
+		&i.LastReadCommentID,
+		&i.LastReadCommentTimestamp,
+		&i.ReadTimestamp,
+	)
+	return i, err
+}
+
+const getThreadsReads = `-- name: GetThreadsReads :many
+SELECT thread_id, read_comment_count, read_timestamp FROM thread_reads
+WHERE user_id = $1
+AND thread_id = ANY($2::INTEGER[])
+`
+
+type GetThreadsReadsParams struct {
+	UserID    uuid.UUID
+	ThreadIds []int32
+}
+
+type GetThreadsReadsRow struct {
+	ThreadID         int32
+	ReadCommentCount int32
+	ReadTimestamp    time.Time
+}
+
+func (q *Queries) GetThreadsReads(ctx context.Context, arg GetThreadsReadsParams) ([]GetThreadsReadsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadsReads, arg.UserID, pq.Array(arg.ThreadIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadsReadsRow
+	for rows.Next() {
+		var i GetThreadsReadsRow
+		if err := rows.Scan(&i.ThreadID, &i.ReadCommentCount, &i.ReadTimestamp); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markThreadRead = `-- name: MarkThreadRead :exec
+INSERT INTO thread_reads (user_id, thread_id, read_comment_count, last_read_comment_id, last_read_comment_timestamp)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, thread_id) DO UPDATE
+SET read_comment_count = EXCLUDED.read_comment_count,
+last_read_comment_id = EXCLUDED.last_read_comment_id,
+last_read_comment_timestamp = EXCLUDED.last_read_comment_timestamp,
+read_timestamp = CURRENT_TIMESTAMP
+WHERE (COALESCE(thread_reads.last_read_comment_timestamp, '-infinity'), COALESCE(thread_reads.last_read_comment_id, 0))
+<= (COALESCE(EXCLUDED.last_read_comment_timestamp, '-infinity'), COALESCE(EXCLUDED.last_read_comment_id, 0))
+`
+
+type MarkThreadReadParams struct {
+	UserID                   uuid.UUID
+	ThreadID                 int32
+	ReadCommentCount         int32
+	LastReadCommentID        sql.NullInt32
+	LastReadCommentTimestamp sql.NullTime
+}
+
+// The marker only moves forward, so reading older comments again does not mark newer ones as unread
+func (q *Queries) MarkThreadRead(ctx context.Context, arg MarkThreadReadParams) error {
+	_, err := q.db.ExecContext(ctx, markThreadRead,
+		arg.UserID,
+		arg.ThreadID,
+		arg.ReadCommentCount,
+		arg.LastReadCommentID,
+		arg.LastReadCommentTimestamp,
+	)
+	return err
+}
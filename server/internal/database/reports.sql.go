@@ -0,0 +1,463 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: reports.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addReportCaseReport = `-- name: AddReportCaseReport :exec
+UPDATE report_cases
+SET report_count = report_count + 1, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) AddReportCaseReport(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, addReportCaseReport, id)
+	return err
+}
+
+const assignReportCase = `-- name: AssignReportCase :one
+UPDATE report_cases
+SET assignee_id = $1, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING id
+`
+
+type AssignReportCaseParams struct {
+	AssigneeID uuid.NullUUID
+	ID         int32
+}
+
+func (q *Queries) AssignReportCase(ctx context.Context, arg AssignReportCaseParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, assignReportCase, arg.AssigneeID, arg.ID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createReport = `-- name: CreateReport :one
+INSERT INTO reports (case_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING id, case_id, reporter_id, reason, details, created_timestamp
+`
+
+type CreateReportParams struct {
+	CaseID     int32
+	ReporterID uuid.NullUUID
+	Reason     string
+	Details    string
+}
+
+func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Report, error) {
+	row := q.db.QueryRowContext(ctx, createReport,
+		arg.CaseID,
+		arg.ReporterID,
+		arg.Reason,
+		arg.Details,
+	)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.CaseID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Details,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createReportResolvedNotifications = `-- name: CreateReportResolvedNotifications :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id, case_id)
+SELECT reports.reporter_id, 'report_resolved', $1::UUID,
+report_cases.thread_id, report_cases.comment_id, report_cases.id
+FROM reports
+JOIN report_cases ON report_cases.id = reports.case_id
+WHERE reports.case_id = $2::INTEGER AND reports.reporter_id IS NOT NULL
+`
+
+type CreateReportResolvedNotificationsParams struct {
+	ActorID uuid.UUID
+	CaseID  int32
+}
+
+func (q *Queries) CreateReportResolvedNotifications(ctx context.Context, arg CreateReportResolvedNotificationsParams) error {
+	_, err := q.db.ExecContext(ctx, createReportResolvedNotifications, arg.ActorID, arg.CaseID)
+	return err
+}
+
+const createWarningNotification = `-- name: CreateWarningNotification :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id, case_id)
+SELECT report_cases.target_user_id, 'warning', $1::UUID,
+report_cases.thread_id, report_cases.comment_id, report_cases.id
+FROM report_cases
+WHERE report_cases.id = $2::INTEGER
+`
+
+type CreateWarningNotificationParams struct {
+	ActorID uuid.UUID
+	CaseID  int32
+}
+
+func (q *Queries) CreateWarningNotification(ctx context.Context, arg CreateWarningNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, createWarningNotification, arg.ActorID, arg.CaseID)
+	return err
+}
+
+const getCaseReports = `-- name: GetCaseReports :many
+SELECT reports.id, reports.case_id, reports.reporter_id, reports.reason, reports.details, reports.created_timestamp, users.username AS reporter_username
+FROM reports
+LEFT JOIN users ON users.id = reports.reporter_id
+WHERE reports.case_id = $1
+ORDER BY reports.id
+`
+
+type GetCaseReportsRow struct {
+	ID               int32
+	CaseID           int32
+	ReporterID       uuid.NullUUID
+	Reason           string
+	Details          string
+	CreatedTimestamp time.Time
+	ReporterUsername sql.NullString
+}
+
+func (q *Queries) GetCaseReports(ctx context.Context, caseID int32) ([]GetCaseReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCaseReports, caseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCaseReportsRow
+	for rows.Next() {
+		var i GetCaseReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CaseID,
+			&i.ReporterID,
+			&i.Reason,
+			&i.Details,
+			&i.CreatedTimestamp,
+			&i.ReporterUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReportCase = `-- name: GetReportCase :one
+SELECT report_cases.id, report_cases.target_type, report_cases.thread_id, report_cases.comment_id, report_cases.target_user_id, report_cases.target_title, report_cases.target_content, report_cases.status, report_cases.report_count, report_cases.assignee_id, report_cases.resolver_id, report_cases.resolution_note, report_cases.created_timestamp, report_cases.updated_timestamp, report_cases.resolved_timestamp, users.username AS target_username,
+ARRAY(SELECT DISTINCT reports.reason FROM reports WHERE reports.case_id = report_cases.id ORDER BY reports.reason)::VARCHAR[] AS reasons
+FROM report_cases
+JOIN users ON users.id = report_cases.target_user_id
+WHERE report_cases.id = $1
+`
+
+type GetReportCaseRow struct {
+	ID                int32
+	TargetType        string
+	ThreadID          sql.NullInt32
+	CommentID         sql.NullInt32
+	TargetUserID      uuid.UUID
+	TargetTitle       string
+	TargetContent     string
+	Status            string
+	ReportCount       int32
+	AssigneeID        uuid.NullUUID
+	ResolverID        uuid.NullUUID
+	ResolutionNote    string
+	CreatedTimestamp  time.Time
+	UpdatedTimestamp  time.Time
+	ResolvedTimestamp sql.NullTime
+	TargetUsername    string
+	Reasons           []string
+}
+
+func (q *Queries) GetReportCase(ctx context.Context, id int32) (GetReportCaseRow, error) {
+	row := q.db.QueryRowContext(ctx, getReportCase, id)
+	var i GetReportCaseRow
+	err := row.Scan(
+		&i.ID,
+		&i.TargetType,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.TargetUserID,
+		&i.TargetTitle,
+		&i.TargetContent,
+		&i.Status,
+		&i.ReportCount,
+		&i.AssigneeID,
+		&i.ResolverID,
+		&i.ResolutionNote,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.ResolvedTimestamp,
+		&i.TargetUsername,
+		pq.Array(&i.Reasons),
+	)
+	return i, err
+}
+
+const getReportCases = `-- name: GetReportCases :many
+SELECT report_cases.id, report_cases.target_type, report_cases.thread_id, report_cases.comment_id, report_cases.target_user_id, report_cases.target_title, report_cases.target_content, report_cases.status, report_cases.report_count, report_cases.assignee_id, report_cases.resolver_id, report_cases.resolution_note, report_cases.created_timestamp, report_cases.updated_timestamp, report_cases.resolved_timestamp, users.username AS target_username,
+ARRAY(SELECT DISTINCT reports.reason FROM reports WHERE reports.case_id = report_cases.id ORDER BY reports.reason)::VARCHAR[] AS reasons
+FROM report_cases
+JOIN users ON users.id = report_cases.target_user_id
+WHERE ($1::VARCHAR IS NULL
+    OR ($1::VARCHAR = 'resolved' AND report_cases.status <> 'open')
+    OR report_cases.status = $1::VARCHAR)
+AND ($2::VARCHAR IS NULL OR report_cases.target_type = $2::VARCHAR)
+AND ($3::VARCHAR IS NULL OR EXISTS (
+    SELECT 1 FROM reports
+    WHERE reports.case_id = report_cases.id AND reports.reason = $3::VARCHAR
+))
+AND ($4::UUID IS NULL OR report_cases.assignee_id = $4::UUID)
+AND (NOT $5::BOOLEAN OR report_cases.assignee_id IS NULL)
+ORDER BY report_cases.created_timestamp ASC, report_cases.id ASC
+LIMIT $7 OFFSET $6
+`
+
+type GetReportCasesParams struct {
+	Status       sql.NullString
+	TargetType   sql.NullString
+	Reason       sql.NullString
+	AssigneeID   uuid.NullUUID
+	Unassigned   bool
+	ResultOffset int32
+	ResultLimit  int32
+}
+
+type GetReportCasesRow struct {
+	ID                int32
+	TargetType        string
+	ThreadID          sql.NullInt32
+	CommentID         sql.NullInt32
+	TargetUserID      uuid.UUID
+	TargetTitle       string
+	TargetContent     string
+	Status            string
+	ReportCount       int32
+	AssigneeID        uuid.NullUUID
+	ResolverID        uuid.NullUUID
+	ResolutionNote    string
+	CreatedTimestamp  time.Time
+	UpdatedTimestamp  time.Time
+	ResolvedTimestamp sql.NullTime
+	TargetUsername    string
+	Reasons           []string
+}
+
+func (q *Queries) GetReportCases(ctx context.Context, arg GetReportCasesParams) ([]GetReportCasesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReportCases,
+		arg.Status,
+		arg.TargetType,
+		arg.Reason,
+		arg.AssigneeID,
+		arg.Unassigned,
+		arg.ResultOffset,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReportCasesRow
+	for rows.Next() {
+		var i GetReportCasesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TargetType,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.TargetUserID,
+			&i.TargetTitle,
+			&i.TargetContent,
+			&i.Status,
+			&i.ReportCount,
+			&i.AssigneeID,
+			&i.ResolverID,
+			&i.ResolutionNote,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+			&i.ResolvedTimestamp,
+			&i.TargetUsername,
+			pq.Array(&i.Reasons),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReportCasesCount = `-- name: GetReportCasesCount :one
+SELECT COUNT(*) FROM report_cases
+WHERE ($1::VARCHAR IS NULL
+    OR ($1::VARCHAR = 'resolved' AND report_cases.status <> 'open')
+    OR report_cases.status = $1::VARCHAR)
+AND ($2::VARCHAR IS NULL OR report_cases.target_type = $2::VARCHAR)
+AND ($3::VARCHAR IS NULL OR EXISTS (
+    SELECT 1 FROM reports
+    WHERE reports.case_id = report_cases.id AND reports.reason = $3::VARCHAR
+))
+AND ($4::UUID IS NULL OR report_cases.assignee_id = $4::UUID)
+AND (NOT $5::BOOLEAN OR report_cases.assignee_id IS NULL)
+`
+
+type GetReportCasesCountParams struct {
+	Status     sql.NullString
+	TargetType sql.NullString
+	Reason     sql.NullString
+	AssigneeID uuid.NullUUID
+	Unassigned bool
+}
+
+func (q *Queries) GetReportCasesCount(ctx context.Context, arg GetReportCasesCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getReportCasesCount,
+		arg.Status,
+		arg.TargetType,
+		arg.Reason,
+		arg.AssigneeID,
+		arg.Unassigned,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const openCommentReportCase = `-- name: OpenCommentReportCase :one
+INSERT INTO report_cases (target_type, thread_id, comment_id, target_user_id, target_content)
+VALUES ('comment', $1, $2, $3, $4)
+ON CONFLICT (comment_id) WHERE status = 'open' AND target_type = 'comment'
+DO UPDATE SET updated_timestamp = report_cases.updated_timestamp
+RETURNING id
+`
+
+type OpenCommentReportCaseParams struct {
+	ThreadID      sql.NullInt32
+	CommentID     sql.NullInt32
+	TargetUserID  uuid.UUID
+	TargetContent string
+}
+
+func (q *Queries) OpenCommentReportCase(ctx context.Context, arg OpenCommentReportCaseParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, openCommentReportCase,
+		arg.ThreadID,
+		arg.CommentID,
+		arg.TargetUserID,
+		arg.TargetContent,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const openThreadReportCase = `-- name: OpenThreadReportCase :one
+INSERT INTO report_cases (target_type, thread_id, target_user_id, target_title, target_content)
+VALUES ('thread', $1, $2, $3, $4)
+ON CONFLICT (thread_id) WHERE status = 'open' AND target_type = 'thread'
+DO UPDATE SET updated_timestamp = report_cases.updated_timestamp
+RETURNING id
+`
+
+type OpenThreadReportCaseParams struct {
+	ThreadID      sql.NullInt32
+	TargetUserID  uuid.UUID
+	TargetTitle   string
+	TargetContent string
+}
+
+// Reports of a target with an open case join that case, which is returned even if another report opened it at the same time.
+// The title and content are only stored when the case is opened, so later reports keep them as they were
+func (q *Queries) OpenThreadReportCase(ctx context.Context, arg OpenThreadReportCaseParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, openThreadReportCase,
+		arg.ThreadID,
+		arg.TargetUserID,
+		arg.TargetTitle,
+		arg.TargetContent,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const openUserReportCase = `-- name: OpenUserReportCase :one
+INSERT INTO report_cases (target_type, target_user_id)
+VALUES ('user', $1)
+ON CONFLICT (target_user_id) WHERE status = 'open' AND target_type = 'user'
+DO UPDATE SET updated_timestamp = report_cases.updated_timestamp
+RETURNING id
+`
+
+func (q *Queries) OpenUserReportCase(ctx context.Context, targetUserID uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, openUserReportCase, targetUserID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const resolveReportCase = `-- name: ResolveReportCase :one
+UPDATE report_cases
+SET status = $1, resolver_id = $2, resolution_note = $3,
+updated_timestamp = CURRENT_TIMESTAMP, resolved_timestamp = CURRENT_TIMESTAMP
+WHERE id = $4 AND status = 'open'
+RETURNING id, target_type, thread_id, comment_id, target_user_id, target_title, target_content, status, report_count, assignee_id, resolver_id, resolution_note, created_timestamp, updated_timestamp, resolved_timestamp
+`
+
+type ResolveReportCaseParams struct {
+	Status         string
+	ResolverID     uuid.NullUUID
+	ResolutionNote string
+	ID             int32
+}
+
+func (q *Queries) ResolveReportCase(ctx context.Context, arg ResolveReportCaseParams) (ReportCase, error) {
+	row := q.db.QueryRowContext(ctx, resolveReportCase,
+		arg.Status,
+		arg.ResolverID,
+		arg.ResolutionNote,
+		arg.ID,
+	)
+	var i ReportCase
+	err := row.Scan(
+		&i.ID,
+		&i.TargetType,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.TargetUserID,
+		&i.TargetTitle,
+		&i.TargetContent,
+		&i.Status,
+		&i.ReportCount,
+		&i.AssigneeID,
+		&i.ResolverID,
+		&i.ResolutionNote,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.ResolvedTimestamp,
+	)
+	return i, err
+}
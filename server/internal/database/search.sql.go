@@ -0,0 +1,149 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: search.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT type, thread_id, comment_id, title,
+ts_headline('english', content, to_tsquery('english', $1),
+    E'StartSel=\uE000, StopSel=\uE001, MaxFragments=2, MaxWords=30, MinWords=10')::TEXT AS snippet,
+creator_id, created_timestamp, ts_rank(document, to_tsquery('english', $1))::REAL AS rank
+FROM search_posts
+WHERE document @@ to_tsquery('english', $1)
+AND ((type = 'thread' AND $2::BOOLEAN) OR (type = 'comment' AND $3::BOOLEAN))
+AND NOT EXISTS (
+    SELECT 1 FROM UNNEST($4::VARCHAR(35)[]) AS wanted(name)
+    WHERE NOT EXISTS (
+        SELECT 1 FROM thread_tags
+        JOIN tags ON tags.id = thread_tags.tag_id
+        LEFT JOIN tag_aliases ON tag_aliases.tag_id = tags.id AND LOWER(tag_aliases.alias) = LOWER(wanted.name)
+        WHERE thread_tags.thread_id = search_posts.thread_id
+        AND (LOWER(tags.name) = LOWER(wanted.name) OR tag_aliases.tag_id IS NOT NULL)
+    )
+)
+AND ($5::UUID IS NULL OR creator_id = $5)
+AND ($6::TIMESTAMPTZ IS NULL OR created_timestamp >= $6)
+AND ($7::TIMESTAMPTZ IS NULL OR created_timestamp < $7)
+ORDER BY rank DESC, created_timestamp DESC
+LIMIT $9 OFFSET $8
+`
+
+type SearchPostsParams struct {
+	Query           string
+	IncludeThreads  bool
+	IncludeComments bool
+	Tags            []string
+	CreatorID       uuid.NullUUID
+	CreatedAfter    sql.NullTime
+	CreatedBefore   sql.NullTime
+	ResultOffset    int32
+	ResultLimit     int32
+}
+
+type SearchPostsRow struct {
+	Type             string
+	ThreadID         int32
+	CommentID        sql.NullInt32
+	Title            string
+	Snippet          string
+	CreatorID        uuid.UUID
+	CreatedTimestamp time.Time
+	Rank             float32
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.IncludeThreads,
+		arg.IncludeComments,
+		pq.Array(arg.Tags),
+		arg.CreatorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ResultOffset,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.ThreadID,
+			&i.CommentID,
+			&i.Title,
+			&i.Snippet,
+			&i.CreatorID,
+			&i.CreatedTimestamp,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPostsCount = `-- name: SearchPostsCount :one
+SELECT COUNT(*) FROM search_posts
+WHERE document @@ to_tsquery('english', $1)
+AND ((type = 'thread' AND $2::BOOLEAN) OR (type = 'comment' AND $3::BOOLEAN))
+AND NOT EXISTS (
+    SELECT 1 FROM UNNEST($4::VARCHAR(35)[]) AS wanted(name)
+    WHERE NOT EXISTS (
+        SELECT 1 FROM thread_tags
+        JOIN tags ON tags.id = thread_tags.tag_id
+        LEFT JOIN tag_aliases ON tag_aliases.tag_id = tags.id AND LOWER(tag_aliases.alias) = LOWER(wanted.name)
+        WHERE thread_tags.thread_id = search_posts.thread_id
+        AND (LOWER(tags.name) = LOWER(wanted.name) OR tag_aliases.tag_id IS NOT NULL)
+    )
+)
+AND ($5::UUID IS NULL OR creator_id = $5)
+AND ($6::TIMESTAMPTZ IS NULL OR created_timestamp >= $6)
+AND ($7::TIMESTAMPTZ IS NULL OR created_timestamp < $7)
+`
+
+type SearchPostsCountParams struct {
+	Query           string
+	IncludeThreads  bool
+	IncludeComments bool
+	Tags            []string
+	CreatorID       uuid.NullUUID
+	CreatedAfter    sql.NullTime
+	CreatedBefore   sql.NullTime
+}
+
+func (q *Queries) SearchPostsCount(ctx context.Context, arg SearchPostsCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, searchPostsCount,
+		arg.Query,
+		arg.IncludeThreads,
+		arg.IncludeComments,
+		pq.Array(arg.Tags),
+		arg.CreatorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
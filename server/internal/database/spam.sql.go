@@ -0,0 +1,502 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: spam.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addSpamTokens = `-- name: AddSpamTokens :many
+INSERT INTO spam_tokens (token, spam_count, ham_count)
+SELECT token, $1::BOOLEAN::INTEGER, (NOT $1::BOOLEAN)::INTEGER
+FROM UNNEST($2::VARCHAR[]) AS token
+ON CONFLICT (token) DO UPDATE
+SET spam_count = spam_tokens.spam_count + EXCLUDED.spam_count,
+ham_count = spam_tokens.ham_count + EXCLUDED.ham_count
+RETURNING (xmax = 0)::BOOLEAN AS inserted
+`
+
+type AddSpamTokensParams struct {
+	Spam   bool
+	Tokens []string
+}
+
+// Whether each token is new to the vocabulary is returned, since the row was inserted rather than updated
+func (q *Queries) AddSpamTokens(ctx context.Context, arg AddSpamTokensParams) ([]bool, error) {
+	rows, err := q.db.QueryContext(ctx, addSpamTokens, arg.Spam, pq.Array(arg.Tokens))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []bool
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return nil, err
+		}
+		items = append(items, inserted)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const adjustSpamModel = `-- name: AdjustSpamModel :exec
+UPDATE spam_model
+SET spam_samples = spam_samples + $1::BIGINT,
+ham_samples = ham_samples + $2::BIGINT,
+vocabulary = vocabulary + $3::BIGINT,
+spam_token_count = spam_token_count + $4::BIGINT,
+ham_token_count = ham_token_count + $5::BIGINT
+`
+
+type AdjustSpamModelParams struct {
+	SpamSamples    int64
+	HamSamples     int64
+	Vocabulary     int64
+	SpamTokenCount int64
+	HamTokenCount  int64
+}
+
+func (q *Queries) AdjustSpamModel(ctx context.Context, arg AdjustSpamModelParams) error {
+	_, err := q.db.ExecContext(ctx, adjustSpamModel,
+		arg.SpamSamples,
+		arg.HamSamples,
+		arg.Vocabulary,
+		arg.SpamTokenCount,
+		arg.HamTokenCount,
+	)
+	return err
+}
+
+const backfillCommentSpamSamples = `-- name: BackfillCommentSpamSamples :execrows
+INSERT INTO spam_samples (comment_id, content, spam, trainer_id)
+SELECT DISTINCT ON (comments.id) comments.id, comments.content,
+report_cases.status <> 'dismissed', report_cases.resolver_id
+FROM report_cases
+JOIN comments ON comments.id = report_cases.comment_id
+WHERE report_cases.target_type = 'comment' AND report_cases.status <> 'open'
+AND EXISTS (SELECT 1 FROM reports WHERE reports.case_id = report_cases.id AND reports.reason = 'spam')
+ORDER BY comments.id, report_cases.resolved_timestamp DESC
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) BackfillCommentSpamSamples(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, backfillCommentSpamSamples)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const backfillThreadSpamSamples = `-- name: BackfillThreadSpamSamples :execrows
+INSERT INTO spam_samples (thread_id, content, spam, trainer_id)
+SELECT DISTINCT ON (threads.id) threads.id, threads.title || E'\n' || threads.content,
+report_cases.status <> 'dismissed', report_cases.resolver_id
+FROM report_cases
+JOIN threads ON threads.id = report_cases.thread_id
+WHERE report_cases.target_type = 'thread' AND report_cases.status <> 'open'
+AND EXISTS (SELECT 1 FROM reports WHERE reports.case_id = report_cases.id AND reports.reason = 'spam')
+ORDER BY threads.id, report_cases.resolved_timestamp DESC
+ON CONFLICT DO NOTHING
+`
+
+// Spam cases are resolved as spam unless they were dismissed, and removed content is already deleted.
+// Only the latest case of each thread is used
+func (q *Queries) BackfillThreadSpamSamples(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, backfillThreadSpamSamples)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimCommentSpamSample = `-- name: ClaimCommentSpamSample :one
+INSERT INTO spam_samples (comment_id, content, spam, trainer_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (comment_id) DO UPDATE
+SET updated_timestamp = spam_samples.updated_timestamp
+RETURNING id, thread_id, comment_id, content, spam, trainer_id, created_timestamp, updated_timestamp, (xmax = 0)::BOOLEAN AS created
+`
+
+type ClaimCommentSpamSampleParams struct {
+	CommentID sql.NullInt32
+	Content   string
+	Spam      bool
+	TrainerID uuid.NullUUID
+}
+
+type ClaimCommentSpamSampleRow struct {
+	ID               int32
+	ThreadID         sql.NullInt32
+	CommentID        sql.NullInt32
+	Content          string
+	Spam             bool
+	TrainerID        uuid.NullUUID
+	CreatedTimestamp time.Time
+	UpdatedTimestamp time.Time
+	Created          bool
+}
+
+func (q *Queries) ClaimCommentSpamSample(ctx context.Context, arg ClaimCommentSpamSampleParams) (ClaimCommentSpamSampleRow, error) {
+	row := q.db.QueryRowContext(ctx, claimCommentSpamSample,
+		arg.CommentID,
+		arg.Content,
+		arg.Spam,
+		arg.TrainerID,
+	)
+	var i ClaimCommentSpamSampleRow
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Content,
+		&i.Spam,
+		&i.TrainerID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.Created,
+	)
+	return i, err
+}
+
+const claimThreadSpamSample = `-- name: ClaimThreadSpamSample :one
+INSERT INTO spam_samples (thread_id, content, spam, trainer_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (thread_id) DO UPDATE
+SET updated_timestamp = spam_samples.updated_timestamp
+RETURNING id, thread_id, comment_id, content, spam, trainer_id, created_timestamp, updated_timestamp, (xmax = 0)::BOOLEAN AS created
+`
+
+type ClaimThreadSpamSampleParams struct {
+	ThreadID  sql.NullInt32
+	Content   string
+	Spam      bool
+	TrainerID uuid.NullUUID
+}
+
+type ClaimThreadSpamSampleRow struct {
+	ID               int32
+	ThreadID         sql.NullInt32
+	CommentID        sql.NullInt32
+	Content          string
+	Spam             bool
+	TrainerID        uuid.NullUUID
+	CreatedTimestamp time.Time
+	UpdatedTimestamp time.Time
+	Created          bool
+}
+
+// The sample is created, or the existing sample of the thread is locked and returned unchanged so that its previous label can be taken out.
+// Concurrent training of the same thread waits for the lock instead of creating a second sample
+func (q *Queries) ClaimThreadSpamSample(ctx context.Context, arg ClaimThreadSpamSampleParams) (ClaimThreadSpamSampleRow, error) {
+	row := q.db.QueryRowContext(ctx, claimThreadSpamSample,
+		arg.ThreadID,
+		arg.Content,
+		arg.Spam,
+		arg.TrainerID,
+	)
+	var i ClaimThreadSpamSampleRow
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Content,
+		&i.Spam,
+		&i.TrainerID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createSpamTokens = `-- name: CreateSpamTokens :exec
+INSERT INTO spam_tokens (token, spam_count, ham_count)
+SELECT UNNEST($1::VARCHAR[]), UNNEST($2::INTEGER[]), UNNEST($3::INTEGER[])
+`
+
+type CreateSpamTokensParams struct {
+	Tokens     []string
+	SpamCounts []int32
+	HamCounts  []int32
+}
+
+func (q *Queries) CreateSpamTokens(ctx context.Context, arg CreateSpamTokensParams) error {
+	_, err := q.db.ExecContext(ctx, createSpamTokens, pq.Array(arg.Tokens), pq.Array(arg.SpamCounts), pq.Array(arg.HamCounts))
+	return err
+}
+
+const deleteSpamTokens = `-- name: DeleteSpamTokens :exec
+DELETE FROM spam_tokens
+`
+
+func (q *Queries) DeleteSpamTokens(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteSpamTokens)
+	return err
+}
+
+const deleteUnusedSpamTokens = `-- name: DeleteUnusedSpamTokens :execrows
+DELETE FROM spam_tokens
+WHERE token = ANY($1::VARCHAR[]) AND spam_count = 0 AND ham_count = 0
+`
+
+// Tokens that are no longer in any sample are left out of the vocabulary
+func (q *Queries) DeleteUnusedSpamTokens(ctx context.Context, tokens []string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnusedSpamTokens, pq.Array(tokens))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getSpamModel = `-- name: GetSpamModel :one
+SELECT spam_samples, ham_samples, vocabulary, spam_token_count, ham_token_count FROM spam_model
+`
+
+type GetSpamModelRow struct {
+	SpamSamples    int64
+	HamSamples     int64
+	Vocabulary     int64
+	SpamTokenCount int64
+	HamTokenCount  int64
+}
+
+func (q *Queries) GetSpamModel(ctx context.Context) (GetSpamModelRow, error) {
+	row := q.db.QueryRowContext(ctx, getSpamModel)
+	var i GetSpamModelRow
+	err := row.Scan(
+		&i.SpamSamples,
+		&i.HamSamples,
+		&i.Vocabulary,
+		&i.SpamTokenCount,
+		&i.HamTokenCount,
+	)
+	return i, err
+}
+
+const getSpamSamples = `-- name: GetSpamSamples :many
+SELECT content, spam FROM spam_samples
+`
+
+type GetSpamSamplesRow struct {
+	Content string
+	Spam    bool
+}
+
+func (q *Queries) GetSpamSamples(ctx context.Context) ([]GetSpamSamplesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSpamSamples)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSpamSamplesRow
+	for rows.Next() {
+		var i GetSpamSamplesRow
+		if err := rows.Scan(&i.Content, &i.Spam); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpamTokenWeights = `-- name: GetSpamTokenWeights :many
+SELECT token, spam_count, ham_count, weight FROM (
+    SELECT spam_tokens.token, spam_tokens.spam_count, spam_tokens.ham_count, (
+        LN((spam_tokens.spam_count + 1)::FLOAT8 / (totals.spam_token_count + totals.vocabulary))
+        - LN((spam_tokens.ham_count + 1)::FLOAT8 / (totals.ham_token_count + totals.vocabulary))
+    )::FLOAT8 AS weight
+    FROM spam_tokens
+    CROSS JOIN spam_model AS totals
+) AS weights
+WHERE $1::VARCHAR IS NULL OR STARTS_WITH(token, $1::VARCHAR)
+ORDER BY
+CASE WHEN $2::BOOLEAN THEN weight END ASC,
+CASE WHEN NOT $2::BOOLEAN THEN weight END DESC,
+token ASC
+LIMIT $4 OFFSET $3
+`
+
+type GetSpamTokenWeightsParams struct {
+	Prefix       sql.NullString
+	Ham          bool
+	ResultOffset int32
+	ResultLimit  int32
+}
+
+type GetSpamTokenWeightsRow struct {
+	Token     string
+	SpamCount int32
+	HamCount  int32
+	Weight    float64
+}
+
+// Positive weights point towards spam, in the same way as the classifier
+func (q *Queries) GetSpamTokenWeights(ctx context.Context, arg GetSpamTokenWeightsParams) ([]GetSpamTokenWeightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSpamTokenWeights,
+		arg.Prefix,
+		arg.Ham,
+		arg.ResultOffset,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSpamTokenWeightsRow
+	for rows.Next() {
+		var i GetSpamTokenWeightsRow
+		if err := rows.Scan(
+			&i.Token,
+			&i.SpamCount,
+			&i.HamCount,
+			&i.Weight,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpamTokenWeightsCount = `-- name: GetSpamTokenWeightsCount :one
+SELECT COUNT(*) FROM spam_tokens
+WHERE $1::VARCHAR IS NULL OR STARTS_WITH(token, $1::VARCHAR)
+`
+
+func (q *Queries) GetSpamTokenWeightsCount(ctx context.Context, prefix sql.NullString) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSpamTokenWeightsCount, prefix)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getSpamTokens = `-- name: GetSpamTokens :many
+SELECT token, spam_count, ham_count FROM spam_tokens
+WHERE token = ANY($1::VARCHAR[])
+`
+
+func (q *Queries) GetSpamTokens(ctx context.Context, tokens []string) ([]SpamToken, error) {
+	rows, err := q.db.QueryContext(ctx, getSpamTokens, pq.Array(tokens))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SpamToken
+	for rows.Next() {
+		var i SpamToken
+		if err := rows.Scan(&i.Token, &i.SpamCount, &i.HamCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recountSpamModel = `-- name: RecountSpamModel :exec
+UPDATE spam_model
+SET spam_samples = samples.spam_samples,
+ham_samples = samples.ham_samples,
+vocabulary = tokens.vocabulary,
+spam_token_count = tokens.spam_token_count,
+ham_token_count = tokens.ham_token_count
+FROM (
+    SELECT COUNT(*) FILTER (WHERE spam) AS spam_samples, COUNT(*) FILTER (WHERE NOT spam) AS ham_samples
+    FROM spam_samples
+) AS samples, (
+    SELECT COUNT(*) AS vocabulary, COALESCE(SUM(spam_count), 0) AS spam_token_count, COALESCE(SUM(ham_count), 0) AS ham_token_count
+    FROM spam_tokens
+) AS tokens
+`
+
+// The totals are counted again after retraining, in case they drifted from the samples and tokens
+func (q *Queries) RecountSpamModel(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, recountSpamModel)
+	return err
+}
+
+const removeSpamTokens = `-- name: RemoveSpamTokens :execrows
+UPDATE spam_tokens
+SET spam_count = spam_count - $1::BOOLEAN::INTEGER,
+ham_count = ham_count - (NOT $1::BOOLEAN)::INTEGER
+WHERE token = ANY($2::VARCHAR[])
+`
+
+type RemoveSpamTokensParams struct {
+	Spam   bool
+	Tokens []string
+}
+
+func (q *Queries) RemoveSpamTokens(ctx context.Context, arg RemoveSpamTokensParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeSpamTokens, arg.Spam, pq.Array(arg.Tokens))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateSpamSample = `-- name: UpdateSpamSample :one
+UPDATE spam_samples
+SET content = $2, spam = $3, trainer_id = $4, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, thread_id, comment_id, content, spam, trainer_id, created_timestamp, updated_timestamp
+`
+
+type UpdateSpamSampleParams struct {
+	ID        int32
+	Content   string
+	Spam      bool
+	TrainerID uuid.NullUUID
+}
+
+func (q *Queries) UpdateSpamSample(ctx context.Context, arg UpdateSpamSampleParams) (SpamSample, error) {
+	row := q.db.QueryRowContext(ctx, updateSpamSample,
+		arg.ID,
+		arg.Content,
+		arg.Spam,
+		arg.TrainerID,
+	)
+	var i SpamSample
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.CommentID,
+		&i.Content,
+		&i.Spam,
+		&i.TrainerID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+	)
+	return i, err
+}
@@ -0,0 +1,126 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: subscriptions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const autoSubscribeThread = `-- name: AutoSubscribeThread :exec
+INSERT INTO thread_subscriptions (user_id, thread_id)
+SELECT users.id, $1::INTEGER
+FROM users
+WHERE users.id = $2
+AND CASE WHEN $3::BOOLEAN THEN users.auto_watch_created ELSE users.auto_watch_commented END
+ON CONFLICT DO NOTHING
+`
+
+type AutoSubscribeThreadParams struct {
+	ThreadID int32
+	UserID   uuid.UUID
+	Created  bool
+}
+
+func (q *Queries) AutoSubscribeThread(ctx context.Context, arg AutoSubscribeThreadParams) error {
+	_, err := q.db.ExecContext(ctx, autoSubscribeThread, arg.ThreadID, arg.UserID, arg.Created)
+	return err
+}
+
+const createThreadSubscription = `-- name: CreateThreadSubscription :one
+INSERT INTO thread_subscriptions (user_id, thread_id)
+VALUES ($1, $2)
+RETURNING user_id, thread_id, created_timestamp
+`
+
+type CreateThreadSubscriptionParams struct {
+	UserID   uuid.UUID
+	ThreadID int32
+}
+
+func (q *Queries) CreateThreadSubscription(ctx context.Context, arg CreateThreadSubscriptionParams) (ThreadSubscription, error) {
+	row := q.db.QueryRowContext(ctx, createThreadSubscription, arg.UserID, arg.ThreadID)
+	var i ThreadSubscription
+	err := row.Scan(&i.UserID, &i.ThreadID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const deleteThreadSubscription = `-- name: DeleteThreadSubscription :one
+DELETE FROM thread_subscriptions
+WHERE user_id = $1 AND thread_id = $2
+RETURNING user_id, thread_id, created_timestamp
+`
+
+type DeleteThreadSubscriptionParams struct {
+	UserID   uuid.UUID
+	ThreadID int32
+}
+
+func (q *Queries) DeleteThreadSubscription(ctx context.Context, arg DeleteThreadSubscriptionParams) (ThreadSubscription, error) {
+	row := q.db.QueryRowContext(ctx, deleteThreadSubscription, arg.UserID, arg.ThreadID)
+	var i ThreadSubscription
+	err := row.Scan(&i.UserID, &i.ThreadID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const getUnnotifiedComments = `-- name: GetUnnotifiedComments :many
+SELECT id FROM comments
+WHERE NOT subscribers_notified AND NOT held
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) GetUnnotifiedComments(ctx context.Context, limit int32) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getUnnotifiedComments, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notifyCommentSubscribers = `-- name: NotifyCommentSubscribers :execrows
+WITH notified AS (
+    UPDATE comments SET subscribers_notified = TRUE
+    WHERE comments.id = $1 AND NOT comments.subscribers_notified AND NOT comments.held
+    RETURNING comments.id, comments.thread_id, comments.creator_id
+)
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id)
+SELECT thread_subscriptions.user_id, 'thread_reply', notified.creator_id, notified.thread_id, notified.id
+FROM notified
+INNER JOIN thread_subscriptions ON thread_subscriptions.thread_id = notified.thread_id
+WHERE thread_subscriptions.user_id <> notified.creator_id
+AND NOT EXISTS (
+    SELECT 1 FROM notifications
+    WHERE notifications.user_id = thread_subscriptions.user_id
+    AND notifications.comment_id = notified.id
+)
+`
+
+// The comment is marked as notified in the same statement, so that it is only processed once from both the queue and a sweep.
+// Held comments are skipped until they are released, and the comment can be deleted before the notifications are created
+func (q *Queries) NotifyCommentSubscribers(ctx context.Context, commentID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, notifyCommentSubscribers, commentID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,466 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: tags.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createTag = `-- name: CreateTag :one
+INSERT INTO tags (name, description, canonical, restricted)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, description, canonical, restricted, created_timestamp
+`
+
+type CreateTagParams struct {
+	Name        string
+	Description string
+	Canonical   bool
+	Restricted  bool
+}
+
+func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, createTag,
+		arg.Name,
+		arg.Description,
+		arg.Canonical,
+		arg.Restricted,
+	)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Canonical,
+		&i.Restricted,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const createTagAlias = `-- name: CreateTagAlias :one
+INSERT INTO tag_aliases (alias, tag_id)
+VALUES ($1, $2)
+RETURNING alias, tag_id, created_timestamp
+`
+
+type CreateTagAliasParams struct {
+	Alias string
+	TagID int32
+}
+
+func (q *Queries) CreateTagAlias(ctx context.Context, arg CreateTagAliasParams) (TagAlias, error) {
+	row := q.db.QueryRowContext(ctx, createTagAlias, arg.Alias, arg.TagID)
+	var i TagAlias
+	err := row.Scan(&i.Alias, &i.TagID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const createThreadTag = `-- name: CreateThreadTag :exec
+INSERT INTO thread_tags (thread_id, tag_id, position)
+VALUES ($1, $2, $3)
+`
+
+type CreateThreadTagParams struct {
+	ThreadID int32
+	TagID    int32
+	Position int16
+}
+
+func (q *Queries) CreateThreadTag(ctx context.Context, arg CreateThreadTagParams) error {
+	_, err := q.db.ExecContext(ctx, createThreadTag, arg.ThreadID, arg.TagID, arg.Position)
+	return err
+}
+
+const deleteTag = `-- name: DeleteTag :exec
+DELETE FROM tags
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteTag, id)
+	return err
+}
+
+const deleteTagAlias = `-- name: DeleteTagAlias :one
+DELETE FROM tag_aliases
+WHERE LOWER(alias) = LOWER($1) AND tag_id = $2
+RETURNING alias, tag_id, created_timestamp
+`
+
+type DeleteTagAliasParams struct {
+	Alias string
+	TagID int32
+}
+
+func (q *Queries) DeleteTagAlias(ctx context.Context, arg DeleteTagAliasParams) (TagAlias, error) {
+	row := q.db.QueryRowContext(ctx, deleteTagAlias, arg.Alias, arg.TagID)
+	var i TagAlias
+	err := row.Scan(&i.Alias, &i.TagID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const getTagAlias = `-- name: GetTagAlias :one
+SELECT alias, tag_id, created_timestamp FROM tag_aliases
+WHERE LOWER(alias) = LOWER($1)
+`
+
+func (q *Queries) GetTagAlias(ctx context.Context, alias string) (TagAlias, error) {
+	row := q.db.QueryRowContext(ctx, getTagAlias, alias)
+	var i TagAlias
+	err := row.Scan(&i.Alias, &i.TagID, &i.CreatedTimestamp)
+	return i, err
+}
+
+const getTagAliases = `-- name: GetTagAliases :many
+SELECT alias FROM tag_aliases
+WHERE tag_id = $1
+ORDER BY LOWER(alias) ASC
+`
+
+func (q *Queries) GetTagAliases(ctx context.Context, tagID int32) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getTagAliases, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		items = append(items, alias)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagByName = `-- name: GetTagByName :one
+SELECT id, name, description, canonical, restricted, created_timestamp FROM tags
+WHERE LOWER(name) = LOWER($1)
+`
+
+func (q *Queries) GetTagByName(ctx context.Context, name string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTagByName, name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Canonical,
+		&i.Restricted,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const getTagThreadsCount = `-- name: GetTagThreadsCount :one
+SELECT COUNT(*) FROM thread_tags
+WHERE tag_id = $1
+`
+
+func (q *Queries) GetTagThreadsCount(ctx context.Context, tagID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTagThreadsCount, tagID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getTags = `-- name: GetTags :many
+SELECT tags.id, tags.name, tags.description, tags.canonical, tags.restricted, tags.created_timestamp, COUNT(threads.id) AS count
+FROM tags
+LEFT JOIN thread_tags ON thread_tags.tag_id = tags.id
+LEFT JOIN threads ON threads.id = thread_tags.thread_id AND NOT threads.held
+AND ($1::TIMESTAMPTZ IS NULL OR threads.created_timestamp >= $1)
+WHERE STARTS_WITH(LOWER(tags.name), LOWER($2))
+OR ($2 <> '' AND EXISTS (
+    SELECT 1 FROM tag_aliases
+    WHERE tag_aliases.tag_id = tags.id
+    AND STARTS_WITH(LOWER(tag_aliases.alias), LOWER($2))
+))
+GROUP BY tags.id
+HAVING COUNT(threads.id) > 0 OR (tags.canonical AND $1::TIMESTAMPTZ IS NULL)
+ORDER BY count DESC, LOWER(tags.name) ASC
+LIMIT $4 OFFSET $3
+`
+
+type GetTagsParams struct {
+	Since     sql.NullTime
+	Prefix    string
+	TagOffset int32
+	TagLimit  int32
+}
+
+type GetTagsRow struct {
+	ID               int32
+	Name             string
+	Description      string
+	Canonical        bool
+	Restricted       bool
+	CreatedTimestamp time.Time
+	Count            int64
+}
+
+// Held threads are left out of the counts, so that tags only used by held threads are not listed
+func (q *Queries) GetTags(ctx context.Context, arg GetTagsParams) ([]GetTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTags,
+		arg.Since,
+		arg.Prefix,
+		arg.TagOffset,
+		arg.TagLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagsRow
+	for rows.Next() {
+		var i GetTagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Canonical,
+			&i.Restricted,
+			&i.CreatedTimestamp,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagsCount = `-- name: GetTagsCount :one
+SELECT COUNT(*) FROM (
+    SELECT tags.id
+    FROM tags
+    LEFT JOIN thread_tags ON thread_tags.tag_id = tags.id
+    LEFT JOIN threads ON threads.id = thread_tags.thread_id AND NOT threads.held
+    AND ($1::TIMESTAMPTZ IS NULL OR threads.created_timestamp >= $1)
+    WHERE STARTS_WITH(LOWER(tags.name), LOWER($2))
+    OR ($2 <> '' AND EXISTS (
+        SELECT 1 FROM tag_aliases
+        WHERE tag_aliases.tag_id = tags.id
+        AND STARTS_WITH(LOWER(tag_aliases.alias), LOWER($2))
+    ))
+    GROUP BY tags.id
+    HAVING COUNT(threads.id) > 0 OR (tags.canonical AND $1::TIMESTAMPTZ IS NULL)
+) AS matching_tags
+`
+
+type GetTagsCountParams struct {
+	Since  sql.NullTime
+	Prefix string
+}
+
+func (q *Queries) GetTagsCount(ctx context.Context, arg GetTagsCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTagsCount, arg.Since, arg.Prefix)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getThreadsTags = `-- name: GetThreadsTags :many
+SELECT thread_tags.thread_id, tags.name
+FROM thread_tags
+JOIN tags ON tags.id = thread_tags.tag_id
+WHERE thread_tags.thread_id = ANY($1::INTEGER[])
+ORDER BY thread_tags.thread_id, thread_tags.position
+`
+
+type GetThreadsTagsRow struct {
+	ThreadID int32
+	Name     string
+}
+
+func (q *Queries) GetThreadsTags(ctx context.Context, threadIds []int32) ([]GetThreadsTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadsTags, pq.Array(threadIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadsTagsRow
+	for rows.Next() {
+		var i GetThreadsTagsRow
+		if err := rows.Scan(&i.ThreadID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeTagRestricted = `-- name: MergeTagRestricted :one
+UPDATE tags
+SET restricted = restricted OR $1::BOOLEAN
+WHERE id = $2
+RETURNING id, name, description, canonical, restricted, created_timestamp
+`
+
+type MergeTagRestrictedParams struct {
+	Restricted bool
+	ID         int32
+}
+
+func (q *Queries) MergeTagRestricted(ctx context.Context, arg MergeTagRestrictedParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, mergeTagRestricted, arg.Restricted, arg.ID)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Canonical,
+		&i.Restricted,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const moveCategoryRequiredTags = `-- name: MoveCategoryRequiredTags :exec
+INSERT INTO category_required_tags (category_id, tag_id)
+SELECT old_category_required_tags.category_id, $1
+FROM category_required_tags AS old_category_required_tags
+WHERE old_category_required_tags.tag_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type MoveCategoryRequiredTagsParams struct {
+	ToTagID   int32
+	FromTagID int32
+}
+
+func (q *Queries) MoveCategoryRequiredTags(ctx context.Context, arg MoveCategoryRequiredTagsParams) error {
+	_, err := q.db.ExecContext(ctx, moveCategoryRequiredTags, arg.ToTagID, arg.FromTagID)
+	return err
+}
+
+const moveTagAliases = `-- name: MoveTagAliases :exec
+UPDATE tag_aliases
+SET tag_id = $1
+WHERE tag_id = $2
+`
+
+type MoveTagAliasesParams struct {
+	ToTagID   int32
+	FromTagID int32
+}
+
+func (q *Queries) MoveTagAliases(ctx context.Context, arg MoveTagAliasesParams) error {
+	_, err := q.db.ExecContext(ctx, moveTagAliases, arg.ToTagID, arg.FromTagID)
+	return err
+}
+
+const moveThreadTags = `-- name: MoveThreadTags :exec
+INSERT INTO thread_tags (thread_id, tag_id, position)
+SELECT old_thread_tags.thread_id, $1, old_thread_tags.position
+FROM thread_tags AS old_thread_tags
+WHERE old_thread_tags.tag_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type MoveThreadTagsParams struct {
+	ToTagID   int32
+	FromTagID int32
+}
+
+func (q *Queries) MoveThreadTags(ctx context.Context, arg MoveThreadTagsParams) error {
+	_, err := q.db.ExecContext(ctx, moveThreadTags, arg.ToTagID, arg.FromTagID)
+	return err
+}
+
+const resolveTag = `-- name: ResolveTag :one
+SELECT id, name, description, canonical, restricted, created_timestamp FROM tags
+WHERE id = COALESCE(
+    (SELECT tag_id FROM tag_aliases WHERE LOWER(alias) = LOWER($1)),
+    (SELECT tags.id FROM tags WHERE LOWER(tags.name) = LOWER($1))
+)
+`
+
+func (q *Queries) ResolveTag(ctx context.Context, name string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, resolveTag, name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Canonical,
+		&i.Restricted,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const updateTag = `-- name: UpdateTag :one
+UPDATE tags
+SET description = $2, restricted = $3, canonical = TRUE
+WHERE id = $1
+RETURNING id, name, description, canonical, restricted, created_timestamp
+`
+
+type UpdateTagParams struct {
+	ID          int32
+	Description string
+	Restricted  bool
+}
+
+func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, updateTag, arg.ID, arg.Description, arg.Restricted)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Canonical,
+		&i.Restricted,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (name)
+VALUES ($1)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET name = tags.name
+RETURNING id, name, description, canonical, restricted, created_timestamp
+`
+
+func (q *Queries) UpsertTag(ctx context.Context, name string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, upsertTag, name)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Canonical,
+		&i.Restricted,
+		&i.CreatedTimestamp,
+	)
+	return i, err
+}
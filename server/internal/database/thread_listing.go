@@ -0,0 +1,296 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+/*
+	The thread listing is written by hand instead of being generated by sqlc,
+	because each sort mode needs its own ORDER BY to make use of its index,
+	which sqlc is unable to parameterize.
+*/
+
+const (
+	ThreadSortNew    = "new"
+	ThreadSortActive = "active"
+	ThreadSortTop    = "top"
+	ThreadSortHot    = "hot"
+)
+
+const threadColumns = "id, title, content, content_html, creator_id, created_timestamp, updated_timestamp, score, hot_rank, " +
+	"last_activity_timestamp, comment_count, last_commenter_id, pinned, locked, archived, category_id, accepted_comment_id, held"
+
+/*
+This is the condition for a thread having a tag that matches wanted.name,
+either by the name of the tag or by one of its aliases, ignoring the case.
+*/
+const threadHasWantedTag = `EXISTS (
+	SELECT 1 FROM thread_tags
+	JOIN tags ON tags.id = thread_tags.tag_id
+	LEFT JOIN tag_aliases ON tag_aliases.tag_id = tags.id AND LOWER(tag_aliases.alias) = LOWER(wanted.name)
+	WHERE thread_tags.thread_id = threads.id
+	AND (LOWER(tags.name) = LOWER(wanted.name) OR tag_aliases.tag_id IS NOT NULL)
+)`
+
+/*
+Each sort mode orders the threads by its column in descending order, after the pinned threads
+and with the ID as the tiebreaker.
+The type is used to cast the key of a cursor back into the type of the column.
+*/
+type threadSort struct {
+	column  string
+	keyType string
+}
+
+var threadSorts = map[string]threadSort{
+	ThreadSortNew:    {column: "created_timestamp", keyType: "TIMESTAMPTZ"},
+	ThreadSortActive: {column: "last_activity_timestamp", keyType: "TIMESTAMPTZ"},
+	ThreadSortTop:    {column: "score", keyType: "INTEGER"},
+	ThreadSortHot:    {column: "hot_rank", keyType: "DOUBLE PRECISION"},
+}
+
+/*
+A cursor points to a thread by whether it is pinned, its sort key and ID,
+which the page starts after (or ends before).
+*/
+type ThreadCursor struct {
+	Pinned bool
+	Key    interface{}
+	ID     int32
+}
+
+type ListThreadsParams struct {
+	CategoryID    sql.NullInt32
+	Tags          []string
+	TagsAny       []string
+	TagsNone      []string
+	CreatorID     uuid.NullUUID
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	HasComments   sql.NullBool
+	Answered      sql.NullBool
+	Sort          string
+	Since         sql.NullTime
+	After         *ThreadCursor
+	Before        *ThreadCursor
+	Limit         int32
+	Offset        int32
+}
+
+/*
+This function gets a page of threads that match the filters,
+with the pinned threads first, then ordered based on the sort mode.
+If a cursor is given, the page starts right after it, or ends right before it.
+The threads before a cursor are selected in the reverse order,
+so they are reversed again before returning.
+*/
+func (q *Queries) ListThreads(ctx context.Context, arg ListThreadsParams) ([]Thread, error) {
+	sort, ok := threadSorts[arg.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort mode: %s", arg.Sort)
+	}
+
+	conditions, args := arg.conditions()
+	direction := "DESC"
+	if arg.After != nil {
+		args = append(args, arg.After.Pinned, arg.After.Key, arg.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(pinned, %s, id) < ($%d, $%d::%s, $%d)",
+			sort.column, len(args)-2, len(args)-1, sort.keyType, len(args)))
+	}
+	if arg.Before != nil {
+		args = append(args, arg.Before.Pinned, arg.Before.Key, arg.Before.ID)
+		conditions = append(conditions, fmt.Sprintf("(pinned, %s, id) > ($%d, $%d::%s, $%d)",
+			sort.column, len(args)-2, len(args)-1, sort.keyType, len(args)))
+		direction = "ASC"
+	}
+
+	args = append(args, arg.Limit, arg.Offset)
+	query := fmt.Sprintf("SELECT %s FROM threads %s ORDER BY pinned %s, %s %s, id %s LIMIT $%d OFFSET $%d",
+		threadColumns, where(conditions), direction, sort.column, direction, direction, len(args)-1, len(args))
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Thread
+	for rows.Next() {
+		var i Thread
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.ContentHTML,
+			&i.CreatorID,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+			&i.Score,
+			&i.HotRank,
+			&i.LastActivityTimestamp,
+			&i.CommentCount,
+			&i.LastCommenterID,
+			&i.Pinned,
+			&i.Locked,
+			&i.Archived,
+			&i.CategoryID,
+			&i.AcceptedCommentID,
+			&i.Held,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if arg.Before != nil {
+		slices.Reverse(items)
+	}
+	return items, nil
+}
+
+/*
+This function counts all the threads that match the filters,
+ignoring the sort mode, cursors, limit and offset.
+*/
+func (q *Queries) CountThreads(ctx context.Context, arg ListThreadsParams) (int64, error) {
+	conditions, args := arg.conditions()
+	row := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM threads "+where(conditions), args...)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+/*
+This function gets the sort key of the thread for the sort mode, which is used in cursors.
+Timestamps are kept to the nanosecond and numbers are kept exactly,
+so that the key can be compared with the column again.
+*/
+func ThreadSortKey(sort string, thread Thread) string {
+	switch sort {
+	case ThreadSortNew:
+		return thread.CreatedTimestamp.Format(time.RFC3339Nano)
+	case ThreadSortActive:
+		return thread.LastActivityTimestamp.Format(time.RFC3339Nano)
+	case ThreadSortTop:
+		return strconv.Itoa(int(thread.Score))
+	default:
+		return strconv.FormatFloat(thread.HotRank, 'g', -1, 64)
+	}
+}
+
+/*
+This function converts the sort key from a cursor back into the type of the column,
+and returns an error if the key is invalid for the sort mode.
+*/
+func ParseThreadSortKey(sort, key string) (interface{}, error) {
+	switch sort {
+	case ThreadSortNew, ThreadSortActive:
+		return time.Parse(time.RFC3339Nano, key)
+	case ThreadSortTop:
+		score, err := strconv.ParseInt(key, 10, 32)
+		return int32(score), err
+	case ThreadSortHot:
+		return strconv.ParseFloat(key, 64)
+	default:
+		return nil, fmt.Errorf("unknown sort mode: %s", sort)
+	}
+}
+
+/*
+This function builds the conditions of the WHERE clause and their arguments from the filters.
+Tags are compared case-insensitively and resolved through aliases.
+The thread must contain all of the tags, at least one of the tags in
+TagsAny and none of the tags in TagsNone. Held threads are never listed.
+*/
+func (arg ListThreadsParams) conditions() ([]string, []interface{}) {
+	conditions := []string{"NOT held"}
+	var args []interface{}
+
+	if arg.CategoryID.Valid {
+		args = append(args, arg.CategoryID.Int32)
+		conditions = append(conditions, fmt.Sprintf("category_id = $%d", len(args)))
+	}
+
+	if len(arg.Tags) > 0 {
+		args = append(args, pq.Array(arg.Tags))
+		conditions = append(conditions, fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM UNNEST($%d::VARCHAR(35)[]) AS wanted(name) WHERE NOT %s)", len(args), threadHasWantedTag,
+		))
+	}
+
+	if len(arg.TagsAny) > 0 {
+		args = append(args, pq.Array(arg.TagsAny))
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM UNNEST($%d::VARCHAR(35)[]) AS wanted(name) WHERE %s)", len(args), threadHasWantedTag,
+		))
+	}
+
+	if len(arg.TagsNone) > 0 {
+		args = append(args, pq.Array(arg.TagsNone))
+		conditions = append(conditions, fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM UNNEST($%d::VARCHAR(35)[]) AS wanted(name) WHERE %s)", len(args), threadHasWantedTag,
+		))
+	}
+
+	if arg.CreatorID.Valid {
+		args = append(args, arg.CreatorID.UUID)
+		conditions = append(conditions, fmt.Sprintf("creator_id = $%d", len(args)))
+	}
+
+	if arg.CreatedAfter.Valid {
+		args = append(args, arg.CreatedAfter.Time)
+		conditions = append(conditions, fmt.Sprintf("created_timestamp >= $%d", len(args)))
+	}
+
+	if arg.CreatedBefore.Valid {
+		args = append(args, arg.CreatedBefore.Time)
+		conditions = append(conditions, fmt.Sprintf("created_timestamp < $%d", len(args)))
+	}
+
+	if arg.HasComments.Valid {
+		if arg.HasComments.Bool {
+			conditions = append(conditions, "comment_count > 0")
+		} else {
+			conditions = append(conditions, "comment_count = 0")
+		}
+	}
+
+	if arg.Answered.Valid {
+		if arg.Answered.Bool {
+			conditions = append(conditions, "accepted_comment_id IS NOT NULL")
+		} else {
+			conditions = append(conditions, "accepted_comment_id IS NULL")
+		}
+	}
+
+	if arg.Since.Valid {
+		args = append(args, arg.Since.Time)
+		conditions = append(conditions, fmt.Sprintf("created_timestamp >= $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+/*
+This function joins the conditions into a WHERE clause, which is empty if there are no conditions.
+*/
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
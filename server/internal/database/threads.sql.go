@@ -10,38 +10,126 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 )
 
+const acceptThreadComment = `-- name: AcceptThreadComment :one
+UPDATE threads
+SET accepted_comment_id = $1::INTEGER
+WHERE id = $2 AND accepted_comment_id IS DISTINCT FROM $1::INTEGER
+RETURNING id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held
+`
+
+type AcceptThreadCommentParams struct {
+	CommentID int32
+	ID        int32
+}
+
+func (q *Queries) AcceptThreadComment(ctx context.Context, arg AcceptThreadCommentParams) (Thread, error) {
+	row := q.db.QueryRowContext(ctx, acceptThreadComment, arg.CommentID, arg.ID)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
+	)
+	return i, err
+}
+
+const addThreadComment = `-- name: AddThreadComment :exec
+UPDATE threads
+SET comment_count = comment_count + 1,
+last_commenter_id = $1::UUID,
+last_activity_timestamp = $2
+WHERE id = $3
+`
+
+type AddThreadCommentParams struct {
+	CommenterID        uuid.UUID
+	CommentedTimestamp time.Time
+	ID                 int32
+}
+
+func (q *Queries) AddThreadComment(ctx context.Context, arg AddThreadCommentParams) error {
+	_, err := q.db.ExecContext(ctx, addThreadComment, arg.CommenterID, arg.CommentedTimestamp, arg.ID)
+	return err
+}
+
+const archiveInactiveThreads = `-- name: ArchiveInactiveThreads :execrows
+UPDATE threads
+SET archived = TRUE
+WHERE NOT archived AND NOT pinned
+AND last_activity_timestamp < $1
+`
+
+func (q *Queries) ArchiveInactiveThreads(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveInactiveThreads, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createThread = `-- name: CreateThread :one
-INSERT INTO threads (title, content, tags, creator_id)
-VALUES ($1, $2, $3, $4)
-RETURNING id, title, content, tags, creator_id, created_timestamp, updated_timestamp
+INSERT INTO threads (title, content, content_html, creator_id, category_id, held)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held
 `
 
 type CreateThreadParams struct {
-	Title     string
-	Content   string
-	Tags      []string
-	CreatorID uuid.UUID
+	Title       string
+	Content     string
+	ContentHTML string
+	CreatorID   uuid.UUID
+	CategoryID  int32
+	Held        bool
 }
 
 func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) (Thread, error) {
 	row := q.db.QueryRowContext(ctx, createThread,
 		arg.Title,
 		arg.Content,
-		pq.Array(arg.Tags),
+		arg.ContentHTML,
 		arg.CreatorID,
+		arg.CategoryID,
+		arg.Held,
 	)
 	var i Thread
 	err := row.Scan(
 		&i.ID,
 		&i.Title,
 		&i.Content,
-		pq.Array(&i.Tags),
 		&i.CreatorID,
 		&i.CreatedTimestamp,
 		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
 	)
 	return i, err
 }
@@ -49,7 +137,7 @@ func (q *Queries) CreateThread(ctx context.Context, arg CreateThreadParams) (Thr
 const deleteThread = `-- name: DeleteThread :one
 DELETE FROM threads
 WHERE id = $1
-RETURNING id, title, content, tags, creator_id, created_timestamp, updated_timestamp
+RETURNING id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held
 `
 
 func (q *Queries) DeleteThread(ctx context.Context, id int32) (Thread, error) {
@@ -59,16 +147,59 @@ func (q *Queries) DeleteThread(ctx context.Context, id int32) (Thread, error) {
 		&i.ID,
 		&i.Title,
 		&i.Content,
-		pq.Array(&i.Tags),
 		&i.CreatorID,
 		&i.CreatedTimestamp,
 		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getCommentThreadState = `-- name: GetCommentThreadState :one
+SELECT threads.locked, threads.archived, threads.held, threads.creator_id,
+comments.held AS comment_held, comments.creator_id AS comment_creator_id
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = $1
+`
+
+type GetCommentThreadStateRow struct {
+	Locked           bool
+	Archived         bool
+	Held             bool
+	CreatorID        uuid.UUID
+	CommentHeld      bool
+	CommentCreatorID uuid.UUID
+}
+
+func (q *Queries) GetCommentThreadState(ctx context.Context, id int32) (GetCommentThreadStateRow, error) {
+	row := q.db.QueryRowContext(ctx, getCommentThreadState, id)
+	var i GetCommentThreadStateRow
+	err := row.Scan(
+		&i.Locked,
+		&i.Archived,
+		&i.Held,
+		&i.CreatorID,
+		&i.CommentHeld,
+		&i.CommentCreatorID,
 	)
 	return i, err
 }
 
 const getThread = `-- name: GetThread :one
-SELECT id, title, content, tags, creator_id, created_timestamp, updated_timestamp FROM threads
+SELECT id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held FROM threads
 WHERE id = $1
 `
 
@@ -79,10 +210,22 @@ func (q *Queries) GetThread(ctx context.Context, id int32) (Thread, error) {
 		&i.ID,
 		&i.Title,
 		&i.Content,
-		pq.Array(&i.Tags),
 		&i.CreatorID,
 		&i.CreatedTimestamp,
 		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
 	)
 	return i, err
 }
@@ -99,38 +242,57 @@ func (q *Queries) GetThreadCreatorID(ctx context.Context, id int32) (uuid.UUID,
 	return creator_id, err
 }
 
-const getThreadsPaginated = `-- name: GetThreadsPaginated :many
-SELECT id, title, content, tags, creator_id, created_timestamp, updated_timestamp FROM threads
-WHERE ARRAY(SELECT LOWER(t) FROM UNNEST(tags) AS t) @> 
-ARRAY(SELECT LOWER(t) FROM UNNEST($1::VARCHAR(35)[]) AS t)
-ORDER BY updated_timestamp DESC 
-LIMIT $2 OFFSET $3
+const getThreadState = `-- name: GetThreadState :one
+SELECT locked, archived, held, creator_id FROM threads
+WHERE id = $1
+`
+
+type GetThreadStateRow struct {
+	Locked    bool
+	Archived  bool
+	Held      bool
+	CreatorID uuid.UUID
+}
+
+func (q *Queries) GetThreadState(ctx context.Context, id int32) (GetThreadStateRow, error) {
+	row := q.db.QueryRowContext(ctx, getThreadState, id)
+	var i GetThreadStateRow
+	err := row.Scan(
+		&i.Locked,
+		&i.Archived,
+		&i.Held,
+		&i.CreatorID,
+	)
+	return i, err
+}
+
+const getThreadsWithoutContentHTML = `-- name: GetThreadsWithoutContentHTML :many
+SELECT id, content FROM threads
+WHERE id > $1 AND content_html = '' AND content <> ''
+ORDER BY id ASC
+LIMIT $2
 `
 
-type GetThreadsPaginatedParams struct {
-	Column1 []string
-	Limit   int32
-	Offset  int32
+type GetThreadsWithoutContentHTMLParams struct {
+	AfterID   int32
+	BatchSize int32
 }
 
-func (q *Queries) GetThreadsPaginated(ctx context.Context, arg GetThreadsPaginatedParams) ([]Thread, error) {
-	rows, err := q.db.QueryContext(ctx, getThreadsPaginated, pq.Array(arg.Column1), arg.Limit, arg.Offset)
+type GetThreadsWithoutContentHTMLRow struct {
+	ID      int32
+	Content string
+}
+
+func (q *Queries) GetThreadsWithoutContentHTML(ctx context.Context, arg GetThreadsWithoutContentHTMLParams) ([]GetThreadsWithoutContentHTMLRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadsWithoutContentHTML, arg.AfterID, arg.BatchSize)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Thread
+	var items []GetThreadsWithoutContentHTMLRow
 	for rows.Next() {
-		var i Thread
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Content,
-			pq.Array(&i.Tags),
-			&i.CreatorID,
-			&i.CreatedTimestamp,
-			&i.UpdatedTimestamp,
-		); err != nil {
+		var i GetThreadsWithoutContentHTMLRow
+		if err := rows.Scan(&i.ID, &i.Content); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -144,39 +306,239 @@ func (q *Queries) GetThreadsPaginated(ctx context.Context, arg GetThreadsPaginat
 	return items, nil
 }
 
-const getThreadsPaginatedCount = `-- name: GetThreadsPaginatedCount :one
-SELECT COUNT(*) FROM threads
-WHERE ARRAY(SELECT LOWER(t) FROM UNNEST(tags) AS t) @> 
-ARRAY(SELECT LOWER(t) FROM UNNEST($1::VARCHAR(35)[]) AS t)
+const lockThreadState = `-- name: LockThreadState :one
+SELECT locked, archived FROM threads
+WHERE id = $1
+FOR NO KEY UPDATE
 `
 
-func (q *Queries) GetThreadsPaginatedCount(ctx context.Context, dollar_1 []string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getThreadsPaginatedCount, pq.Array(dollar_1))
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type LockThreadStateRow struct {
+	Locked   bool
+	Archived bool
+}
+
+func (q *Queries) LockThreadState(ctx context.Context, id int32) (LockThreadStateRow, error) {
+	row := q.db.QueryRowContext(ctx, lockThreadState, id)
+	var i LockThreadStateRow
+	err := row.Scan(&i.Locked, &i.Archived)
+	return i, err
+}
+
+const refreshThreadComments = `-- name: RefreshThreadComments :exec
+UPDATE threads
+SET comment_count = (SELECT COUNT(*) FROM comments WHERE thread_id = threads.id AND NOT held),
+last_commenter_id = (
+    SELECT creator_id FROM comments
+    WHERE thread_id = threads.id AND NOT held
+    ORDER BY comments.created_timestamp DESC, comments.id DESC
+    LIMIT 1
+),
+last_activity_timestamp = GREATEST(updated_timestamp, COALESCE(
+    (SELECT MAX(created_timestamp) FROM comments WHERE thread_id = threads.id AND NOT held), updated_timestamp
+))
+WHERE threads.id = $1
+`
+
+// Held comments are left out, since they were never added to the thread
+func (q *Queries) RefreshThreadComments(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, refreshThreadComments, id)
+	return err
+}
+
+const releaseThread = `-- name: ReleaseThread :one
+UPDATE threads
+SET held = FALSE
+WHERE id = $1 AND held
+RETURNING id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held
+`
+
+func (q *Queries) ReleaseThread(ctx context.Context, id int32) (Thread, error) {
+	row := q.db.QueryRowContext(ctx, releaseThread, id)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
+	)
+	return i, err
+}
+
+const unacceptThreadComment = `-- name: UnacceptThreadComment :one
+UPDATE threads
+SET accepted_comment_id = NULL
+WHERE id = $1 AND accepted_comment_id = $2::INTEGER
+RETURNING id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held
+`
+
+type UnacceptThreadCommentParams struct {
+	ID        int32
+	CommentID int32
+}
+
+func (q *Queries) UnacceptThreadComment(ctx context.Context, arg UnacceptThreadCommentParams) (Thread, error) {
+	row := q.db.QueryRowContext(ctx, unacceptThreadComment, arg.ID, arg.CommentID)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
+	)
+	return i, err
 }
 
 const updateThreadContent = `-- name: UpdateThreadContent :one
 UPDATE threads
-SET content = $2, updated_timestamp = CURRENT_TIMESTAMP
+SET content = $2, content_html = $3, updated_timestamp = CURRENT_TIMESTAMP, last_activity_timestamp = CURRENT_TIMESTAMP,
+held = held OR $4
 WHERE id = $1
-RETURNING content, updated_timestamp
+RETURNING title, content, content_html, updated_timestamp, held
 `
 
 type UpdateThreadContentParams struct {
-	ID      int32
-	Content string
+	ID          int32
+	Content     string
+	ContentHTML string
+	Held        bool
 }
 
 type UpdateThreadContentRow struct {
+	Title            string
 	Content          string
+	ContentHTML      string
 	UpdatedTimestamp time.Time
+	Held             bool
 }
 
+// Edits can hold the thread, but only moderators can release it
 func (q *Queries) UpdateThreadContent(ctx context.Context, arg UpdateThreadContentParams) (UpdateThreadContentRow, error) {
-	row := q.db.QueryRowContext(ctx, updateThreadContent, arg.ID, arg.Content)
+	row := q.db.QueryRowContext(ctx, updateThreadContent,
+		arg.ID,
+		arg.Content,
+		arg.ContentHTML,
+		arg.Held,
+	)
 	var i UpdateThreadContentRow
-	err := row.Scan(&i.Content, &i.UpdatedTimestamp)
+	err := row.Scan(
+		&i.Title,
+		&i.Content,
+		&i.ContentHTML,
+		&i.UpdatedTimestamp,
+		&i.Held,
+	)
+	return i, err
+}
+
+const updateThreadContentHTML = `-- name: UpdateThreadContentHTML :exec
+UPDATE threads
+SET content_html = $2
+WHERE id = $1
+`
+
+type UpdateThreadContentHTMLParams struct {
+	ID          int32
+	ContentHTML string
+}
+
+func (q *Queries) UpdateThreadContentHTML(ctx context.Context, arg UpdateThreadContentHTMLParams) error {
+	_, err := q.db.ExecContext(ctx, updateThreadContentHTML, arg.ID, arg.ContentHTML)
+	return err
+}
+
+const updateThreadScore = `-- name: UpdateThreadScore :exec
+UPDATE threads
+SET score = score + $1::INTEGER,
+hot_rank = LOG(GREATEST(score + $1::INTEGER, 1)) + EXTRACT(EPOCH FROM created_timestamp) / 45000
+WHERE id = $2
+`
+
+type UpdateThreadScoreParams struct {
+	Delta int32
+	ID    int32
+}
+
+func (q *Queries) UpdateThreadScore(ctx context.Context, arg UpdateThreadScoreParams) error {
+	_, err := q.db.ExecContext(ctx, updateThreadScore, arg.Delta, arg.ID)
+	return err
+}
+
+const updateThreadState = `-- name: UpdateThreadState :one
+UPDATE threads
+SET pinned = $1, locked = $2, archived = $3,
+last_activity_timestamp = CASE
+    WHEN archived AND NOT $3::BOOLEAN THEN CURRENT_TIMESTAMP
+    ELSE last_activity_timestamp
+END
+WHERE id = $4
+RETURNING id, title, content, creator_id, created_timestamp, updated_timestamp, score, hot_rank, last_activity_timestamp, comment_count, last_commenter_id, search_vector, pinned, locked, archived, category_id, content_html, accepted_comment_id, held
+`
+
+type UpdateThreadStateParams struct {
+	Pinned   bool
+	Locked   bool
+	Archived bool
+	ID       int32
+}
+
+func (q *Queries) UpdateThreadState(ctx context.Context, arg UpdateThreadStateParams) (Thread, error) {
+	row := q.db.QueryRowContext(ctx, updateThreadState,
+		arg.Pinned,
+		arg.Locked,
+		arg.Archived,
+		arg.ID,
+	)
+	var i Thread
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.CreatorID,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&i.Score,
+		&i.HotRank,
+		&i.LastActivityTimestamp,
+		&i.CommentCount,
+		&i.LastCommenterID,
+		&i.SearchVector,
+		&i.Pinned,
+		&i.Locked,
+		&i.Archived,
+		&i.CategoryID,
+		&i.ContentHTML,
+		&i.AcceptedCommentID,
+		&i.Held,
+	)
 	return i, err
 }
@@ -0,0 +1,186 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: thumbnails.sql
+
+package database
+
+import (
+	"context"
+)
+
+const createBlobThumbnail = `-- name: CreateBlobThumbnail :exec
+INSERT INTO blob_thumbnails (source_sha256, size, thumbnail_sha256, width, height)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (source_sha256, size) DO UPDATE
+SET thumbnail_sha256 = EXCLUDED.thumbnail_sha256,
+width = EXCLUDED.width,
+height = EXCLUDED.height
+`
+
+type CreateBlobThumbnailParams struct {
+	SourceSha256    string
+	Size            int32
+	ThumbnailSha256 string
+	Width           int32
+	Height          int32
+}
+
+func (q *Queries) CreateBlobThumbnail(ctx context.Context, arg CreateBlobThumbnailParams) error {
+	_, err := q.db.ExecContext(ctx, createBlobThumbnail,
+		arg.SourceSha256,
+		arg.Size,
+		arg.ThumbnailSha256,
+		arg.Width,
+		arg.Height,
+	)
+	return err
+}
+
+const getAttachmentThumbnail = `-- name: GetAttachmentThumbnail :one
+SELECT blobs.sha256, blobs.size, blobs.content_type
+FROM attachments
+INNER JOIN blobs AS sources ON sources.sha256 = attachments.blob_sha256
+INNER JOIN blob_thumbnails ON blob_thumbnails.source_sha256 = sources.sha256
+INNER JOIN blobs ON blobs.sha256 = blob_thumbnails.thumbnail_sha256
+WHERE attachments.id = $1 AND blob_thumbnails.size = $2
+AND sources.thumbnail_status = 'ready'
+`
+
+type GetAttachmentThumbnailParams struct {
+	AttachmentID int32
+	Size         int32
+}
+
+type GetAttachmentThumbnailRow struct {
+	Sha256      string
+	Size        int32
+	ContentType string
+}
+
+func (q *Queries) GetAttachmentThumbnail(ctx context.Context, arg GetAttachmentThumbnailParams) (GetAttachmentThumbnailRow, error) {
+	row := q.db.QueryRowContext(ctx, getAttachmentThumbnail, arg.AttachmentID, arg.Size)
+	var i GetAttachmentThumbnailRow
+	err := row.Scan(&i.Sha256, &i.Size, &i.ContentType)
+	return i, err
+}
+
+const getBlobThumbnails = `-- name: GetBlobThumbnails :many
+SELECT blob_thumbnails.size, blob_thumbnails.width, blob_thumbnails.height
+FROM blob_thumbnails
+INNER JOIN blobs ON blobs.sha256 = blob_thumbnails.source_sha256
+WHERE blob_thumbnails.source_sha256 = $1 AND blobs.thumbnail_status = 'ready'
+ORDER BY blob_thumbnails.size
+`
+
+type GetBlobThumbnailsRow struct {
+	Size   int32
+	Width  int32
+	Height int32
+}
+
+func (q *Queries) GetBlobThumbnails(ctx context.Context, sourceSha256 string) ([]GetBlobThumbnailsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBlobThumbnails, sourceSha256)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBlobThumbnailsRow
+	for rows.Next() {
+		var i GetBlobThumbnailsRow
+		if err := rows.Scan(&i.Size, &i.Width, &i.Height); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingThumbnailBlobs = `-- name: GetPendingThumbnailBlobs :many
+SELECT sha256 FROM blobs
+WHERE thumbnail_status = 'pending'
+ORDER BY uploaded_timestamp
+LIMIT $1
+`
+
+func (q *Queries) GetPendingThumbnailBlobs(ctx context.Context, limit int32) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingThumbnailBlobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var sha256 string
+		if err := rows.Scan(&sha256); err != nil {
+			return nil, err
+		}
+		items = append(items, sha256)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThumbnailSource = `-- name: GetThumbnailSource :one
+SELECT content_type, thumbnail_status FROM blobs
+WHERE sha256 = $1
+`
+
+type GetThumbnailSourceRow struct {
+	ContentType     string
+	ThumbnailStatus string
+}
+
+func (q *Queries) GetThumbnailSource(ctx context.Context, sha256 string) (GetThumbnailSourceRow, error) {
+	row := q.db.QueryRowContext(ctx, getThumbnailSource, sha256)
+	var i GetThumbnailSourceRow
+	err := row.Scan(&i.ContentType, &i.ThumbnailStatus)
+	return i, err
+}
+
+const recordThumbnailAttempt = `-- name: RecordThumbnailAttempt :one
+UPDATE blobs
+SET thumbnail_attempts = thumbnail_attempts + 1,
+thumbnail_status = CASE WHEN thumbnail_attempts + 1 >= $1::INTEGER THEN 'failed' ELSE thumbnail_status END
+WHERE sha256 = $2 AND thumbnail_status = 'pending'
+RETURNING thumbnail_status
+`
+
+type RecordThumbnailAttemptParams struct {
+	MaxAttempts int32
+	Sha256      string
+}
+
+// The image is left pending for the next sweep until it runs out of attempts, after which it is marked as failed
+func (q *Queries) RecordThumbnailAttempt(ctx context.Context, arg RecordThumbnailAttemptParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, recordThumbnailAttempt, arg.MaxAttempts, arg.Sha256)
+	var thumbnail_status string
+	err := row.Scan(&thumbnail_status)
+	return thumbnail_status, err
+}
+
+const setThumbnailStatus = `-- name: SetThumbnailStatus :exec
+UPDATE blobs SET thumbnail_status = $2
+WHERE sha256 = $1
+`
+
+type SetThumbnailStatusParams struct {
+	Sha256          string
+	ThumbnailStatus string
+}
+
+func (q *Queries) SetThumbnailStatus(ctx context.Context, arg SetThumbnailStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setThumbnailStatus, arg.Sha256, arg.ThumbnailStatus)
+	return err
+}
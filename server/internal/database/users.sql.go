@@ -7,14 +7,35 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const banUser = `-- name: BanUser :exec
+UPDATE users
+SET banned_until = CASE
+    WHEN $1::INTEGER IS NULL THEN 'infinity'
+    ELSE CURRENT_TIMESTAMP + MAKE_INTERVAL(days => $1::INTEGER)
+END
+WHERE id = $2
+`
+
+type BanUserParams struct {
+	BanDays sql.NullInt32
+	ID      uuid.UUID
+}
+
+func (q *Queries) BanUser(ctx context.Context, arg BanUserParams) error {
+	_, err := q.db.ExecContext(ctx, banUser, arg.BanDays, arg.ID)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, username, password)
 VALUES ($1, $2, $3)
-RETURNING id, username
+RETURNING id, username, role
 `
 
 type CreateUserParams struct {
@@ -26,56 +47,153 @@ type CreateUserParams struct {
 type CreateUserRow struct {
 	ID       uuid.UUID
 	Username string
+	Role     string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
 	row := q.db.QueryRowContext(ctx, createUser, arg.ID, arg.Username, arg.Password)
 	var i CreateUserRow
-	err := row.Scan(&i.ID, &i.Username)
+	err := row.Scan(&i.ID, &i.Username, &i.Role)
 	return i, err
 }
 
-const getUserID = `-- name: GetUserID :one
-SELECT id FROM users
+const getUserBanned = `-- name: GetUserBanned :one
+SELECT (banned_until IS NOT NULL AND banned_until > CURRENT_TIMESTAMP)::BOOLEAN AS banned FROM users
 WHERE id = $1
 `
 
-func (q *Queries) GetUserID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, getUserID, id)
-	err := row.Scan(&id)
-	return id, err
+func (q *Queries) GetUserBanned(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, getUserBanned, id)
+	var banned bool
+	err := row.Scan(&banned)
+	return banned, err
+}
+
+const getUserCreatedTimestamp = `-- name: GetUserCreatedTimestamp :one
+SELECT GREATEST(created_timestamp, 'epoch'::TIMESTAMPTZ)::TIMESTAMPTZ AS created_timestamp FROM users
+WHERE id = $1
+`
+
+// Users backfilled as '-infinity' cannot be scanned, so they are treated as created at the Unix epoch instead
+func (q *Queries) GetUserCreatedTimestamp(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getUserCreatedTimestamp, id)
+	var created_timestamp time.Time
+	err := row.Scan(&created_timestamp)
+	return created_timestamp, err
 }
 
 const getUserIDAndPassHash = `-- name: GetUserIDAndPassHash :one
-SELECT id, password FROM users
+SELECT id, password, (banned_until IS NOT NULL AND banned_until > CURRENT_TIMESTAMP)::BOOLEAN AS banned FROM users
 WHERE username = $1
 `
 
 type GetUserIDAndPassHashRow struct {
 	ID       uuid.UUID
 	Password string
+	Banned   bool
 }
 
 func (q *Queries) GetUserIDAndPassHash(ctx context.Context, username string) (GetUserIDAndPassHashRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserIDAndPassHash, username)
 	var i GetUserIDAndPassHashRow
-	err := row.Scan(&i.ID, &i.Password)
+	err := row.Scan(&i.ID, &i.Password, &i.Banned)
 	return i, err
 }
 
 const getUserInfo = `-- name: GetUserInfo :one
-SELECT id, username FROM users
+SELECT id, username, role FROM users
 WHERE id = $1
 `
 
 type GetUserInfoRow struct {
 	ID       uuid.UUID
 	Username string
+	Role     string
 }
 
 func (q *Queries) GetUserInfo(ctx context.Context, id uuid.UUID) (GetUserInfoRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserInfo, id)
 	var i GetUserInfoRow
-	err := row.Scan(&i.ID, &i.Username)
+	err := row.Scan(&i.ID, &i.Username, &i.Role)
+	return i, err
+}
+
+const getUserRole = `-- name: GetUserRole :one
+SELECT role FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserRole(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserRole, id)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getUserSettings = `-- name: GetUserSettings :one
+SELECT auto_watch_created, auto_watch_commented FROM users
+WHERE id = $1
+`
+
+type GetUserSettingsRow struct {
+	AutoWatchCreated   bool
+	AutoWatchCommented bool
+}
+
+func (q *Queries) GetUserSettings(ctx context.Context, id uuid.UUID) (GetUserSettingsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserSettings, id)
+	var i GetUserSettingsRow
+	err := row.Scan(&i.AutoWatchCreated, &i.AutoWatchCommented)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, role
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+type UpdateUserRoleRow struct {
+	ID       uuid.UUID
+	Username string
+	Role     string
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (UpdateUserRoleRow, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i UpdateUserRoleRow
+	err := row.Scan(&i.ID, &i.Username, &i.Role)
+	return i, err
+}
+
+const updateUserSettings = `-- name: UpdateUserSettings :one
+UPDATE users
+SET auto_watch_created = COALESCE($1, auto_watch_created),
+auto_watch_commented = COALESCE($2, auto_watch_commented)
+WHERE id = $3
+RETURNING auto_watch_created, auto_watch_commented
+`
+
+type UpdateUserSettingsParams struct {
+	AutoWatchCreated   sql.NullBool
+	AutoWatchCommented sql.NullBool
+	ID                 uuid.UUID
+}
+
+type UpdateUserSettingsRow struct {
+	AutoWatchCreated   bool
+	AutoWatchCommented bool
+}
+
+func (q *Queries) UpdateUserSettings(ctx context.Context, arg UpdateUserSettingsParams) (UpdateUserSettingsRow, error) {
+	row := q.db.QueryRowContext(ctx, updateUserSettings, arg.AutoWatchCreated, arg.AutoWatchCommented, arg.ID)
+	var i UpdateUserSettingsRow
+	err := row.Scan(&i.AutoWatchCreated, &i.AutoWatchCommented)
 	return i, err
 }
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+)
+
+/*
+This function archives the threads without any activity for the given number of days,
+checking once every interval until the context is cancelled.
+Pinned threads are never archived automatically.
+*/
+func ArchiveInactiveThreads(ctx context.Context, q *database.Queries, days int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := q.ArchiveInactiveThreads(ctx, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			log.Printf("Failed to archive inactive threads: %v", err)
+		} else if archived > 0 {
+			log.Printf("Archived %d inactive threads", archived)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/storage"
+)
+
+// The number of blobs that are deleted in each batch, so that a large backlog does not hold up the database
+const blobBatchSize = 100
+
+/*
+This function garbage collects the attachments that are not referenced by any thread or comment
+after the grace period, which gives users time to use their uploads, checking once every interval
+until the context is cancelled. The stored files are deleted once no attachment uses them anymore.
+*/
+func CollectOrphanedAttachments(ctx context.Context, db *sql.DB, q *database.Queries, store storage.BlobStore, gracePeriod, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().Add(-gracePeriod)
+
+		deleted, err := q.DeleteOrphanedAttachments(ctx, cutoff)
+		if err != nil {
+			log.Printf("Failed to delete orphaned attachments: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d orphaned attachments", deleted)
+		}
+
+		deletedBlobs, err := collectOrphanedBlobs(ctx, db, q, store, cutoff)
+		if err != nil {
+			log.Printf("Failed to delete orphaned blobs: %v", err)
+		}
+		if deletedBlobs > 0 {
+			log.Printf("Deleted %d orphaned blobs", deletedBlobs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+This function deletes the stored files that are no longer used by any attachment, in batches.
+Each file is deleted while its blob is locked, so that uploading the same content again at the same time
+either waits for the deletion to finish or keeps the file, since uploads refresh the blob before storing the file
+and blobs are only collected once they have not been refreshed for the grace period.
+*/
+func collectOrphanedBlobs(ctx context.Context, db *sql.DB, q *database.Queries, store storage.BlobStore, cutoff time.Time) (int, error) {
+	deleted := 0
+
+	for {
+		keys, err := q.GetOrphanedBlobs(ctx, database.GetOrphanedBlobsParams{
+			UploadedBefore: cutoff,
+			ResultLimit:    blobBatchSize,
+		})
+		if err != nil {
+			return deleted, err
+		}
+
+		batchDeleted := 0
+		for _, key := range keys {
+			ok, err := collectBlob(ctx, db, q, store, key, cutoff)
+			if err != nil {
+				return deleted, err
+			}
+			if ok {
+				batchDeleted++
+			}
+		}
+		deleted += batchDeleted
+
+		// Blobs that are skipped are left for the next run, so that they are not fetched again forever
+		if len(keys) < blobBatchSize || batchDeleted == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+/*
+This function deletes the stored file and the blob if it is still unused, returning whether it was deleted.
+*/
+func collectBlob(ctx context.Context, db *sql.DB, q *database.Queries, store storage.BlobStore, key string, cutoff time.Time) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qtx := q.WithTx(tx)
+
+	_, err = qtx.LockOrphanedBlob(ctx, database.LockOrphanedBlobParams{
+		Sha256:         key,
+		UploadedBefore: cutoff,
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = store.Delete(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	err = qtx.DeleteBlob(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/markdown"
+)
+
+const renderBatchSize = 100
+
+/*
+This function renders the content of the threads and comments that were created before
+the rendered HTML was stored, in batches until there are none left.
+*/
+func RenderMissingContentHTML(ctx context.Context, q *database.Queries) {
+	rendered := 0
+
+	afterID := int32(0)
+	for {
+		threads, err := q.GetThreadsWithoutContentHTML(ctx, database.GetThreadsWithoutContentHTMLParams{
+			AfterID:   afterID,
+			BatchSize: renderBatchSize,
+		})
+		if err != nil {
+			log.Printf("Failed to get threads without content HTML: %v", err)
+			return
+		}
+
+		for _, thread := range threads {
+			contentHTML, err := markdown.Render(thread.Content)
+			if err != nil {
+				log.Printf("Failed to render content of thread %d: %v", thread.ID, err)
+				return
+			}
+
+			err = q.UpdateThreadContentHTML(ctx, database.UpdateThreadContentHTMLParams{
+				ID:          thread.ID,
+				ContentHTML: contentHTML,
+			})
+			if err != nil {
+				log.Printf("Failed to update content HTML of thread %d: %v", thread.ID, err)
+				return
+			}
+			afterID = thread.ID
+			rendered++
+		}
+
+		if len(threads) < renderBatchSize {
+			break
+		}
+	}
+
+	afterID = 0
+	for {
+		comments, err := q.GetCommentsWithoutContentHTML(ctx, database.GetCommentsWithoutContentHTMLParams{
+			AfterID:   afterID,
+			BatchSize: renderBatchSize,
+		})
+		if err != nil {
+			log.Printf("Failed to get comments without content HTML: %v", err)
+			return
+		}
+
+		for _, comment := range comments {
+			contentHTML, err := markdown.Render(comment.Content)
+			if err != nil {
+				log.Printf("Failed to render content of comment %d: %v", comment.ID, err)
+				return
+			}
+
+			err = q.UpdateCommentContentHTML(ctx, database.UpdateCommentContentHTMLParams{
+				ID:          comment.ID,
+				ContentHTML: contentHTML,
+			})
+			if err != nil {
+				log.Printf("Failed to update content HTML of comment %d: %v", comment.ID, err)
+				return
+			}
+			afterID = comment.ID
+			rendered++
+		}
+
+		if len(comments) < renderBatchSize {
+			break
+		}
+	}
+
+	if rendered > 0 {
+		log.Printf("Rendered the content HTML of %d threads and comments", rendered)
+	}
+}
@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+)
+
+// The number of comments whose subscribers are not notified yet that are picked up in each sweep
+const subscriptionBatchSize = 100
+
+/*
+Notifies the subscribers of a thread about new comments in the background,
+so that creating a comment does not wait for the notifications of every subscriber.
+Comments are queued by the handlers and processed one at a time by Run, which also sweeps for comments
+that are not notified yet every interval in case they were dropped from a full queue or the server restarted.
+*/
+type SubscriptionNotifier struct {
+	q          *database.Queries
+	commentIDs chan int32
+}
+
+/*
+This function creates a subscription notifier that can queue up to the given number of comments.
+*/
+func NewSubscriptionNotifier(q *database.Queries, queueSize int) *SubscriptionNotifier {
+	return &SubscriptionNotifier{
+		q:          q,
+		commentIDs: make(chan int32, queueSize),
+	}
+}
+
+/*
+This function queues the comment to notify the subscribers of its thread, without blocking.
+If the queue is full, the comment is left to the next sweep since it is still marked as not notified.
+*/
+func (notifier *SubscriptionNotifier) Enqueue(comment database.Comment) {
+	select {
+	case notifier.commentIDs <- comment.ID:
+	default:
+		log.Printf("Subscription notifier queue is full, leaving comment %d for the next sweep", comment.ID)
+	}
+}
+
+/*
+This function notifies the subscribers of the queued and not yet notified comments until the context is cancelled.
+*/
+func (notifier *SubscriptionNotifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	notifier.sweep(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case commentID := <-notifier.commentIDs:
+			notifier.notify(ctx, commentID)
+		case <-ticker.C:
+			notifier.sweep(ctx)
+		}
+	}
+}
+
+/*
+This function notifies the subscribers of the comments that are not notified yet.
+*/
+func (notifier *SubscriptionNotifier) sweep(ctx context.Context) {
+	commentIDs, err := notifier.q.GetUnnotifiedComments(ctx, subscriptionBatchSize)
+	if err != nil {
+		log.Printf("Failed to get comments to notify subscribers of: %v", err)
+		return
+	}
+
+	for _, commentID := range commentIDs {
+		notifier.notify(ctx, commentID)
+	}
+}
+
+/*
+This function creates a notification for each subscriber of the thread of the comment,
+except for the creator of the comment and users who were already notified about it.
+Comments that were already notified, are held or were deleted are skipped.
+*/
+func (notifier *SubscriptionNotifier) notify(ctx context.Context, commentID int32) {
+	_, err := notifier.q.NotifyCommentSubscribers(ctx, commentID)
+	if err != nil {
+		log.Printf("Failed to notify subscribers of comment %d: %v", commentID, err)
+	}
+}
@@ -0,0 +1,218 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/wangyuanchi/shibespace/server/imaging"
+	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/storage"
+)
+
+// The number of pending images that are picked up in each sweep
+const thumbnailBatchSize = 100
+
+// The number of times generating the thumbnails of an image can fail for other reasons before it is marked as failed
+const maxThumbnailAttempts = 5
+
+// Images that cannot be decoded or scaled will never succeed, so they are marked as failed straight away
+var errInvalidImage = errors.New("invalid image")
+
+/*
+Generates the thumbnails of uploaded images in the background, so that uploading does not wait for them.
+Images are queued by the handlers and processed one at a time by Run, which also sweeps for pending images
+every interval in case they were dropped from a full queue or the server restarted before they were processed.
+*/
+type ThumbnailGenerator struct {
+	db    *sql.DB
+	q     *database.Queries
+	store storage.BlobStore
+	keys  chan string
+}
+
+/*
+This function creates a thumbnail generator that can queue up to the given number of images.
+*/
+func NewThumbnailGenerator(db *sql.DB, q *database.Queries, store storage.BlobStore, queueSize int) *ThumbnailGenerator {
+	return &ThumbnailGenerator{
+		db:    db,
+		q:     q,
+		store: store,
+		keys:  make(chan string, queueSize),
+	}
+}
+
+/*
+This function queues the image with the key to generate its thumbnails, without blocking.
+If the queue is full, the image is left to the next sweep since it is still pending.
+*/
+func (generator *ThumbnailGenerator) Enqueue(key string) {
+	select {
+	case generator.keys <- key:
+	default:
+		log.Printf("Thumbnail queue is full, leaving blob %s for the next sweep", key)
+	}
+}
+
+/*
+This function generates the thumbnails of the queued and pending images until the context is cancelled.
+*/
+func (generator *ThumbnailGenerator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	generator.sweep(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key := <-generator.keys:
+			generator.generate(ctx, key)
+		case <-ticker.C:
+			generator.sweep(ctx)
+		}
+	}
+}
+
+/*
+This function generates the thumbnails of the images that are still pending.
+*/
+func (generator *ThumbnailGenerator) sweep(ctx context.Context) {
+	keys, err := generator.q.GetPendingThumbnailBlobs(ctx, thumbnailBatchSize)
+	if err != nil {
+		log.Printf("Failed to get pending thumbnails: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		generator.generate(ctx, key)
+	}
+}
+
+/*
+This function generates every size of thumbnail for the image, then marks its thumbnails as ready,
+or as failed if the image cannot be decoded. Other errors, such as the store being unavailable,
+leave the image pending so that it is retried in the next sweep, until it runs out of attempts.
+Images that are no longer pending are skipped, since they may have been processed from both the queue and a sweep.
+*/
+func (generator *ThumbnailGenerator) generate(ctx context.Context, key string) {
+	source, err := generator.q.GetThumbnailSource(ctx, key)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Failed to get blob %s for thumbnails: %v", key, err)
+		}
+		return
+	}
+	if source.ThumbnailStatus != "pending" {
+		return
+	}
+
+	err = generator.generateThumbnails(ctx, key, source.ContentType)
+	if err != nil && !errors.Is(err, errInvalidImage) {
+		log.Printf("Failed to generate thumbnails of blob %s, retrying later: %v", key, err)
+
+		status, err := generator.q.RecordThumbnailAttempt(ctx, database.RecordThumbnailAttemptParams{
+			Sha256:      key,
+			MaxAttempts: maxThumbnailAttempts,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			log.Printf("Failed to record thumbnail attempt of blob %s: %v", key, err)
+		} else if status == "failed" {
+			log.Printf("Giving up on thumbnails of blob %s after %d attempts", key, maxThumbnailAttempts)
+		}
+		return
+	}
+
+	status := "ready"
+	if err != nil {
+		log.Printf("Failed to generate thumbnails of blob %s: %v", key, err)
+		status = "failed"
+	}
+
+	err = generator.q.SetThumbnailStatus(ctx, database.SetThumbnailStatusParams{
+		Sha256:          key,
+		ThumbnailStatus: status,
+	})
+	if err != nil {
+		log.Printf("Failed to set thumbnail status of blob %s: %v", key, err)
+	}
+}
+
+func (generator *ThumbnailGenerator) generateThumbnails(ctx context.Context, key, contentType string) error {
+	file, err := generator.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, size := range imaging.ThumbnailSizes {
+		thumbnail, thumbnailType, bounds, err := imaging.Thumbnail(data, contentType, size)
+		if err != nil {
+			return fmt.Errorf("%w: failed to create %dpx thumbnail: %v", errInvalidImage, size, err)
+		}
+
+		err = generator.storeThumbnail(ctx, key, size, thumbnail, thumbnailType, int32(bounds.Dx()), int32(bounds.Dy()))
+		if err != nil {
+			return fmt.Errorf("failed to store %dpx thumbnail: %v", size, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+This function stores the thumbnail as a blob and links it to the image, while the blob is locked
+in the same way as uploads so that it cannot be garbage collected in the meantime.
+*/
+func (generator *ThumbnailGenerator) storeThumbnail(ctx context.Context, sourceKey string, size int, thumbnail []byte, contentType string, width, height int32) error {
+	hash := sha256.Sum256(thumbnail)
+	key := hex.EncodeToString(hash[:])
+
+	tx, err := generator.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := generator.q.WithTx(tx)
+
+	_, err = qtx.UpsertBlob(ctx, database.UpsertBlobParams{
+		Sha256:          key,
+		Size:            int32(len(thumbnail)),
+		ContentType:     contentType,
+		ThumbnailStatus: "none",
+	})
+	if err != nil {
+		return err
+	}
+
+	err = generator.store.Put(ctx, key, thumbnail, contentType)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.CreateBlobThumbnail(ctx, database.CreateBlobThumbnailParams{
+		SourceSha256:    sourceKey,
+		Size:            int32(size),
+		ThumbnailSha256: key,
+		Width:           width,
+		Height:          height,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/jobs"
 	"github.com/wangyuanchi/shibespace/server/routes"
+	"github.com/wangyuanchi/shibespace/server/storage"
 )
 
 func main() {
@@ -26,23 +31,43 @@ func main() {
 		log.Fatal("SERVER_URL is not found in the environment")
 	}
 
-	connection, close := database.GetConnection()
+	connection, db, close := database.GetConnection()
 	defer close()
 
+	go jobs.RenderMissingContentHTML(context.Background(), connection)
+
+	notifier := jobs.NewSubscriptionNotifier(connection, 1000)
+	go notifier.Run(context.Background(), time.Minute)
+
+	store := storage.GetBlobStore()
+	go jobs.CollectOrphanedAttachments(context.Background(), db, connection, store, 24*time.Hour, time.Hour)
+
+	thumbnails := jobs.NewThumbnailGenerator(db, connection, store, 1000)
+	go thumbnails.Run(context.Background(), 10*time.Minute)
+
+	archiveDays := os.Getenv("THREAD_ARCHIVE_DAYS")
+	if archiveDays != "" {
+		days, err := strconv.Atoi(archiveDays)
+		if err != nil || days < 1 {
+			log.Fatal("THREAD_ARCHIVE_DAYS must be an integer that is at least 1")
+		}
+		go jobs.ArchiveInactiveThreads(context.Background(), connection, days, time.Hour)
+	}
+
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{serverURL}, // To send the jwt cookie
 		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link", "X-Total-Count"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "X-Next-Cursor", "X-Prev-Cursor"},
 		AllowCredentials: true, // To send the jwt cookie
 		MaxAge:           300,
 	}))
 
 	v1r := chi.NewRouter()
 	r.Mount("/v1", v1r)
-	routes.RegisterRoutes(v1r, connection)
+	routes.RegisterRoutes(v1r, connection, db, notifier, store, thumbnails)
 
 	log.Printf("Server starting on port %s", port)
 	err := http.ListenAndServe(":"+port, r)
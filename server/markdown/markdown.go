@@ -0,0 +1,67 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+/*
+The renderer follows CommonMark, together with the tables, strikethrough and autolinks from GitHub Flavored Markdown,
+and quote-replies of comments.
+Raw HTML in the content is left out by the renderer, and the output is still sanitized with an allowlist
+in case the renderer ever produces unsafe HTML. Both are safe for concurrent use.
+*/
+var renderer = goldmark.New(
+	goldmark.WithExtensions(
+		extension.Table,
+		extension.Strikethrough,
+		extension.Linkify,
+		quoteExtension{},
+	),
+)
+
+var policy = newPolicy()
+
+/*
+This function creates the allowlist policy for user generated content,
+which also keeps the language of code blocks for syntax highlighting on the client.
+*/
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile("^language-[a-zA-Z0-9_+-]+$")).OnElements("code")
+	p.AllowAttrs("data-comment-id").Matching(regexp.MustCompile("^[0-9]+$")).OnElements("blockquote")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+/*
+This function renders the Markdown content into sanitized HTML.
+Quote-replies are rendered without the authors of the quoted comments.
+*/
+func Render(content string) (string, error) {
+	return RenderWithQuotes(content, nil)
+}
+
+/*
+This function renders the Markdown content into sanitized HTML,
+showing the authors of the quoted comments from the map of comment IDs to usernames.
+Quoted comments that are not in the map are rendered without their author.
+*/
+func RenderWithQuotes(content string, quotes map[int32]string) (string, error) {
+	pc := parser.NewContext()
+	pc.Set(quotesKey, quotes)
+
+	var buf bytes.Buffer
+	err := renderer.Convert([]byte(content), &buf, parser.WithContext(pc))
+	if err != nil {
+		return "", err
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
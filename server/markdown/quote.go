@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// The maximum number of comments that can be quoted with attribution in the same content
+const maxQuotes = 10
+
+/*
+A quote-reply is a blockquote whose first line is '[quote:<comment_id>]', such as:
+
+	> [quote:12]
+	> the quoted text
+
+The marker line is removed, the ID of the comment is kept in the data-comment-id attribute of the blockquote,
+and the username of its author is shown above the quoted text if the comment exists.
+*/
+var quoteMarker = regexp.MustCompile(`^\[quote:([0-9]+)\][ \t]*\r?\n?$`)
+
+// Finds the markers in the raw content, which may be nested in several levels of blockquotes
+var quoteMarkers = regexp.MustCompile(`(?m)^[ \t]*(?:>[ \t]?)+\[quote:([0-9]+)\][ \t]*$`)
+
+// The usernames of the authors of the quoted comments are passed to the parser through its context
+var quotesKey = parser.NewContextKey()
+
+var kindQuoteAttribution = ast.NewNodeKind("QuoteAttribution")
+
+/*
+The line that shows the author of the quoted comment, which is the first child of the blockquote.
+*/
+type quoteAttribution struct {
+	ast.BaseBlock
+	CommentID int32
+	Username  string
+}
+
+func (n *quoteAttribution) Kind() ast.NodeKind {
+	return kindQuoteAttribution
+}
+
+func (n *quoteAttribution) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"CommentID": strconv.Itoa(int(n.CommentID)),
+		"Username":  n.Username,
+	}, nil)
+}
+
+/*
+This function gets the IDs of the comments quoted in the content, in order and without duplicates,
+up to the maximum number of quotes. The IDs are only used to look up the authors before rendering.
+*/
+func QuoteIDs(content string) []int32 {
+	ids := []int32{}
+	seen := make(map[int32]bool)
+
+	for _, match := range quoteMarkers.FindAllStringSubmatch(content, -1) {
+		id, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil || seen[int32(id)] {
+			continue
+		}
+
+		seen[int32(id)] = true
+		ids = append(ids, int32(id))
+		if len(ids) == maxQuotes {
+			break
+		}
+	}
+
+	return ids
+}
+
+/*
+Adds quote-replies to the renderer.
+*/
+type quoteExtension struct{}
+
+func (quoteExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithParagraphTransformers(util.Prioritized(quoteParagraphTransformer{}, 200)))
+	m.Renderer().AddOptions(goldmarkrenderer.WithNodeRenderers(util.Prioritized(quoteRenderer{}, 500)))
+}
+
+type quoteParagraphTransformer struct{}
+
+/*
+This function turns the blockquote into a quote-reply if its first paragraph starts with the marker line.
+It runs when the paragraph is closed, before its inline content is parsed, so that the marker can be removed.
+*/
+func (quoteParagraphTransformer) Transform(node *ast.Paragraph, reader text.Reader, pc parser.Context) {
+	blockquote := node.Parent()
+	if blockquote == nil || blockquote.Kind() != ast.KindBlockquote || node.PreviousSibling() != nil {
+		return
+	}
+
+	lines := node.Lines()
+	if lines.Len() == 0 {
+		return
+	}
+
+	first := lines.At(0)
+	match := quoteMarker.FindSubmatch(first.Value(reader.Source()))
+	if match == nil {
+		return
+	}
+	id, err := strconv.ParseInt(string(match[1]), 10, 32)
+	if err != nil {
+		return
+	}
+
+	lines.SetSliced(1, lines.Len())
+	if lines.Len() == 0 {
+		blockquote.RemoveChild(blockquote, node)
+	}
+
+	blockquote.SetAttributeString("data-comment-id", []byte(strconv.Itoa(int(id))))
+
+	quotes, _ := pc.Get(quotesKey).(map[int32]string)
+	username, ok := quotes[int32(id)]
+	if !ok {
+		return
+	}
+
+	attribution := &quoteAttribution{CommentID: int32(id), Username: username}
+	if blockquote.FirstChild() != nil {
+		blockquote.InsertBefore(blockquote, blockquote.FirstChild(), attribution)
+	} else {
+		blockquote.AppendChild(blockquote, attribution)
+	}
+}
+
+type quoteRenderer struct{}
+
+func (quoteRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindQuoteAttribution, renderQuoteAttribution)
+}
+
+/*
+This function renders the author of the quoted comment.
+It is not linked, since clients find the comment from the data-comment-id attribute of the blockquote.
+*/
+func renderQuoteAttribution(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	attribution := n.(*quoteAttribution)
+	fmt.Fprintf(w, "<p><cite>@%s</cite> wrote:</p>\n", html.EscapeString(attribution.Username))
+
+	return ast.WalkSkipChildren, nil
+}
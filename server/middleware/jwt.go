@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,6 +15,29 @@ import (
 	"github.com/wangyuanchi/shibespace/server/internal/database"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Banned users are treated as if they are not logged in when authentication is optional
+var ErrUserBanned = errors.New("user is banned")
+
+var roleRanks = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+/*
+This function checks if the role has at least the permissions of the minimum role,
+where admins have every permission of moderators, who have every permission of users.
+*/
+func RoleAtLeast(role, minimum string) bool {
+	return roleRanks[role] >= roleRanks[minimum]
+}
+
 /*
 This function generates a JSON web token for the given user ID.
 The jwt is returned with its expiration time.
@@ -44,7 +68,7 @@ func GenerateJWT(userID uuid.UUID) (string, time.Time, error) {
 
 /*
 This function gets the JSON web token from cookies, parses it, then extracts the userID.
-Then, it checks if the userID actually exists in the database.
+Then, it checks if the userID actually exists in the database and is not banned.
 If it does, it returns the userID and the 200 status code.
 Otherwise, it returns a zero UUID, the relevant status code and the error that happened.
 This should be used when an action requires user authentication.
@@ -79,10 +103,13 @@ func JWTExtractUserID(connection *database.Queries, r *http.Request) (uuid.UUID,
 			return zeroUUID, http.StatusUnauthorized, errors.New("invalid token")
 		}
 
-		exist, err := checkUserIDExist(connection, r, userIDFromToken)
+		exist, banned, err := checkUserIDExist(connection, r, userIDFromToken)
 		if err != nil {
 			return zeroUUID, http.StatusInternalServerError, err
 		}
+		if banned {
+			return zeroUUID, http.StatusForbidden, ErrUserBanned
+		}
 		if exist {
 			return userIDFromToken, http.StatusOK, nil
 		} else {
@@ -94,6 +121,24 @@ func JWTExtractUserID(connection *database.Queries, r *http.Request) (uuid.UUID,
 	}
 }
 
+/*
+This function is the optional version of JWTExtractUserID, used when
+authentication only personalizes the response (e.g. whether the viewer reacted).
+Any authentication failure, including a ban, is treated as an anonymous viewer, so the returned
+ID is only valid when a logged in user is found. Server errors are still returned.
+*/
+func JWTExtractOptionalUserID(connection *database.Queries, r *http.Request) (uuid.NullUUID, int, error) {
+	userID, statusCode, err := JWTExtractUserID(connection, r)
+	if err != nil {
+		if statusCode == http.StatusUnauthorized || err == ErrUserBanned {
+			return uuid.NullUUID{}, http.StatusOK, nil
+		}
+		return uuid.NullUUID{}, statusCode, err
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}, http.StatusOK, nil
+}
+
 /*
 This function checks if the user is authorized to act as a target user.
 Specifically, it checks if the user ID from jwt matches the target ID supplied,
@@ -117,19 +162,45 @@ func JWTCheckMatching(connection *database.Queries, r *http.Request, targetID st
 }
 
 /*
-This function checks if the given userID exists in the database.
+This function checks if the user from jwt has one of the given roles.
+If they do, it returns the user ID and the 200 status code.
+Otherwise, it returns the zero UUID, relevant status code and the error that happened.
+This should be used when an action is restricted to moderators or admins.
+*/
+func JWTCheckRole(connection *database.Queries, r *http.Request, roles ...string) (uuid.UUID, int, error) {
+	var zeroUUID uuid.UUID
+
+	userIDFromToken, statusCode, err := JWTExtractUserID(connection, r)
+	if err != nil {
+		return zeroUUID, statusCode, err
+	}
+
+	role, err := connection.GetUserRole(r.Context(), userIDFromToken)
+	if err != nil {
+		return zeroUUID, http.StatusInternalServerError, fmt.Errorf("failed to get user role: %v", err)
+	}
+
+	if !slices.Contains(roles, role) {
+		return zeroUUID, http.StatusForbidden, errors.New("user does not have the required role")
+	} else {
+		return userIDFromToken, http.StatusOK, nil
+	}
+}
+
+/*
+This function checks if the given userID exists in the database, and whether the user is currently banned.
 If it does, this function returns true. Otherwise, it returns false.
 An error can be thrown even if the userID does not exist,
 it should be marked as an internal server error.
 */
-func checkUserIDExist(connection *database.Queries, r *http.Request, userID uuid.UUID) (bool, error) {
-	_, err := connection.GetUserID(r.Context(), userID)
+func checkUserIDExist(connection *database.Queries, r *http.Request, userID uuid.UUID) (bool, bool, error) {
+	banned, err := connection.GetUserBanned(r.Context(), userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return false, nil
+			return false, false, nil
 		} else {
-			return false, fmt.Errorf("failed to get userID: %v", err)
+			return false, false, fmt.Errorf("failed to get userID: %v", err)
 		}
 	}
-	return true, nil
+	return true, banned, nil
 }
@@ -1,18 +1,32 @@
 package routes
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/go-chi/chi/v5"
+	"github.com/wangyuanchi/shibespace/server/automod"
 	"github.com/wangyuanchi/shibespace/server/handlers"
 	"github.com/wangyuanchi/shibespace/server/internal/database"
+	"github.com/wangyuanchi/shibespace/server/jobs"
+	"github.com/wangyuanchi/shibespace/server/storage"
 )
 
 /*
 This function registers the specified routes under the given router.
-It also takes in a database connection so that the handlers have access to it.
+It also takes in a database connection so that the handlers have access to it,
+together with the underlying database handle for handlers that need transactions,
+the notifier for the subscribers of threads, the store for uploaded files and the generator for their thumbnails.
+The auto-moderation rules are cached for a minute, so that changes made through other servers are picked up.
 */
-func RegisterRoutes(r *chi.Mux, c *database.Queries) {
+func RegisterRoutes(r *chi.Mux, c *database.Queries, db *sql.DB, notifier *jobs.SubscriptionNotifier, store storage.BlobStore, thumbnails *jobs.ThumbnailGenerator) {
 	connection := handlers.DatabaseConnection{
-		DB: c,
+		DB:         c,
+		Conn:       db,
+		Notifier:   notifier,
+		Store:      store,
+		Thumbnails: thumbnails,
+		Rules:      automod.NewCache(time.Minute),
 	}
 
 	r.Get("/health", handlers.HealthHandler)
@@ -21,15 +35,98 @@ func RegisterRoutes(r *chi.Mux, c *database.Queries) {
 	r.Post("/users/auth", connection.AuthenticateUserHandler)
 	r.Get("/users/unauth", handlers.UnauthenticateUserHandler)
 	r.Get("/users/{user_id}", connection.GetUserInfoHandler)
+	r.Patch("/users/{user_id}/role", connection.UpdateUserRoleHandler)
+	r.Get("/users/{user_id}/settings", connection.GetUserSettingsHandler)
+	r.Patch("/users/{user_id}/settings", connection.UpdateUserSettingsHandler)
+	r.Get("/users/me/bookmarks", connection.GetUserBookmarksHandler)
+	r.Get("/users/me/blocks", connection.GetUserBlocksHandler)
+	r.Post("/users/{user_id}/block", connection.CreateUserBlockHandler)
+	r.Delete("/users/{user_id}/block", connection.DeleteUserBlockHandler)
 
 	r.Post("/threads", connection.CreateThreadHandler)
 	r.Get("/threads", connection.GetThreadsPaginatedHandler)
 	r.Get("/threads/{thread_id}", connection.GetThreadHandler)
 	r.Patch("/threads/{thread_id}/content", connection.UpdateThreadContentHandler)
+	r.Patch("/threads/{thread_id}/state", connection.UpdateThreadStateHandler)
+	r.Post("/threads/{thread_id}/subscription", connection.CreateThreadSubscriptionHandler)
+	r.Delete("/threads/{thread_id}/subscription", connection.DeleteThreadSubscriptionHandler)
+	r.Post("/threads/{thread_id}/poll/vote", connection.CreatePollVoteHandler)
+	r.Delete("/threads/{thread_id}/poll/vote", connection.DeletePollVoteHandler)
+	r.Post("/threads/{thread_id}/bookmark", connection.CreateThreadBookmarkHandler)
+	r.Delete("/threads/{thread_id}/bookmark", connection.DeleteThreadBookmarkHandler)
+	r.Post("/threads/{thread_id}/read", connection.MarkThreadReadHandler)
 	r.Delete("/threads/{thread_id}", connection.DeleteThreadHandler)
+	r.Post("/threads/{thread_id}/reactions", connection.CreateThreadReactionHandler)
+	r.Delete("/threads/{thread_id}/reactions/{reaction}", connection.DeleteThreadReactionHandler)
 
 	r.Post("/comments", connection.CreateCommentHandler)
 	r.Get("/comments", connection.GetCommentsPaginatedHandler)
+	r.Get("/comments/{comment_id}", connection.GetCommentHandler)
+	r.Get("/comments/{comment_id}/context", connection.GetCommentContextHandler)
 	r.Patch("/comments/{comment_id}/content", connection.UpdateCommentContentHandler)
 	r.Delete("/comments/{comment_id}", connection.DeleteCommentHandler)
+	r.Post("/comments/{comment_id}/accept", connection.AcceptCommentHandler)
+	r.Delete("/comments/{comment_id}/accept", connection.UnacceptCommentHandler)
+	r.Post("/comments/{comment_id}/reactions", connection.CreateCommentReactionHandler)
+	r.Delete("/comments/{comment_id}/reactions/{reaction}", connection.DeleteCommentReactionHandler)
+	r.Post("/comments/{comment_id}/bookmark", connection.CreateCommentBookmarkHandler)
+	r.Delete("/comments/{comment_id}/bookmark", connection.DeleteCommentBookmarkHandler)
+
+	r.Patch("/bookmarks/{bookmark_id}", connection.UpdateBookmarkHandler)
+
+	r.Get("/conversations", connection.GetConversationsHandler)
+	r.Post("/conversations", connection.CreateConversationHandler)
+	r.Get("/conversations/unread-count", connection.GetUnreadMessagesCountHandler)
+	r.Get("/conversations/{conversation_id}", connection.GetConversationHandler)
+	r.Post("/conversations/{conversation_id}/leave", connection.LeaveConversationHandler)
+	r.Post("/conversations/{conversation_id}/read", connection.MarkConversationReadHandler)
+	r.Get("/conversations/{conversation_id}/messages", connection.GetMessagesHandler)
+	r.Post("/conversations/{conversation_id}/messages", connection.CreateMessageHandler)
+
+	r.Post("/attachments", connection.UploadAttachmentHandler)
+	r.Get("/attachments/{attachment_id}", connection.GetAttachmentHandler)
+	r.Get("/attachments/{attachment_id}/info", connection.GetAttachmentInfoHandler)
+	r.Get("/attachments/{attachment_id}/thumbnails/{size}", connection.GetAttachmentThumbnailHandler)
+
+	r.Post("/reports", connection.CreateReportHandler)
+	r.Get("/moderation/cases", connection.GetReportCasesHandler)
+	r.Get("/moderation/cases/{case_id}", connection.GetReportCaseHandler)
+	r.Patch("/moderation/cases/{case_id}/assignee", connection.UpdateReportCaseAssigneeHandler)
+	r.Post("/moderation/cases/{case_id}/resolve", connection.ResolveReportCaseHandler)
+
+	r.Get("/automod/rules", connection.GetAutomodRulesHandler)
+	r.Post("/automod/rules", connection.CreateAutomodRuleHandler)
+	r.Patch("/automod/rules/{rule_id}", connection.UpdateAutomodRuleHandler)
+	r.Delete("/automod/rules/{rule_id}", connection.DeleteAutomodRuleHandler)
+	r.Post("/automod/test", connection.TestAutomodRulesHandler)
+
+	r.Get("/spam", connection.GetSpamStatisticsHandler)
+	r.Post("/spam/retrain", connection.RetrainSpamClassifierHandler)
+	r.Get("/spam/tokens", connection.GetSpamTokensHandler)
+	r.Post("/spam/samples", connection.CreateSpamSampleHandler)
+
+	r.Get("/reactions", handlers.GetReactionsHandler)
+
+	r.Get("/search", connection.SearchHandler)
+
+	r.Post("/preview", connection.PreviewHandler)
+
+	r.Get("/categories", connection.GetCategoriesHandler)
+	r.Post("/categories", connection.CreateCategoryHandler)
+	r.Get("/categories/{slug}", connection.GetCategoryHandler)
+	r.Patch("/categories/{slug}", connection.UpdateCategoryHandler)
+	r.Delete("/categories/{slug}", connection.DeleteCategoryHandler)
+	r.Get("/categories/{slug}/threads", connection.GetCategoryThreadsHandler)
+
+	r.Get("/notifications", connection.GetNotificationsHandler)
+	r.Get("/notifications/unread-count", connection.GetUnreadNotificationsCountHandler)
+	r.Post("/notifications/read-all", connection.MarkAllNotificationsReadHandler)
+	r.Post("/notifications/{notification_id}/read", connection.MarkNotificationReadHandler)
+
+	r.Get("/tags", connection.GetTagsHandler)
+	r.Post("/tags", connection.CreateTagHandler)
+	r.Get("/tags/{tag_name}", connection.GetTagHandler)
+	r.Patch("/tags/{tag_name}", connection.UpdateTagHandler)
+	r.Post("/tags/{tag_name}/aliases", connection.CreateTagAliasHandler)
+	r.Delete("/tags/{tag_name}/aliases/{alias}", connection.DeleteTagAliasHandler)
 }
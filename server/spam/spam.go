@@ -0,0 +1,115 @@
+package spam
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// The minimum number of both spam and ham samples before content is classified
+const MinSamples = 10
+
+// Tokens longer than this are left out, since they are unlikely to appear again
+const maxTokenLength = 64
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+/*
+The number of spam and ham samples that have a token.
+*/
+type Counts struct {
+	Spam int64
+	Ham  int64
+}
+
+/*
+A naive Bayes model, where the totals are over every token, and the counts are only needed for the tokens being classified.
+*/
+type Model struct {
+	SpamSamples    int64
+	HamSamples     int64
+	SpamTokenCount int64
+	HamTokenCount  int64
+	Vocabulary     int64
+	Tokens         map[string]Counts
+}
+
+/*
+This function splits the content into the host of every link as 'link:<host>',
+followed by the distinct lowercase words outside of links that are at least 2 characters long, in the order that they first appear.
+*/
+func Tokenize(content string) []string {
+	tokens := []string{}
+	seen := make(map[string]bool)
+
+	add := func(token string) {
+		if len(token) > maxTokenLength || seen[token] {
+			return
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+
+	for _, link := range linkPattern.FindAllString(content, -1) {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		parsed, err := url.Parse(link)
+		if err == nil && parsed.Hostname() != "" {
+			add("link:" + strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www."))
+		}
+	}
+
+	// The words of links are left out, since their host is already a token
+	content = linkPattern.ReplaceAllString(content, " ")
+	for _, word := range wordPattern.FindAllString(strings.ToLower(content), -1) {
+		if len([]rune(word)) >= 2 {
+			add(word)
+		}
+	}
+
+	return tokens
+}
+
+/*
+This function checks if the model has enough spam and ham samples to classify content.
+*/
+func (model Model) Trained() bool {
+	return model.SpamSamples >= MinSamples && model.HamSamples >= MinSamples
+}
+
+/*
+This function returns the log-likelihood ratio of a token being in spam rather than ham, with add-one smoothing.
+Positive weights point towards spam and negative weights point towards ham.
+*/
+func (model Model) Weight(counts Counts) float64 {
+	vocabulary := float64(max(model.Vocabulary, 1))
+	spam := float64(counts.Spam+1) / (float64(model.SpamTokenCount) + vocabulary)
+	ham := float64(counts.Ham+1) / (float64(model.HamTokenCount) + vocabulary)
+
+	return math.Log(spam) - math.Log(ham)
+}
+
+/*
+This function returns the probability that the content with the tokens is spam, which is 0 if the model is not trained.
+Tokens that the model has never seen are left out.
+*/
+func (model Model) Probability(tokens []string) float64 {
+	if !model.Trained() {
+		return 0
+	}
+
+	logOdds := math.Log(float64(model.SpamSamples)) - math.Log(float64(model.HamSamples))
+	for _, token := range tokens {
+		counts, ok := model.Tokens[token]
+		if !ok {
+			continue
+		}
+		logOdds += model.Weight(counts)
+	}
+
+	return 1 / (1 + math.Exp(-logOdds))
+}
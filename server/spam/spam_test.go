@@ -0,0 +1,144 @@
+package spam
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    []string{},
+		},
+		{
+			name:    "lowercase distinct words in order",
+			content: "Buy cheap pills, BUY now!",
+			want:    []string{"buy", "cheap", "pills", "now"},
+		},
+		{
+			name:    "single characters are left out",
+			content: "a b cd 1 23",
+			want:    []string{"cd", "23"},
+		},
+		{
+			name:    "unicode words",
+			content: "Größe 東京",
+			want:    []string{"größe", "東京"},
+		},
+		{
+			name:    "links become their host before the words",
+			content: "Visit https://Shop.Example.com/buy?now=1 today",
+			want:    []string{"link:shop.example.com", "visit", "today"},
+		},
+		{
+			name:    "www is left out of hosts",
+			content: "www.example.com and http://www.example.com/page",
+			want:    []string{"link:example.com", "and"},
+		},
+		{
+			name:    "long tokens are left out",
+			content: strings.Repeat("a", maxTokenLength+1) + " short",
+			want:    []string{"short"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Tokenize(test.content)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func trainedModel() Model {
+	return Model{
+		SpamSamples:    MinSamples,
+		HamSamples:     MinSamples,
+		SpamTokenCount: 20,
+		HamTokenCount:  20,
+		Vocabulary:     4,
+		Tokens: map[string]Counts{
+			"pills":  {Spam: 9, Ham: 1},
+			"cheap":  {Spam: 5, Ham: 5},
+			"thanks": {Spam: 1, Ham: 9},
+			"hello":  {Spam: 5, Ham: 5},
+		},
+	}
+}
+
+func TestProbability(t *testing.T) {
+	t.Run("untrained model", func(t *testing.T) {
+		model := trainedModel()
+		model.HamSamples = MinSamples - 1
+		if got := model.Probability([]string{"pills"}); got != 0 {
+			t.Errorf("got %v, want 0", got)
+		}
+	})
+
+	t.Run("no tokens uses the prior", func(t *testing.T) {
+		model := trainedModel()
+		model.SpamSamples = 30
+		want := 30.0 / (30 + MinSamples)
+		if got := model.Probability(nil); math.Abs(got-want) > 1e-9 {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("unseen tokens are left out", func(t *testing.T) {
+		model := trainedModel()
+		if got := model.Probability([]string{"unseen", "other"}); math.Abs(got-0.5) > 1e-9 {
+			t.Errorf("got %v, want 0.5", got)
+		}
+	})
+
+	t.Run("balanced tokens are neutral", func(t *testing.T) {
+		model := trainedModel()
+		if got := model.Probability([]string{"cheap", "hello"}); math.Abs(got-0.5) > 1e-9 {
+			t.Errorf("got %v, want 0.5", got)
+		}
+	})
+
+	t.Run("spam and ham tokens", func(t *testing.T) {
+		model := trainedModel()
+		spam := model.Probability([]string{"pills"})
+		ham := model.Probability([]string{"thanks"})
+		if spam <= 0.5 || ham >= 0.5 {
+			t.Errorf("got %v for spam and %v for ham", spam, ham)
+		}
+		if math.Abs(spam+ham-1) > 1e-9 {
+			t.Errorf("got %v and %v, want them to be symmetric", spam, ham)
+		}
+	})
+}
+
+func TestWeight(t *testing.T) {
+	model := trainedModel()
+
+	// Add-one smoothing over a vocabulary of 4: (9 + 1) / (20 + 4) against (1 + 1) / (20 + 4)
+	want := math.Log(10.0 / 2.0)
+	if got := model.Weight(Counts{Spam: 9, Ham: 1}); math.Abs(got-want) > 1e-9 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Tokens that were never seen still have a finite weight
+	got := model.Weight(Counts{})
+	if math.IsInf(got, 0) || math.IsNaN(got) {
+		t.Errorf("got %v for an unseen token", got)
+	}
+
+	// An empty model does not divide by zero
+	got = Model{}.Weight(Counts{Spam: 1})
+	if math.IsInf(got, 0) || math.IsNaN(got) {
+		t.Errorf("got %v for an empty model", got)
+	}
+}
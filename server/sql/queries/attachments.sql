@@ -0,0 +1,75 @@
+-- Uploading the same content again refreshes the blob, so that it is not garbage collected within the grace period of the upload
+-- name: UpsertBlob :one
+INSERT INTO blobs (sha256, size, content_type, thumbnail_status)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (sha256) DO UPDATE
+SET uploaded_timestamp = CURRENT_TIMESTAMP,
+thumbnail_status = CASE WHEN blobs.thumbnail_status = 'none' THEN EXCLUDED.thumbnail_status ELSE blobs.thumbnail_status END
+RETURNING thumbnail_status;
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (blob_sha256, uploader_id, filename)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetAttachment :one
+SELECT attachments.*, blobs.size, blobs.content_type, blobs.thumbnail_status
+FROM attachments
+INNER JOIN blobs ON blobs.sha256 = attachments.blob_sha256
+WHERE attachments.id = $1;
+
+-- name: DeleteThreadAttachmentReferences :exec
+DELETE FROM attachment_references
+WHERE thread_id = $1 AND comment_id IS NULL;
+
+-- name: DeleteCommentAttachmentReferences :exec
+DELETE FROM attachment_references
+WHERE comment_id = $1;
+
+-- Links to attachments that do not exist are ignored
+-- name: CreateAttachmentReferences :exec
+INSERT INTO attachment_references (attachment_id, thread_id, comment_id)
+SELECT attachments.id, sqlc.arg(thread_id)::INTEGER, sqlc.narg(comment_id)::INTEGER
+FROM attachments
+WHERE attachments.id = ANY(sqlc.arg(attachment_ids)::INTEGER[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteOrphanedAttachments :execrows
+DELETE FROM attachments
+WHERE attachments.created_timestamp < sqlc.arg(created_before)::TIMESTAMPTZ
+AND NOT EXISTS (
+    SELECT 1 FROM attachment_references
+    WHERE attachment_references.attachment_id = attachments.id
+);
+
+-- name: GetOrphanedBlobs :many
+SELECT blobs.sha256 FROM blobs
+WHERE blobs.uploaded_timestamp < sqlc.arg(uploaded_before)::TIMESTAMPTZ
+AND NOT EXISTS (
+    SELECT 1 FROM attachments
+    WHERE attachments.blob_sha256 = blobs.sha256
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blob_thumbnails
+    WHERE blob_thumbnails.thumbnail_sha256 = blobs.sha256
+)
+LIMIT sqlc.arg(result_limit)::INTEGER;
+
+-- Blobs that are being uploaded again were refreshed by the upload, so they are skipped until their grace period is over
+-- name: LockOrphanedBlob :one
+SELECT blobs.sha256 FROM blobs
+WHERE blobs.sha256 = sqlc.arg(sha256)
+AND blobs.uploaded_timestamp < sqlc.arg(uploaded_before)::TIMESTAMPTZ
+AND NOT EXISTS (
+    SELECT 1 FROM attachments
+    WHERE attachments.blob_sha256 = blobs.sha256
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blob_thumbnails
+    WHERE blob_thumbnails.thumbnail_sha256 = blobs.sha256
+)
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteBlob :exec
+DELETE FROM blobs
+WHERE sha256 = $1;
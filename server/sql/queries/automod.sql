@@ -0,0 +1,29 @@
+-- name: GetAutomodRules :many
+SELECT * FROM automod_rules
+ORDER BY id;
+
+-- name: GetEnabledAutomodRules :many
+SELECT * FROM automod_rules
+WHERE enabled
+ORDER BY id;
+
+-- name: GetAutomodRule :one
+SELECT * FROM automod_rules
+WHERE id = $1;
+
+-- name: CreateAutomodRule :one
+INSERT INTO automod_rules (name, pattern, words, max_links, max_account_age_hours, action, message, replacement, enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: UpdateAutomodRule :one
+UPDATE automod_rules
+SET name = $2, pattern = $3, words = $4, max_links = $5, max_account_age_hours = $6,
+action = $7, message = $8, replacement = $9, enabled = $10, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteAutomodRule :one
+DELETE FROM automod_rules
+WHERE id = $1
+RETURNING id;
@@ -0,0 +1,23 @@
+-- name: CreateUserBlock :one
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: DeleteUserBlock :one
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2
+RETURNING *;
+
+-- name: GetUserBlocks :many
+SELECT user_blocks.blocked_id, users.username, user_blocks.created_timestamp
+FROM user_blocks
+JOIN users ON users.id = user_blocks.blocked_id
+WHERE user_blocks.blocker_id = $1
+ORDER BY user_blocks.created_timestamp DESC;
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = ANY(sqlc.arg(other_ids)::UUID[]))
+    OR (blocked_id = sqlc.arg(user_id) AND blocker_id = ANY(sqlc.arg(other_ids)::UUID[]))
+);
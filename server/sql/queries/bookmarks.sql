@@ -0,0 +1,79 @@
+-- name: CreateThreadBookmark :one
+INSERT INTO bookmarks (user_id, thread_id, note, folder)
+SELECT sqlc.arg(user_id), threads.id, sqlc.arg(note), sqlc.arg(folder)
+FROM threads
+WHERE threads.id = sqlc.arg(thread_id)
+AND (NOT threads.held OR threads.creator_id = sqlc.arg(user_id) OR sqlc.arg(include_held)::BOOLEAN)
+RETURNING *;
+
+-- name: CreateCommentBookmark :one
+INSERT INTO bookmarks (user_id, thread_id, comment_id, note, folder)
+SELECT sqlc.arg(user_id), comments.thread_id, comments.id, sqlc.arg(note), sqlc.arg(folder)
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = sqlc.arg(comment_id)
+AND (NOT threads.held OR threads.creator_id = sqlc.arg(user_id) OR sqlc.arg(include_held)::BOOLEAN)
+AND (NOT comments.held OR comments.creator_id = sqlc.arg(user_id) OR sqlc.arg(include_held)::BOOLEAN)
+RETURNING *;
+
+-- name: DeleteThreadBookmark :one
+DELETE FROM bookmarks
+WHERE user_id = $1 AND thread_id = $2 AND comment_id IS NULL
+RETURNING *;
+
+-- name: DeleteCommentBookmark :one
+DELETE FROM bookmarks
+WHERE user_id = $1 AND comment_id = $2
+RETURNING *;
+
+-- name: UpdateBookmark :one
+UPDATE bookmarks
+SET note = COALESCE(sqlc.narg(note), note), folder = COALESCE(sqlc.narg(folder), folder)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: GetBookmarks :many
+SELECT bookmarks.*, threads.title AS thread_title,
+COALESCE(comments.content, threads.content)::TEXT AS content,
+COALESCE(comments.content_html, threads.content_html)::TEXT AS content_html
+FROM bookmarks
+JOIN threads ON threads.id = bookmarks.thread_id
+LEFT JOIN comments ON comments.id = bookmarks.comment_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND NOT threads.held AND (comments.id IS NULL OR NOT comments.held)
+AND (sqlc.narg(folder)::VARCHAR(50) IS NULL OR bookmarks.folder = sqlc.narg(folder)::VARCHAR(50))
+AND (sqlc.narg(after_id)::INTEGER IS NULL OR bookmarks.id < sqlc.narg(after_id)::INTEGER)
+ORDER BY bookmarks.id DESC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
+
+-- name: GetBookmarksBefore :many
+SELECT bookmarks.*, threads.title AS thread_title,
+COALESCE(comments.content, threads.content)::TEXT AS content,
+COALESCE(comments.content_html, threads.content_html)::TEXT AS content_html
+FROM bookmarks
+JOIN threads ON threads.id = bookmarks.thread_id
+LEFT JOIN comments ON comments.id = bookmarks.comment_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND NOT threads.held AND (comments.id IS NULL OR NOT comments.held)
+AND (sqlc.narg(folder)::VARCHAR(50) IS NULL OR bookmarks.folder = sqlc.narg(folder)::VARCHAR(50))
+AND bookmarks.id > sqlc.arg(before_id)::INTEGER
+ORDER BY bookmarks.id ASC
+LIMIT sqlc.arg(result_limit);
+
+-- name: GetBookmarksCount :one
+SELECT COUNT(*) FROM bookmarks
+JOIN threads ON threads.id = bookmarks.thread_id
+LEFT JOIN comments ON comments.id = bookmarks.comment_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND NOT threads.held AND (comments.id IS NULL OR NOT comments.held)
+AND (sqlc.narg(folder)::VARCHAR(50) IS NULL OR bookmarks.folder = sqlc.narg(folder)::VARCHAR(50));
+
+-- name: GetBookmarkedThreads :many
+SELECT thread_id FROM bookmarks
+WHERE user_id = sqlc.arg(user_id) AND comment_id IS NULL
+AND thread_id = ANY(sqlc.arg(thread_ids)::INTEGER[]);
+
+-- name: GetBookmarkedComments :many
+SELECT comment_id::INTEGER FROM bookmarks
+WHERE user_id = sqlc.arg(user_id)
+AND comment_id = ANY(sqlc.arg(comment_ids)::INTEGER[]);
@@ -0,0 +1,42 @@
+-- name: GetCategories :many
+SELECT * FROM categories
+ORDER BY position ASC, name ASC;
+
+-- name: GetCategory :one
+SELECT * FROM categories
+WHERE id = $1;
+
+-- name: GetCategoryBySlug :one
+SELECT * FROM categories
+WHERE slug = $1;
+
+-- name: CreateCategory :one
+INSERT INTO categories (slug, name, description, position, parent_id, post_role)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateCategory :one
+UPDATE categories
+SET slug = $2, name = $3, description = $4, position = $5, parent_id = $6, post_role = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteCategory :one
+DELETE FROM categories
+WHERE id = $1
+RETURNING *;
+
+-- name: GetCategoriesRequiredTags :many
+SELECT category_required_tags.category_id, tags.id, tags.name FROM category_required_tags
+JOIN tags ON tags.id = category_required_tags.tag_id
+WHERE category_required_tags.category_id = ANY(sqlc.arg(category_ids)::INTEGER[])
+ORDER BY tags.name ASC;
+
+-- name: DeleteCategoryRequiredTags :exec
+DELETE FROM category_required_tags
+WHERE category_id = $1;
+
+-- name: CreateCategoryRequiredTag :exec
+INSERT INTO category_required_tags (category_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
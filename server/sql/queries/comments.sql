@@ -1,29 +1,98 @@
 -- name: CreateComment :one
-INSERT INTO comments (content, thread_id, creator_id)
-VALUES ($1, $2, $3)
+INSERT INTO comments (content, content_html, thread_id, creator_id, parent_id, held)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetCommentCreatorID :one
 SELECT creator_id FROM comments
 WHERE id = $1;
 
+-- Edits can hold the comment, but only moderators can release it
 -- name: UpdateCommentContent :one
 UPDATE comments
-SET content = $2, updated_timestamp = CURRENT_TIMESTAMP
+SET content = $2, content_html = $3, updated_timestamp = CURRENT_TIMESTAMP, held = held OR $4
 WHERE id = $1
-RETURNING content, updated_timestamp;
+RETURNING content, content_html, updated_timestamp, held;
 
 -- name: DeleteComment :one
 DELETE FROM comments
 WHERE id = $1
 RETURNING *;
 
+-- The accepted answer of the thread is listed first, but is fetched separately with GetAcceptedComment,
+-- so that the rest of the comments are paged on (created_timestamp, id) alone
+
+-- name: GetAcceptedComment :one
+SELECT * FROM comments
+WHERE id = $1 AND NOT held;
+
 -- name: GetCommentsPaginated :many
 SELECT * FROM comments
-WHERE thread_id = $1
-ORDER BY created_timestamp ASC 
-LIMIT $2 OFFSET $3;
+WHERE thread_id = sqlc.arg(thread_id) AND NOT held
+AND id IS DISTINCT FROM sqlc.narg(accepted_id)::INTEGER
+ORDER BY created_timestamp ASC, id ASC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
+
+-- name: GetCommentsAfter :many
+SELECT * FROM comments
+WHERE thread_id = sqlc.arg(thread_id) AND NOT held
+AND id IS DISTINCT FROM sqlc.narg(accepted_id)::INTEGER
+AND (created_timestamp, id) > (sqlc.arg(created_timestamp)::TIMESTAMPTZ, sqlc.arg(id)::INTEGER)
+ORDER BY created_timestamp ASC, id ASC
+LIMIT sqlc.arg(result_limit);
+
+-- name: GetCommentsBefore :many
+SELECT * FROM comments
+WHERE thread_id = sqlc.arg(thread_id) AND NOT held
+AND id IS DISTINCT FROM sqlc.narg(accepted_id)::INTEGER
+AND (created_timestamp, id) < (sqlc.arg(created_timestamp)::TIMESTAMPTZ, sqlc.arg(id)::INTEGER)
+ORDER BY created_timestamp DESC, id DESC
+LIMIT sqlc.arg(result_limit);
 
 -- name: GetCommentsPaginatedCount :one
 SELECT COUNT(*) FROM comments
-WHERE thread_id = $1;
\ No newline at end of file
+WHERE thread_id = $1 AND NOT held;
+
+-- name: GetCommentsWithoutContentHTML :many
+SELECT id, content FROM comments
+WHERE id > sqlc.arg(after_id) AND content_html = '' AND content <> ''
+ORDER BY id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateCommentContentHTML :exec
+UPDATE comments
+SET content_html = $2
+WHERE id = $1;
+
+-- name: GetCommentThreadAndCreator :one
+SELECT thread_id, creator_id, held FROM comments
+WHERE id = $1;
+
+-- name: GetComment :one
+SELECT * FROM comments
+WHERE id = $1;
+
+-- The accepted answer is first, and the rest of the comments are counted up to the comment together with the accepted answer
+-- name: GetCommentListPosition :one
+SELECT comments.thread_id, (CASE WHEN comments.id = threads.accepted_comment_id THEN 1 ELSE (
+    SELECT COUNT(*) FROM comments AS earlier
+    WHERE earlier.thread_id = comments.thread_id AND NOT earlier.held
+    AND (earlier.id = threads.accepted_comment_id
+    OR (earlier.created_timestamp, earlier.id) <= (comments.created_timestamp, comments.id))
+) END)::INTEGER AS position
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = $1 AND NOT comments.held;
+
+-- Comments in held threads cannot be quoted either, so that their authors are not revealed
+-- name: GetQuotedCommentAuthors :many
+SELECT comments.id, users.username FROM comments
+JOIN users ON users.id = comments.creator_id
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = ANY(sqlc.arg(comment_ids)::INTEGER[]) AND NOT comments.held AND NOT threads.held;
+
+-- name: ReleaseComment :one
+UPDATE comments
+SET held = FALSE
+WHERE id = $1 AND held
+RETURNING *;
@@ -0,0 +1,132 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (creator_id, is_group, title)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id)
+SELECT sqlc.arg(conversation_id), UNNEST(sqlc.arg(user_ids)::UUID[]);
+
+-- The existing direct conversation between the users is returned instead if there is one, with created set to false
+-- name: CreateDirectConversation :one
+INSERT INTO conversations (creator_id, is_group, direct_low_id, direct_high_id)
+VALUES (sqlc.arg(creator_id)::UUID, FALSE,
+LEAST(sqlc.arg(creator_id)::UUID, sqlc.arg(other_id)::UUID), GREATEST(sqlc.arg(creator_id)::UUID, sqlc.arg(other_id)::UUID))
+ON CONFLICT (direct_low_id, direct_high_id) DO UPDATE
+SET direct_low_id = conversations.direct_low_id
+RETURNING *, (xmax = 0)::BOOLEAN AS created;
+
+-- A new direct conversation can be started between the users once either of them leaves
+-- name: ClearDirectConversation :exec
+UPDATE conversations
+SET direct_low_id = NULL, direct_high_id = NULL
+WHERE id = $1;
+
+-- name: GetConversation :one
+SELECT conversations.*, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.id > conversation_participants.last_read_message_id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND NOT EXISTS (
+        SELECT 1 FROM user_blocks
+        WHERE user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = messages.sender_id
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id)
+AND conversation_participants.user_id = sqlc.arg(user_id) AND conversation_participants.left_timestamp IS NULL;
+
+-- name: GetUserConversations :many
+SELECT conversations.*, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.id > conversation_participants.last_read_message_id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND NOT EXISTS (
+        SELECT 1 FROM user_blocks
+        WHERE user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = messages.sender_id
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg(user_id) AND conversation_participants.left_timestamp IS NULL
+ORDER BY conversations.last_message_timestamp DESC, conversations.id DESC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
+
+-- name: GetUserConversationsCount :one
+SELECT COUNT(*) FROM conversation_participants
+WHERE user_id = $1 AND left_timestamp IS NULL;
+
+-- name: GetUnreadMessagesCount :one
+SELECT COUNT(*) FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+WHERE conversation_participants.user_id = $1 AND conversation_participants.left_timestamp IS NULL
+AND messages.id > conversation_participants.last_read_message_id
+AND messages.sender_id <> conversation_participants.user_id
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = messages.sender_id
+);
+
+-- name: GetConversationsParticipants :many
+SELECT conversation_participants.conversation_id, conversation_participants.user_id, users.username,
+conversation_participants.joined_timestamp, conversation_participants.left_timestamp,
+conversation_participants.last_read_message_id
+FROM conversation_participants
+JOIN users ON users.id = conversation_participants.user_id
+WHERE conversation_participants.conversation_id = ANY(sqlc.arg(conversation_ids)::INTEGER[])
+ORDER BY conversation_participants.conversation_id, conversation_participants.joined_timestamp, users.username;
+
+-- name: LeaveConversation :one
+UPDATE conversation_participants
+SET left_timestamp = CURRENT_TIMESTAMP
+WHERE conversation_id = $1 AND user_id = $2 AND left_timestamp IS NULL
+RETURNING *;
+
+-- name: MarkConversationRead :one
+UPDATE conversation_participants
+SET last_read_message_id = GREATEST(last_read_message_id, sqlc.arg(message_id)::INTEGER)
+WHERE conversation_id = sqlc.arg(conversation_id) AND user_id = sqlc.arg(user_id) AND left_timestamp IS NULL
+RETURNING *;
+
+-- name: CreateMessage :one
+INSERT INTO messages (conversation_id, sender_id, content, content_html)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateConversationLastMessage :exec
+UPDATE conversations
+SET last_message_timestamp = $2
+WHERE id = $1;
+
+-- name: GetLatestMessageID :one
+SELECT COALESCE(MAX(id), 0)::INTEGER FROM messages
+WHERE conversation_id = $1;
+
+-- name: GetMessageConversationID :one
+SELECT conversation_id FROM messages
+WHERE id = $1;
+
+-- name: GetMessages :many
+SELECT messages.* FROM messages
+WHERE messages.conversation_id = sqlc.arg(conversation_id)
+AND (sqlc.narg(after_id)::INTEGER IS NULL OR messages.id < sqlc.narg(after_id)::INTEGER)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = messages.sender_id
+)
+ORDER BY messages.id DESC
+LIMIT sqlc.arg(result_limit);
+
+-- name: GetMessagesBefore :many
+SELECT messages.* FROM messages
+WHERE messages.conversation_id = sqlc.arg(conversation_id)
+AND messages.id > sqlc.arg(before_id)::INTEGER
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = sqlc.arg(viewer_id) AND user_blocks.blocked_id = messages.sender_id
+)
+ORDER BY messages.id ASC
+LIMIT sqlc.arg(result_limit);
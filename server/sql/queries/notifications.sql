@@ -0,0 +1,54 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id)
+VALUES (sqlc.arg(user_id), sqlc.arg(type), sqlc.arg(actor_id), sqlc.arg(thread_id)::INTEGER, sqlc.narg(comment_id));
+
+-- name: CreateMentionNotifications :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id)
+SELECT users.id, 'mention', sqlc.arg(actor_id)::UUID, sqlc.arg(thread_id)::INTEGER, sqlc.narg(comment_id)::INTEGER
+FROM users
+WHERE users.username = ANY(sqlc.arg(usernames)::VARCHAR(20)[])
+AND users.id <> sqlc.arg(actor_id)::UUID
+AND NOT EXISTS (
+    SELECT 1 FROM notifications
+    WHERE notifications.user_id = users.id
+    AND notifications.thread_id = sqlc.arg(thread_id)::INTEGER
+    AND notifications.comment_id IS NOT DISTINCT FROM sqlc.narg(comment_id)::INTEGER
+)
+ON CONFLICT (user_id, thread_id, COALESCE(comment_id, 0)) WHERE type = 'mention' DO NOTHING;
+
+-- name: GetNotifications :many
+SELECT notifications.*, users.username AS actor_username, threads.title AS thread_title
+FROM notifications
+JOIN users ON users.id = notifications.actor_id
+LEFT JOIN threads ON threads.id = notifications.thread_id
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(after_id)::INTEGER IS NULL OR notifications.id < sqlc.narg(after_id)::INTEGER)
+AND (NOT sqlc.arg(unread_only)::BOOLEAN OR NOT notifications.read)
+ORDER BY notifications.id DESC
+LIMIT sqlc.arg(result_limit);
+
+-- name: GetNotificationsBefore :many
+SELECT notifications.*, users.username AS actor_username, threads.title AS thread_title
+FROM notifications
+JOIN users ON users.id = notifications.actor_id
+LEFT JOIN threads ON threads.id = notifications.thread_id
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND notifications.id > sqlc.arg(before_id)::INTEGER
+AND (NOT sqlc.arg(unread_only)::BOOLEAN OR NOT notifications.read)
+ORDER BY notifications.id ASC
+LIMIT sqlc.arg(result_limit);
+
+-- name: GetUnreadNotificationsCount :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND NOT read;
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read = TRUE
+WHERE id = $1 AND user_id = $2
+RETURNING id;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read = TRUE
+WHERE user_id = $1 AND NOT read;
@@ -0,0 +1,42 @@
+-- name: CreatePoll :one
+INSERT INTO polls (thread_id, question, multiple_choice, hide_results, closes_timestamp)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options (poll_id, position, text)
+SELECT sqlc.arg(poll_id)::INTEGER, options.position::INTEGER, options.text
+FROM UNNEST(sqlc.arg(texts)::TEXT[]) WITH ORDINALITY AS options(text, position);
+
+-- name: GetThreadPoll :one
+SELECT * FROM polls
+WHERE thread_id = $1;
+
+-- name: GetPollResults :many
+SELECT poll_options.id, poll_options.text, COUNT(poll_votes.user_id) AS votes
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.poll_id = $1
+GROUP BY poll_options.id
+ORDER BY poll_options.position;
+
+-- name: GetPollVotersCount :one
+SELECT COUNT(*) FROM poll_ballots
+WHERE poll_id = $1;
+
+-- name: GetUserPollVotes :many
+SELECT option_id FROM poll_votes
+WHERE poll_id = $1 AND user_id = $2
+ORDER BY option_id;
+
+-- name: CreatePollBallot :exec
+INSERT INTO poll_ballots (poll_id, user_id)
+VALUES ($1, $2);
+
+-- name: CreatePollVotes :exec
+INSERT INTO poll_votes (poll_id, user_id, option_id)
+SELECT sqlc.arg(poll_id)::INTEGER, sqlc.arg(user_id)::UUID, UNNEST(sqlc.arg(option_ids)::INTEGER[]);
+
+-- name: DeletePollBallot :execrows
+DELETE FROM poll_ballots
+WHERE poll_id = $1 AND user_id = $2;
@@ -0,0 +1,35 @@
+-- name: CreateThreadReaction :one
+INSERT INTO thread_reactions (thread_id, user_id, reaction)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteThreadReaction :one
+DELETE FROM thread_reactions
+WHERE thread_id = $1 AND user_id = $2 AND reaction = $3
+RETURNING *;
+
+-- name: GetThreadsReactions :many
+SELECT thread_id, reaction, COUNT(*) AS count,
+COALESCE(BOOL_OR(user_id = sqlc.narg(viewer_id)), FALSE)::BOOLEAN AS reacted
+FROM thread_reactions
+WHERE thread_id = ANY(sqlc.arg(thread_ids)::INTEGER[])
+GROUP BY thread_id, reaction
+ORDER BY thread_id, MIN(created_timestamp), reaction;
+
+-- name: CreateCommentReaction :one
+INSERT INTO comment_reactions (comment_id, user_id, reaction)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteCommentReaction :one
+DELETE FROM comment_reactions
+WHERE comment_id = $1 AND user_id = $2 AND reaction = $3
+RETURNING *;
+
+-- name: GetCommentsReactions :many
+SELECT comment_id, reaction, COUNT(*) AS count,
+COALESCE(BOOL_OR(user_id = sqlc.narg(viewer_id)), FALSE)::BOOLEAN AS reacted
+FROM comment_reactions
+WHERE comment_id = ANY(sqlc.arg(comment_ids)::INTEGER[])
+GROUP BY comment_id, reaction
+ORDER BY comment_id, MIN(created_timestamp), reaction;
@@ -0,0 +1,48 @@
+-- name: GetCommentReadPosition :one
+SELECT comments.id, comments.thread_id, comments.created_timestamp, (
+    SELECT COUNT(*) FROM comments AS earlier
+    WHERE earlier.thread_id = comments.thread_id AND NOT earlier.held
+    AND (earlier.created_timestamp, earlier.id) <= (comments.created_timestamp, comments.id)
+)::INTEGER AS position
+FROM comments
+WHERE comments.id = $1 AND NOT comments.held;
+
+-- name: GetLatestCommentReadPosition :one
+SELECT comments.id, comments.thread_id, comments.created_timestamp, (
+    SELECT COUNT(*) FROM comments AS earlier
+    WHERE earlier.thread_id = comments.thread_id AND NOT earlier.held
+)::INTEGER AS position
+FROM comments
+WHERE comments.thread_id = $1 AND NOT comments.held
+ORDER BY comments.created_timestamp DESC, comments.id DESC
+LIMIT 1;
+
+-- name: MarkThreadRead :exec
+INSERT INTO thread_reads (user_id, thread_id, read_comment_count, last_read_comment_id, last_read_comment_timestamp)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, thread_id) DO UPDATE
+SET read_comment_count = EXCLUDED.read_comment_count,
+last_read_comment_id = EXCLUDED.last_read_comment_id,
+last_read_comment_timestamp = EXCLUDED.last_read_comment_timestamp,
+read_timestamp = CURRENT_TIMESTAMP
+-- The marker only moves forward, so reading older comments again does not mark newer ones as unread
+WHERE (COALESCE(thread_reads.last_read_comment_timestamp, '-infinity'), COALESCE(thread_reads.last_read_comment_id, 0))
+<= (COALESCE(EXCLUDED.last_read_comment_timestamp, '-infinity'), COALESCE(EXCLUDED.last_read_comment_id, 0));
+
+-- name: GetThreadRead :one
+SELECT * FROM thread_reads
+WHERE user_id = $1 AND thread_id = $2;
+
+-- name: GetThreadsReads :many
+SELECT thread_id, read_comment_count, read_timestamp FROM thread_reads
+WHERE user_id = sqlc.arg(user_id)
+AND thread_id = ANY(sqlc.arg(thread_ids)::INTEGER[]);
+
+-- The accepted answer is counted even if it is unread, since it is listed before the rest of the comments
+-- name: GetReadCommentsCount :one
+SELECT COUNT(*) FROM comments
+JOIN thread_reads ON thread_reads.thread_id = comments.thread_id
+JOIN threads ON threads.id = comments.thread_id
+WHERE thread_reads.user_id = $1 AND comments.thread_id = $2 AND NOT comments.held
+AND ((comments.created_timestamp, comments.id) <= (thread_reads.last_read_comment_timestamp, thread_reads.last_read_comment_id)
+OR comments.id = threads.accepted_comment_id);
@@ -0,0 +1,105 @@
+-- Reports of a target with an open case join that case, which is returned even if another report opened it at the same time.
+-- The title and content are only stored when the case is opened, so later reports keep them as they were
+-- name: OpenThreadReportCase :one
+INSERT INTO report_cases (target_type, thread_id, target_user_id, target_title, target_content)
+VALUES ('thread', $1, $2, $3, $4)
+ON CONFLICT (thread_id) WHERE status = 'open' AND target_type = 'thread'
+DO UPDATE SET updated_timestamp = report_cases.updated_timestamp
+RETURNING id;
+
+-- name: OpenCommentReportCase :one
+INSERT INTO report_cases (target_type, thread_id, comment_id, target_user_id, target_content)
+VALUES ('comment', $1, $2, $3, $4)
+ON CONFLICT (comment_id) WHERE status = 'open' AND target_type = 'comment'
+DO UPDATE SET updated_timestamp = report_cases.updated_timestamp
+RETURNING id;
+
+-- name: OpenUserReportCase :one
+INSERT INTO report_cases (target_type, target_user_id)
+VALUES ('user', $1)
+ON CONFLICT (target_user_id) WHERE status = 'open' AND target_type = 'user'
+DO UPDATE SET updated_timestamp = report_cases.updated_timestamp
+RETURNING id;
+
+-- name: CreateReport :one
+INSERT INTO reports (case_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: AddReportCaseReport :exec
+UPDATE report_cases
+SET report_count = report_count + 1, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetReportCases :many
+SELECT report_cases.*, users.username AS target_username,
+ARRAY(SELECT DISTINCT reports.reason FROM reports WHERE reports.case_id = report_cases.id ORDER BY reports.reason)::VARCHAR[] AS reasons
+FROM report_cases
+JOIN users ON users.id = report_cases.target_user_id
+WHERE (sqlc.narg(status)::VARCHAR IS NULL
+    OR (sqlc.narg(status)::VARCHAR = 'resolved' AND report_cases.status <> 'open')
+    OR report_cases.status = sqlc.narg(status)::VARCHAR)
+AND (sqlc.narg(target_type)::VARCHAR IS NULL OR report_cases.target_type = sqlc.narg(target_type)::VARCHAR)
+AND (sqlc.narg(reason)::VARCHAR IS NULL OR EXISTS (
+    SELECT 1 FROM reports
+    WHERE reports.case_id = report_cases.id AND reports.reason = sqlc.narg(reason)::VARCHAR
+))
+AND (sqlc.narg(assignee_id)::UUID IS NULL OR report_cases.assignee_id = sqlc.narg(assignee_id)::UUID)
+AND (NOT sqlc.arg(unassigned)::BOOLEAN OR report_cases.assignee_id IS NULL)
+ORDER BY report_cases.created_timestamp ASC, report_cases.id ASC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
+
+-- name: GetReportCasesCount :one
+SELECT COUNT(*) FROM report_cases
+WHERE (sqlc.narg(status)::VARCHAR IS NULL
+    OR (sqlc.narg(status)::VARCHAR = 'resolved' AND report_cases.status <> 'open')
+    OR report_cases.status = sqlc.narg(status)::VARCHAR)
+AND (sqlc.narg(target_type)::VARCHAR IS NULL OR report_cases.target_type = sqlc.narg(target_type)::VARCHAR)
+AND (sqlc.narg(reason)::VARCHAR IS NULL OR EXISTS (
+    SELECT 1 FROM reports
+    WHERE reports.case_id = report_cases.id AND reports.reason = sqlc.narg(reason)::VARCHAR
+))
+AND (sqlc.narg(assignee_id)::UUID IS NULL OR report_cases.assignee_id = sqlc.narg(assignee_id)::UUID)
+AND (NOT sqlc.arg(unassigned)::BOOLEAN OR report_cases.assignee_id IS NULL);
+
+-- name: GetReportCase :one
+SELECT report_cases.*, users.username AS target_username,
+ARRAY(SELECT DISTINCT reports.reason FROM reports WHERE reports.case_id = report_cases.id ORDER BY reports.reason)::VARCHAR[] AS reasons
+FROM report_cases
+JOIN users ON users.id = report_cases.target_user_id
+WHERE report_cases.id = $1;
+
+-- name: GetCaseReports :many
+SELECT reports.*, users.username AS reporter_username
+FROM reports
+LEFT JOIN users ON users.id = reports.reporter_id
+WHERE reports.case_id = $1
+ORDER BY reports.id;
+
+-- name: AssignReportCase :one
+UPDATE report_cases
+SET assignee_id = sqlc.narg(assignee_id), updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+RETURNING id;
+
+-- name: ResolveReportCase :one
+UPDATE report_cases
+SET status = sqlc.arg(status), resolver_id = sqlc.arg(resolver_id), resolution_note = sqlc.arg(resolution_note),
+updated_timestamp = CURRENT_TIMESTAMP, resolved_timestamp = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+-- name: CreateReportResolvedNotifications :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id, case_id)
+SELECT reports.reporter_id, 'report_resolved', sqlc.arg(actor_id)::UUID,
+report_cases.thread_id, report_cases.comment_id, report_cases.id
+FROM reports
+JOIN report_cases ON report_cases.id = reports.case_id
+WHERE reports.case_id = sqlc.arg(case_id)::INTEGER AND reports.reporter_id IS NOT NULL;
+
+-- name: CreateWarningNotification :exec
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id, case_id)
+SELECT report_cases.target_user_id, 'warning', sqlc.arg(actor_id)::UUID,
+report_cases.thread_id, report_cases.comment_id, report_cases.id
+FROM report_cases
+WHERE report_cases.id = sqlc.arg(case_id)::INTEGER;
@@ -0,0 +1,41 @@
+-- name: SearchPosts :many
+SELECT type, thread_id, comment_id, title,
+ts_headline('english', content, to_tsquery('english', sqlc.arg(query)),
+    E'StartSel=\uE000, StopSel=\uE001, MaxFragments=2, MaxWords=30, MinWords=10')::TEXT AS snippet,
+creator_id, created_timestamp, ts_rank(document, to_tsquery('english', sqlc.arg(query)))::REAL AS rank
+FROM search_posts
+WHERE document @@ to_tsquery('english', sqlc.arg(query))
+AND ((type = 'thread' AND sqlc.arg(include_threads)::BOOLEAN) OR (type = 'comment' AND sqlc.arg(include_comments)::BOOLEAN))
+AND NOT EXISTS (
+    SELECT 1 FROM UNNEST(sqlc.arg(tags)::VARCHAR(35)[]) AS wanted(name)
+    WHERE NOT EXISTS (
+        SELECT 1 FROM thread_tags
+        JOIN tags ON tags.id = thread_tags.tag_id
+        LEFT JOIN tag_aliases ON tag_aliases.tag_id = tags.id AND LOWER(tag_aliases.alias) = LOWER(wanted.name)
+        WHERE thread_tags.thread_id = search_posts.thread_id
+        AND (LOWER(tags.name) = LOWER(wanted.name) OR tag_aliases.tag_id IS NOT NULL)
+    )
+)
+AND (sqlc.narg(creator_id)::UUID IS NULL OR creator_id = sqlc.narg(creator_id))
+AND (sqlc.narg(created_after)::TIMESTAMPTZ IS NULL OR created_timestamp >= sqlc.narg(created_after))
+AND (sqlc.narg(created_before)::TIMESTAMPTZ IS NULL OR created_timestamp < sqlc.narg(created_before))
+ORDER BY rank DESC, created_timestamp DESC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
+
+-- name: SearchPostsCount :one
+SELECT COUNT(*) FROM search_posts
+WHERE document @@ to_tsquery('english', sqlc.arg(query))
+AND ((type = 'thread' AND sqlc.arg(include_threads)::BOOLEAN) OR (type = 'comment' AND sqlc.arg(include_comments)::BOOLEAN))
+AND NOT EXISTS (
+    SELECT 1 FROM UNNEST(sqlc.arg(tags)::VARCHAR(35)[]) AS wanted(name)
+    WHERE NOT EXISTS (
+        SELECT 1 FROM thread_tags
+        JOIN tags ON tags.id = thread_tags.tag_id
+        LEFT JOIN tag_aliases ON tag_aliases.tag_id = tags.id AND LOWER(tag_aliases.alias) = LOWER(wanted.name)
+        WHERE thread_tags.thread_id = search_posts.thread_id
+        AND (LOWER(tags.name) = LOWER(wanted.name) OR tag_aliases.tag_id IS NOT NULL)
+    )
+)
+AND (sqlc.narg(creator_id)::UUID IS NULL OR creator_id = sqlc.narg(creator_id))
+AND (sqlc.narg(created_after)::TIMESTAMPTZ IS NULL OR created_timestamp >= sqlc.narg(created_after))
+AND (sqlc.narg(created_before)::TIMESTAMPTZ IS NULL OR created_timestamp < sqlc.narg(created_before));
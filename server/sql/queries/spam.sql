@@ -0,0 +1,128 @@
+-- name: GetSpamModel :one
+SELECT spam_samples, ham_samples, vocabulary, spam_token_count, ham_token_count FROM spam_model;
+
+-- name: AdjustSpamModel :exec
+UPDATE spam_model
+SET spam_samples = spam_samples + sqlc.arg(spam_samples)::BIGINT,
+ham_samples = ham_samples + sqlc.arg(ham_samples)::BIGINT,
+vocabulary = vocabulary + sqlc.arg(vocabulary)::BIGINT,
+spam_token_count = spam_token_count + sqlc.arg(spam_token_count)::BIGINT,
+ham_token_count = ham_token_count + sqlc.arg(ham_token_count)::BIGINT;
+
+-- The totals are counted again after retraining, in case they drifted from the samples and tokens
+-- name: RecountSpamModel :exec
+UPDATE spam_model
+SET spam_samples = samples.spam_samples,
+ham_samples = samples.ham_samples,
+vocabulary = tokens.vocabulary,
+spam_token_count = tokens.spam_token_count,
+ham_token_count = tokens.ham_token_count
+FROM (
+    SELECT COUNT(*) FILTER (WHERE spam) AS spam_samples, COUNT(*) FILTER (WHERE NOT spam) AS ham_samples
+    FROM spam_samples
+) AS samples, (
+    SELECT COUNT(*) AS vocabulary, COALESCE(SUM(spam_count), 0) AS spam_token_count, COALESCE(SUM(ham_count), 0) AS ham_token_count
+    FROM spam_tokens
+) AS tokens;
+
+-- name: GetSpamTokens :many
+SELECT * FROM spam_tokens
+WHERE token = ANY(sqlc.arg(tokens)::VARCHAR[]);
+
+-- Positive weights point towards spam, in the same way as the classifier
+-- name: GetSpamTokenWeights :many
+SELECT token, spam_count, ham_count, weight FROM (
+    SELECT spam_tokens.*, (
+        LN((spam_tokens.spam_count + 1)::FLOAT8 / (totals.spam_token_count + totals.vocabulary))
+        - LN((spam_tokens.ham_count + 1)::FLOAT8 / (totals.ham_token_count + totals.vocabulary))
+    )::FLOAT8 AS weight
+    FROM spam_tokens
+    CROSS JOIN spam_model AS totals
+) AS weights
+WHERE sqlc.narg(prefix)::VARCHAR IS NULL OR STARTS_WITH(token, sqlc.narg(prefix)::VARCHAR)
+ORDER BY
+CASE WHEN sqlc.arg(ham)::BOOLEAN THEN weight END ASC,
+CASE WHEN NOT sqlc.arg(ham)::BOOLEAN THEN weight END DESC,
+token ASC
+LIMIT sqlc.arg(result_limit) OFFSET sqlc.arg(result_offset);
+
+-- name: GetSpamTokenWeightsCount :one
+SELECT COUNT(*) FROM spam_tokens
+WHERE sqlc.narg(prefix)::VARCHAR IS NULL OR STARTS_WITH(token, sqlc.narg(prefix)::VARCHAR);
+
+-- Whether each token is new to the vocabulary is returned, since the row was inserted rather than updated
+-- name: AddSpamTokens :many
+INSERT INTO spam_tokens (token, spam_count, ham_count)
+SELECT token, sqlc.arg(spam)::BOOLEAN::INTEGER, (NOT sqlc.arg(spam)::BOOLEAN)::INTEGER
+FROM UNNEST(sqlc.arg(tokens)::VARCHAR[]) AS token
+ON CONFLICT (token) DO UPDATE
+SET spam_count = spam_tokens.spam_count + EXCLUDED.spam_count,
+ham_count = spam_tokens.ham_count + EXCLUDED.ham_count
+RETURNING (xmax = 0)::BOOLEAN AS inserted;
+
+-- name: RemoveSpamTokens :execrows
+UPDATE spam_tokens
+SET spam_count = spam_count - sqlc.arg(spam)::BOOLEAN::INTEGER,
+ham_count = ham_count - (NOT sqlc.arg(spam)::BOOLEAN)::INTEGER
+WHERE token = ANY(sqlc.arg(tokens)::VARCHAR[]);
+
+-- Tokens that are no longer in any sample are left out of the vocabulary
+-- name: DeleteUnusedSpamTokens :execrows
+DELETE FROM spam_tokens
+WHERE token = ANY(sqlc.arg(tokens)::VARCHAR[]) AND spam_count = 0 AND ham_count = 0;
+
+-- name: CreateSpamTokens :exec
+INSERT INTO spam_tokens (token, spam_count, ham_count)
+SELECT UNNEST(sqlc.arg(tokens)::VARCHAR[]), UNNEST(sqlc.arg(spam_counts)::INTEGER[]), UNNEST(sqlc.arg(ham_counts)::INTEGER[]);
+
+-- name: DeleteSpamTokens :exec
+DELETE FROM spam_tokens;
+
+-- The sample is created, or the existing sample of the thread is locked and returned unchanged so that its previous label can be taken out.
+-- Concurrent training of the same thread waits for the lock instead of creating a second sample
+-- name: ClaimThreadSpamSample :one
+INSERT INTO spam_samples (thread_id, content, spam, trainer_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (thread_id) DO UPDATE
+SET updated_timestamp = spam_samples.updated_timestamp
+RETURNING *, (xmax = 0)::BOOLEAN AS created;
+
+-- name: ClaimCommentSpamSample :one
+INSERT INTO spam_samples (comment_id, content, spam, trainer_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (comment_id) DO UPDATE
+SET updated_timestamp = spam_samples.updated_timestamp
+RETURNING *, (xmax = 0)::BOOLEAN AS created;
+
+-- name: GetSpamSamples :many
+SELECT content, spam FROM spam_samples;
+
+-- name: UpdateSpamSample :one
+UPDATE spam_samples
+SET content = $2, spam = $3, trainer_id = $4, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- Spam cases are resolved as spam unless they were dismissed, and removed content is already deleted.
+-- Only the latest case of each thread is used
+-- name: BackfillThreadSpamSamples :execrows
+INSERT INTO spam_samples (thread_id, content, spam, trainer_id)
+SELECT DISTINCT ON (threads.id) threads.id, threads.title || E'\n' || threads.content,
+report_cases.status <> 'dismissed', report_cases.resolver_id
+FROM report_cases
+JOIN threads ON threads.id = report_cases.thread_id
+WHERE report_cases.target_type = 'thread' AND report_cases.status <> 'open'
+AND EXISTS (SELECT 1 FROM reports WHERE reports.case_id = report_cases.id AND reports.reason = 'spam')
+ORDER BY threads.id, report_cases.resolved_timestamp DESC
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillCommentSpamSamples :execrows
+INSERT INTO spam_samples (comment_id, content, spam, trainer_id)
+SELECT DISTINCT ON (comments.id) comments.id, comments.content,
+report_cases.status <> 'dismissed', report_cases.resolver_id
+FROM report_cases
+JOIN comments ON comments.id = report_cases.comment_id
+WHERE report_cases.target_type = 'comment' AND report_cases.status <> 'open'
+AND EXISTS (SELECT 1 FROM reports WHERE reports.case_id = report_cases.id AND reports.reason = 'spam')
+ORDER BY comments.id, report_cases.resolved_timestamp DESC
+ON CONFLICT DO NOTHING;
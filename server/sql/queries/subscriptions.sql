@@ -0,0 +1,42 @@
+-- name: CreateThreadSubscription :one
+INSERT INTO thread_subscriptions (user_id, thread_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: AutoSubscribeThread :exec
+INSERT INTO thread_subscriptions (user_id, thread_id)
+SELECT users.id, sqlc.arg(thread_id)::INTEGER
+FROM users
+WHERE users.id = sqlc.arg(user_id)
+AND CASE WHEN sqlc.arg(created)::BOOLEAN THEN users.auto_watch_created ELSE users.auto_watch_commented END
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteThreadSubscription :one
+DELETE FROM thread_subscriptions
+WHERE user_id = $1 AND thread_id = $2
+RETURNING *;
+
+-- The comment is marked as notified in the same statement, so that it is only processed once from both the queue and a sweep.
+-- Held comments are skipped until they are released, and the comment can be deleted before the notifications are created
+-- name: NotifyCommentSubscribers :execrows
+WITH notified AS (
+    UPDATE comments SET subscribers_notified = TRUE
+    WHERE comments.id = sqlc.arg(comment_id) AND NOT comments.subscribers_notified AND NOT comments.held
+    RETURNING comments.id, comments.thread_id, comments.creator_id
+)
+INSERT INTO notifications (user_id, type, actor_id, thread_id, comment_id)
+SELECT thread_subscriptions.user_id, 'thread_reply', notified.creator_id, notified.thread_id, notified.id
+FROM notified
+INNER JOIN thread_subscriptions ON thread_subscriptions.thread_id = notified.thread_id
+WHERE thread_subscriptions.user_id <> notified.creator_id
+AND NOT EXISTS (
+    SELECT 1 FROM notifications
+    WHERE notifications.user_id = thread_subscriptions.user_id
+    AND notifications.comment_id = notified.id
+);
+
+-- name: GetUnnotifiedComments :many
+SELECT id FROM comments
+WHERE NOT subscribers_notified AND NOT held
+ORDER BY id
+LIMIT $1;
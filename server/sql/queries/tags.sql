@@ -0,0 +1,125 @@
+-- Held threads are left out of the counts, so that tags only used by held threads are not listed
+-- name: GetTags :many
+SELECT tags.*, COUNT(threads.id) AS count
+FROM tags
+LEFT JOIN thread_tags ON thread_tags.tag_id = tags.id
+LEFT JOIN threads ON threads.id = thread_tags.thread_id AND NOT threads.held
+AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR threads.created_timestamp >= sqlc.narg(since))
+WHERE STARTS_WITH(LOWER(tags.name), LOWER(sqlc.arg(prefix)))
+OR (sqlc.arg(prefix) <> '' AND EXISTS (
+    SELECT 1 FROM tag_aliases
+    WHERE tag_aliases.tag_id = tags.id
+    AND STARTS_WITH(LOWER(tag_aliases.alias), LOWER(sqlc.arg(prefix)))
+))
+GROUP BY tags.id
+HAVING COUNT(threads.id) > 0 OR (tags.canonical AND sqlc.narg(since)::TIMESTAMPTZ IS NULL)
+ORDER BY count DESC, LOWER(tags.name) ASC
+LIMIT sqlc.arg(tag_limit) OFFSET sqlc.arg(tag_offset);
+
+-- name: GetTagsCount :one
+SELECT COUNT(*) FROM (
+    SELECT tags.id
+    FROM tags
+    LEFT JOIN thread_tags ON thread_tags.tag_id = tags.id
+    LEFT JOIN threads ON threads.id = thread_tags.thread_id AND NOT threads.held
+    AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR threads.created_timestamp >= sqlc.narg(since))
+    WHERE STARTS_WITH(LOWER(tags.name), LOWER(sqlc.arg(prefix)))
+    OR (sqlc.arg(prefix) <> '' AND EXISTS (
+        SELECT 1 FROM tag_aliases
+        WHERE tag_aliases.tag_id = tags.id
+        AND STARTS_WITH(LOWER(tag_aliases.alias), LOWER(sqlc.arg(prefix)))
+    ))
+    GROUP BY tags.id
+    HAVING COUNT(threads.id) > 0 OR (tags.canonical AND sqlc.narg(since)::TIMESTAMPTZ IS NULL)
+) AS matching_tags;
+
+-- name: GetTagByName :one
+SELECT * FROM tags
+WHERE LOWER(name) = LOWER(sqlc.arg(name));
+
+-- name: GetTagThreadsCount :one
+SELECT COUNT(*) FROM thread_tags
+WHERE tag_id = $1;
+
+-- name: ResolveTag :one
+SELECT * FROM tags
+WHERE id = COALESCE(
+    (SELECT tag_id FROM tag_aliases WHERE LOWER(alias) = LOWER(sqlc.arg(name))),
+    (SELECT tags.id FROM tags WHERE LOWER(tags.name) = LOWER(sqlc.arg(name)))
+);
+
+-- name: CreateTag :one
+INSERT INTO tags (name, description, canonical, restricted)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpsertTag :one
+INSERT INTO tags (name)
+VALUES ($1)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET name = tags.name
+RETURNING *;
+
+-- name: UpdateTag :one
+UPDATE tags
+SET description = $2, restricted = $3, canonical = TRUE
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTag :exec
+DELETE FROM tags
+WHERE id = $1;
+
+-- name: GetTagAliases :many
+SELECT alias FROM tag_aliases
+WHERE tag_id = $1
+ORDER BY LOWER(alias) ASC;
+
+-- name: GetTagAlias :one
+SELECT * FROM tag_aliases
+WHERE LOWER(alias) = LOWER(sqlc.arg(alias));
+
+-- name: CreateTagAlias :one
+INSERT INTO tag_aliases (alias, tag_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: DeleteTagAlias :one
+DELETE FROM tag_aliases
+WHERE LOWER(alias) = LOWER(sqlc.arg(alias)) AND tag_id = sqlc.arg(tag_id)
+RETURNING *;
+
+-- name: MoveTagAliases :exec
+UPDATE tag_aliases
+SET tag_id = sqlc.arg(to_tag_id)
+WHERE tag_id = sqlc.arg(from_tag_id);
+
+-- name: MoveThreadTags :exec
+INSERT INTO thread_tags (thread_id, tag_id, position)
+SELECT old_thread_tags.thread_id, sqlc.arg(to_tag_id), old_thread_tags.position
+FROM thread_tags AS old_thread_tags
+WHERE old_thread_tags.tag_id = sqlc.arg(from_tag_id)
+ON CONFLICT DO NOTHING;
+
+-- name: MoveCategoryRequiredTags :exec
+INSERT INTO category_required_tags (category_id, tag_id)
+SELECT old_category_required_tags.category_id, sqlc.arg(to_tag_id)
+FROM category_required_tags AS old_category_required_tags
+WHERE old_category_required_tags.tag_id = sqlc.arg(from_tag_id)
+ON CONFLICT DO NOTHING;
+
+-- name: MergeTagRestricted :one
+UPDATE tags
+SET restricted = restricted OR sqlc.arg(restricted)::BOOLEAN
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateThreadTag :exec
+INSERT INTO thread_tags (thread_id, tag_id, position)
+VALUES ($1, $2, $3);
+
+-- name: GetThreadsTags :many
+SELECT thread_tags.thread_id, tags.name
+FROM thread_tags
+JOIN tags ON tags.id = thread_tags.tag_id
+WHERE thread_tags.thread_id = ANY(sqlc.arg(thread_ids)::INTEGER[])
+ORDER BY thread_tags.thread_id, thread_tags.position;
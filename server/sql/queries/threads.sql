@@ -1,6 +1,6 @@
 -- name: CreateThread :one
-INSERT INTO threads (title, content, tags, creator_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO threads (title, content, content_html, creator_id, category_id, held)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetThread :one
@@ -11,25 +11,105 @@ WHERE id = $1;
 SELECT creator_id FROM threads
 WHERE id = $1;
 
+-- name: AcceptThreadComment :one
+UPDATE threads
+SET accepted_comment_id = sqlc.arg(comment_id)::INTEGER
+WHERE id = sqlc.arg(id) AND accepted_comment_id IS DISTINCT FROM sqlc.arg(comment_id)::INTEGER
+RETURNING *;
+
+-- name: UnacceptThreadComment :one
+UPDATE threads
+SET accepted_comment_id = NULL
+WHERE id = sqlc.arg(id) AND accepted_comment_id = sqlc.arg(comment_id)::INTEGER
+RETURNING *;
+
+-- Edits can hold the thread, but only moderators can release it
 -- name: UpdateThreadContent :one
 UPDATE threads
-SET content = $2, updated_timestamp = CURRENT_TIMESTAMP
+SET content = $2, content_html = $3, updated_timestamp = CURRENT_TIMESTAMP, last_activity_timestamp = CURRENT_TIMESTAMP,
+held = held OR $4
 WHERE id = $1
-RETURNING content, updated_timestamp;
+RETURNING title, content, content_html, updated_timestamp, held;
 
 -- name: DeleteThread :one
 DELETE FROM threads
 WHERE id = $1
 RETURNING *;
 
--- name: GetThreadsPaginated :many
-SELECT * FROM threads
-WHERE ARRAY(SELECT LOWER(t) FROM UNNEST(tags) AS t) @> 
-ARRAY(SELECT LOWER(t) FROM UNNEST($1::VARCHAR(35)[]) AS t)
-ORDER BY updated_timestamp DESC 
-LIMIT $2 OFFSET $3;
-
--- name: GetThreadsPaginatedCount :one
-SELECT COUNT(*) FROM threads
-WHERE ARRAY(SELECT LOWER(t) FROM UNNEST(tags) AS t) @> 
-ARRAY(SELECT LOWER(t) FROM UNNEST($1::VARCHAR(35)[]) AS t);
\ No newline at end of file
+-- name: UpdateThreadScore :exec
+UPDATE threads
+SET score = score + sqlc.arg(delta)::INTEGER,
+hot_rank = LOG(GREATEST(score + sqlc.arg(delta)::INTEGER, 1)) + EXTRACT(EPOCH FROM created_timestamp) / 45000
+WHERE id = sqlc.arg(id);
+
+-- name: AddThreadComment :exec
+UPDATE threads
+SET comment_count = comment_count + 1,
+last_commenter_id = sqlc.arg(commenter_id)::UUID,
+last_activity_timestamp = sqlc.arg(commented_timestamp)
+WHERE id = sqlc.arg(id);
+
+-- Held comments are left out, since they were never added to the thread
+-- name: RefreshThreadComments :exec
+UPDATE threads
+SET comment_count = (SELECT COUNT(*) FROM comments WHERE thread_id = threads.id AND NOT held),
+last_commenter_id = (
+    SELECT creator_id FROM comments
+    WHERE thread_id = threads.id AND NOT held
+    ORDER BY comments.created_timestamp DESC, comments.id DESC
+    LIMIT 1
+),
+last_activity_timestamp = GREATEST(updated_timestamp, COALESCE(
+    (SELECT MAX(created_timestamp) FROM comments WHERE thread_id = threads.id AND NOT held), updated_timestamp
+))
+WHERE threads.id = $1;
+
+-- name: ReleaseThread :one
+UPDATE threads
+SET held = FALSE
+WHERE id = $1 AND held
+RETURNING *;
+
+-- name: GetThreadState :one
+SELECT locked, archived, held, creator_id FROM threads
+WHERE id = $1;
+
+-- name: LockThreadState :one
+SELECT locked, archived FROM threads
+WHERE id = $1
+FOR NO KEY UPDATE;
+
+-- name: GetCommentThreadState :one
+SELECT threads.locked, threads.archived, threads.held, threads.creator_id,
+comments.held AS comment_held, comments.creator_id AS comment_creator_id
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE comments.id = $1;
+
+-- name: UpdateThreadState :one
+UPDATE threads
+SET pinned = sqlc.arg(pinned), locked = sqlc.arg(locked), archived = sqlc.arg(archived),
+last_activity_timestamp = CASE
+    WHEN archived AND NOT sqlc.arg(archived)::BOOLEAN THEN CURRENT_TIMESTAMP
+    ELSE last_activity_timestamp
+END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ArchiveInactiveThreads :execrows
+UPDATE threads
+SET archived = TRUE
+WHERE NOT archived AND NOT pinned
+AND last_activity_timestamp < sqlc.arg(cutoff);
+
+
+-- name: GetThreadsWithoutContentHTML :many
+SELECT id, content FROM threads
+WHERE id > sqlc.arg(after_id) AND content_html = '' AND content <> ''
+ORDER BY id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateThreadContentHTML :exec
+UPDATE threads
+SET content_html = $2
+WHERE id = $1;
\ No newline at end of file
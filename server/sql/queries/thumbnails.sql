@@ -0,0 +1,45 @@
+-- name: GetPendingThumbnailBlobs :many
+SELECT sha256 FROM blobs
+WHERE thumbnail_status = 'pending'
+ORDER BY uploaded_timestamp
+LIMIT $1;
+
+-- name: GetThumbnailSource :one
+SELECT content_type, thumbnail_status FROM blobs
+WHERE sha256 = $1;
+
+-- name: CreateBlobThumbnail :exec
+INSERT INTO blob_thumbnails (source_sha256, size, thumbnail_sha256, width, height)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (source_sha256, size) DO UPDATE
+SET thumbnail_sha256 = EXCLUDED.thumbnail_sha256,
+width = EXCLUDED.width,
+height = EXCLUDED.height;
+
+-- name: SetThumbnailStatus :exec
+UPDATE blobs SET thumbnail_status = $2
+WHERE sha256 = $1;
+
+-- The image is left pending for the next sweep until it runs out of attempts, after which it is marked as failed
+-- name: RecordThumbnailAttempt :one
+UPDATE blobs
+SET thumbnail_attempts = thumbnail_attempts + 1,
+thumbnail_status = CASE WHEN thumbnail_attempts + 1 >= sqlc.arg(max_attempts)::INTEGER THEN 'failed' ELSE thumbnail_status END
+WHERE sha256 = sqlc.arg(sha256) AND thumbnail_status = 'pending'
+RETURNING thumbnail_status;
+
+-- name: GetBlobThumbnails :many
+SELECT blob_thumbnails.size, blob_thumbnails.width, blob_thumbnails.height
+FROM blob_thumbnails
+INNER JOIN blobs ON blobs.sha256 = blob_thumbnails.source_sha256
+WHERE blob_thumbnails.source_sha256 = $1 AND blobs.thumbnail_status = 'ready'
+ORDER BY blob_thumbnails.size;
+
+-- name: GetAttachmentThumbnail :one
+SELECT blobs.sha256, blobs.size, blobs.content_type
+FROM attachments
+INNER JOIN blobs AS sources ON sources.sha256 = attachments.blob_sha256
+INNER JOIN blob_thumbnails ON blob_thumbnails.source_sha256 = sources.sha256
+INNER JOIN blobs ON blobs.sha256 = blob_thumbnails.thumbnail_sha256
+WHERE attachments.id = sqlc.arg(attachment_id) AND blob_thumbnails.size = sqlc.arg(size)
+AND sources.thumbnail_status = 'ready';
@@ -1,16 +1,50 @@
 -- name: CreateUser :one
 INSERT INTO users (id, username, password)
 VALUES ($1, $2, $3)
-RETURNING id, username;
+RETURNING id, username, role;
 
--- name: GetUserID :one
-SELECT id FROM users
+-- name: GetUserBanned :one
+SELECT (banned_until IS NOT NULL AND banned_until > CURRENT_TIMESTAMP)::BOOLEAN AS banned FROM users
 WHERE id = $1;
 
 -- name: GetUserIDAndPassHash :one
-SELECT id, password FROM users
+SELECT id, password, (banned_until IS NOT NULL AND banned_until > CURRENT_TIMESTAMP)::BOOLEAN AS banned FROM users
 WHERE username = $1;
 
 -- name: GetUserInfo :one
-SELECT id, username FROM users
-WHERE id = $1;
\ No newline at end of file
+SELECT id, username, role FROM users
+WHERE id = $1;
+
+-- Users backfilled as '-infinity' cannot be scanned, so they are treated as created at the Unix epoch instead
+-- name: GetUserCreatedTimestamp :one
+SELECT GREATEST(created_timestamp, 'epoch'::TIMESTAMPTZ)::TIMESTAMPTZ AS created_timestamp FROM users
+WHERE id = $1;
+
+-- name: GetUserRole :one
+SELECT role FROM users
+WHERE id = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, role;
+
+-- name: BanUser :exec
+UPDATE users
+SET banned_until = CASE
+    WHEN sqlc.narg(ban_days)::INTEGER IS NULL THEN 'infinity'
+    ELSE CURRENT_TIMESTAMP + MAKE_INTERVAL(days => sqlc.narg(ban_days)::INTEGER)
+END
+WHERE id = sqlc.arg(id);
+
+-- name: GetUserSettings :one
+SELECT auto_watch_created, auto_watch_commented FROM users
+WHERE id = $1;
+
+-- name: UpdateUserSettings :one
+UPDATE users
+SET auto_watch_created = COALESCE(sqlc.narg(auto_watch_created), auto_watch_created),
+auto_watch_commented = COALESCE(sqlc.narg(auto_watch_commented), auto_watch_commented)
+WHERE id = sqlc.arg(id)
+RETURNING auto_watch_created, auto_watch_commented;
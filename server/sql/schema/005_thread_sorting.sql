@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE threads
+ADD COLUMN score INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN hot_rank DOUBLE PRECISION NOT NULL DEFAULT (EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) / 45000);
+
+UPDATE threads
+SET score = (SELECT COUNT(*) FROM thread_reactions WHERE thread_id = threads.id);
+
+UPDATE threads
+SET hot_rank = LOG(GREATEST(score, 1)) + EXTRACT(EPOCH FROM created_timestamp) / 45000;
+
+CREATE INDEX threads_created_timestamp_idx ON threads (created_timestamp DESC, id DESC);
+CREATE INDEX threads_score_idx ON threads (score DESC, id DESC);
+CREATE INDEX threads_hot_rank_idx ON threads (hot_rank DESC, id DESC);
+CREATE INDEX comments_thread_id_created_timestamp_idx ON comments (thread_id, created_timestamp);
+
+-- +goose Down
+DROP INDEX comments_thread_id_created_timestamp_idx;
+DROP INDEX threads_hot_rank_idx;
+DROP INDEX threads_score_idx;
+DROP INDEX threads_created_timestamp_idx;
+
+ALTER TABLE threads
+DROP COLUMN hot_rank,
+DROP COLUMN score;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE threads
+ADD COLUMN last_activity_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ADD COLUMN comment_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN last_commenter_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE threads
+SET comment_count = (SELECT COUNT(*) FROM comments WHERE thread_id = threads.id),
+last_commenter_id = (
+    SELECT creator_id FROM comments
+    WHERE thread_id = threads.id
+    ORDER BY comments.created_timestamp DESC, comments.id DESC
+    LIMIT 1
+),
+last_activity_timestamp = GREATEST(updated_timestamp, COALESCE(
+    (SELECT MAX(created_timestamp) FROM comments WHERE thread_id = threads.id), updated_timestamp
+));
+
+CREATE INDEX threads_last_activity_timestamp_idx ON threads (last_activity_timestamp DESC, id DESC);
+
+-- +goose Down
+DROP INDEX threads_last_activity_timestamp_idx;
+
+ALTER TABLE threads
+DROP COLUMN last_commenter_id,
+DROP COLUMN comment_count,
+DROP COLUMN last_activity_timestamp;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE threads
+ADD COLUMN search_vector TSVECTOR NOT NULL GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', content), 'B')
+) STORED;
+
+ALTER TABLE comments
+ADD COLUMN search_vector TSVECTOR NOT NULL GENERATED ALWAYS AS (
+    to_tsvector('english', content)
+) STORED;
+
+CREATE INDEX threads_search_vector_idx ON threads USING GIN (search_vector);
+CREATE INDEX comments_search_vector_idx ON comments USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX comments_search_vector_idx;
+DROP INDEX threads_search_vector_idx;
+
+ALTER TABLE comments DROP COLUMN search_vector;
+ALTER TABLE threads DROP COLUMN search_vector;
@@ -0,0 +1,65 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(35) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    canonical BOOLEAN NOT NULL DEFAULT FALSE,
+    restricted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX tags_name_idx ON tags (LOWER(name));
+
+CREATE TABLE tag_aliases (
+    alias VARCHAR(35) NOT NULL,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX tag_aliases_alias_idx ON tag_aliases (LOWER(alias));
+CREATE INDEX tag_aliases_tag_id_idx ON tag_aliases (tag_id);
+
+CREATE TABLE thread_tags (
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    PRIMARY KEY (thread_id, tag_id)
+);
+
+CREATE INDEX thread_tags_tag_id_idx ON thread_tags (tag_id, thread_id);
+
+INSERT INTO tags (name)
+SELECT MODE() WITHIN GROUP (ORDER BY tag)
+FROM threads, UNNEST(tags) AS tag
+GROUP BY LOWER(tag);
+
+INSERT INTO thread_tags (thread_id, tag_id, position)
+SELECT DISTINCT ON (threads.id, tags.id) threads.id, tags.id, thread_tag.position
+FROM threads
+CROSS JOIN LATERAL UNNEST(threads.tags) WITH ORDINALITY AS thread_tag(name, position)
+JOIN tags ON LOWER(tags.name) = LOWER(thread_tag.name)
+ORDER BY threads.id, tags.id, thread_tag.position;
+
+ALTER TABLE threads DROP COLUMN tags;
+
+-- +goose Down
+ALTER TABLE threads ADD COLUMN tags VARCHAR(35)[] NOT NULL DEFAULT '{}';
+
+UPDATE threads
+SET tags = ARRAY(
+    SELECT tags.name FROM thread_tags
+    JOIN tags ON tags.id = thread_tags.tag_id
+    WHERE thread_tags.thread_id = threads.id
+    ORDER BY thread_tags.position
+);
+
+ALTER TABLE threads ALTER COLUMN tags DROP DEFAULT;
+
+DROP TABLE thread_tags;
+DROP TABLE tag_aliases;
+DROP TABLE tags;
+
+ALTER TABLE users DROP COLUMN role;
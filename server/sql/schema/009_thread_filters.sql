@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX threads_creator_id_idx ON threads (creator_id, created_timestamp DESC);
+
+-- +goose Down
+DROP INDEX threads_creator_id_idx;
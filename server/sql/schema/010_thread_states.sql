@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE threads
+ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+DROP INDEX threads_created_timestamp_idx;
+DROP INDEX threads_last_activity_timestamp_idx;
+DROP INDEX threads_score_idx;
+DROP INDEX threads_hot_rank_idx;
+
+CREATE INDEX threads_created_timestamp_idx ON threads (pinned DESC, created_timestamp DESC, id DESC);
+CREATE INDEX threads_last_activity_timestamp_idx ON threads (pinned DESC, last_activity_timestamp DESC, id DESC);
+CREATE INDEX threads_score_idx ON threads (pinned DESC, score DESC, id DESC);
+CREATE INDEX threads_hot_rank_idx ON threads (pinned DESC, hot_rank DESC, id DESC);
+
+-- +goose Down
+DROP INDEX threads_hot_rank_idx;
+DROP INDEX threads_score_idx;
+DROP INDEX threads_last_activity_timestamp_idx;
+DROP INDEX threads_created_timestamp_idx;
+
+CREATE INDEX threads_created_timestamp_idx ON threads (created_timestamp DESC, id DESC);
+CREATE INDEX threads_last_activity_timestamp_idx ON threads (last_activity_timestamp DESC, id DESC);
+CREATE INDEX threads_score_idx ON threads (score DESC, id DESC);
+CREATE INDEX threads_hot_rank_idx ON threads (hot_rank DESC, id DESC);
+
+ALTER TABLE threads
+DROP COLUMN archived,
+DROP COLUMN locked,
+DROP COLUMN pinned;
@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT,
+    post_role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (post_role IN ('user', 'moderator', 'admin')),
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id, position);
+
+CREATE TABLE category_required_tags (
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (category_id, tag_id)
+);
+
+INSERT INTO categories (slug, name)
+VALUES ('general', 'General');
+
+ALTER TABLE threads
+ADD COLUMN category_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT;
+
+UPDATE threads
+SET category_id = (SELECT id FROM categories WHERE slug = 'general');
+
+ALTER TABLE threads
+ALTER COLUMN category_id SET NOT NULL;
+
+CREATE INDEX threads_category_id_idx ON threads (category_id, pinned DESC, last_activity_timestamp DESC, id DESC);
+
+-- +goose Down
+DROP INDEX threads_category_id_idx;
+
+ALTER TABLE threads
+DROP COLUMN category_id;
+
+DROP TABLE category_required_tags;
+DROP TABLE categories;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- The rendered HTML of existing content is filled in by the server on startup
+ALTER TABLE threads
+ADD COLUMN content_html TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comments
+ADD COLUMN content_html TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE comments
+DROP COLUMN content_html;
+
+ALTER TABLE threads
+DROP COLUMN content_html;
@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE comments
+ADD COLUMN parent_id INTEGER REFERENCES comments(id) ON DELETE SET NULL;
+
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
+
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('mention', 'thread_reply', 'comment_reply')),
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, id DESC);
+CREATE INDEX notifications_unread_idx ON notifications (user_id) WHERE NOT read;
+
+-- Users are only notified once for each mention, even if the content is edited
+CREATE UNIQUE INDEX notifications_mention_idx ON notifications (user_id, thread_id, COALESCE(comment_id, 0))
+WHERE type = 'mention';
+
+-- +goose Down
+DROP TABLE notifications;
+
+DROP INDEX comments_parent_id_idx;
+
+ALTER TABLE comments
+DROP COLUMN parent_id;
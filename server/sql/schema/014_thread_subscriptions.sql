@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE thread_subscriptions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, thread_id)
+);
+
+CREATE INDEX thread_subscriptions_thread_id_idx ON thread_subscriptions (thread_id);
+
+ALTER TABLE users
+ADD COLUMN auto_watch_created BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN auto_watch_commented BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Comments whose subscribers have not been notified yet, so that they are picked up again after a full queue or a restart.
+-- Existing comments were already notified, so only comments created from now on start out as not notified
+ALTER TABLE comments
+ADD COLUMN subscribers_notified BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE comments
+ALTER COLUMN subscribers_notified SET DEFAULT FALSE;
+
+CREATE INDEX comments_subscribers_pending_idx ON comments (id) WHERE NOT subscribers_notified;
+
+-- Authors were previously always notified of new comments on their threads
+INSERT INTO thread_subscriptions (user_id, thread_id)
+SELECT creator_id, id FROM threads;
+
+-- +goose Down
+ALTER TABLE comments
+DROP COLUMN subscribers_notified;
+
+ALTER TABLE users
+DROP COLUMN auto_watch_created,
+DROP COLUMN auto_watch_commented;
+
+DROP TABLE thread_subscriptions;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    note TEXT NOT NULL DEFAULT '',
+    folder VARCHAR(50) NOT NULL DEFAULT '',
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Comment bookmarks also store the thread of the comment, so thread bookmarks are those without a comment
+CREATE UNIQUE INDEX bookmarks_thread_idx ON bookmarks (user_id, thread_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX bookmarks_comment_idx ON bookmarks (user_id, comment_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX bookmarks_user_id_idx ON bookmarks (user_id, folder, id DESC);
+
+-- +goose Down
+DROP TABLE bookmarks;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE thread_reads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    read_comment_count INTEGER NOT NULL DEFAULT 0,
+    -- The position of the last read comment, which is kept even if the comment is deleted
+    last_read_comment_id INTEGER,
+    last_read_comment_timestamp TIMESTAMPTZ,
+    read_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, thread_id)
+);
+
+-- +goose Down
+DROP TABLE thread_reads;
@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE polls (
+    id SERIAL PRIMARY KEY,
+    thread_id INTEGER NOT NULL UNIQUE REFERENCES threads(id) ON DELETE CASCADE,
+    question VARCHAR(255) NOT NULL,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    hide_results BOOLEAN NOT NULL DEFAULT FALSE,
+    closes_timestamp TIMESTAMPTZ,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Results can only be hidden until the poll closes
+    CHECK (NOT hide_results OR closes_timestamp IS NOT NULL)
+);
+
+CREATE TABLE poll_options (
+    id SERIAL PRIMARY KEY,
+    poll_id INTEGER NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text VARCHAR(100) NOT NULL,
+    UNIQUE (poll_id, position),
+    UNIQUE (poll_id, id)
+);
+
+-- Each user has a single ballot for each poll, which holds one or more votes depending on the poll
+CREATE TABLE poll_ballots (
+    poll_id INTEGER NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE TABLE poll_votes (
+    poll_id INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    option_id INTEGER NOT NULL,
+    PRIMARY KEY (poll_id, user_id, option_id),
+    FOREIGN KEY (poll_id, user_id) REFERENCES poll_ballots(poll_id, user_id) ON DELETE CASCADE,
+    -- Votes can only be for the options of the same poll
+    FOREIGN KEY (poll_id, option_id) REFERENCES poll_options(poll_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_option_id_idx ON poll_votes (option_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_ballots;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- The stored files, identified by the SHA-256 hash of their content so that the same file is only stored once
+CREATE TABLE blobs (
+    sha256 CHAR(64) PRIMARY KEY,
+    size INTEGER NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    uploaded_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    blob_sha256 CHAR(64) NOT NULL REFERENCES blobs(sha256),
+    uploader_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX attachments_blob_sha256_idx ON attachments (blob_sha256);
+
+-- The threads and comments whose content links to the attachments, which keep them from being garbage collected
+CREATE TABLE attachment_references (
+    attachment_id INTEGER NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX attachment_references_thread_idx ON attachment_references (attachment_id, thread_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX attachment_references_comment_idx ON attachment_references (attachment_id, comment_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX attachment_references_thread_id_idx ON attachment_references (thread_id);
+CREATE INDEX attachment_references_comment_id_idx ON attachment_references (comment_id);
+
+-- +goose Down
+DROP TABLE attachment_references;
+DROP TABLE attachments;
+DROP TABLE blobs;
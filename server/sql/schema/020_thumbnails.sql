@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE blobs
+ADD COLUMN thumbnail_status VARCHAR(10) NOT NULL DEFAULT 'none'
+CHECK (thumbnail_status IN ('none', 'pending', 'ready', 'failed'));
+
+-- The number of times generating the thumbnails failed for a reason other than the image, such as the store being down
+ALTER TABLE blobs
+ADD COLUMN thumbnail_attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX blobs_thumbnail_pending_idx ON blobs (uploaded_timestamp) WHERE thumbnail_status = 'pending';
+
+-- The thumbnails are blobs themselves, so that they are stored and garbage collected in the same way
+CREATE TABLE blob_thumbnails (
+    source_sha256 CHAR(64) NOT NULL REFERENCES blobs(sha256) ON DELETE CASCADE,
+    size INTEGER NOT NULL,
+    thumbnail_sha256 CHAR(64) NOT NULL REFERENCES blobs(sha256),
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    PRIMARY KEY (source_sha256, size)
+);
+
+CREATE INDEX blob_thumbnails_thumbnail_sha256_idx ON blob_thumbnails (thumbnail_sha256);
+
+-- Images uploaded before thumbnails existed get them as well
+UPDATE blobs SET thumbnail_status = 'pending'
+WHERE content_type LIKE 'image/%';
+
+-- +goose Down
+DROP TABLE blob_thumbnails;
+ALTER TABLE blobs DROP COLUMN thumbnail_attempts;
+ALTER TABLE blobs DROP COLUMN thumbnail_status;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE threads
+ADD COLUMN accepted_comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL;
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_type_check,
+ADD CONSTRAINT notifications_type_check CHECK (type IN ('mention', 'thread_reply', 'comment_reply', 'answer_accepted'));
+
+-- +goose Down
+DELETE FROM notifications
+WHERE type = 'answer_accepted';
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_type_check,
+ADD CONSTRAINT notifications_type_check CHECK (type IN ('mention', 'thread_reply', 'comment_reply'));
+
+ALTER TABLE threads
+DROP COLUMN accepted_comment_id;
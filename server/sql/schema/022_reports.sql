@@ -0,0 +1,65 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN banned_until TIMESTAMPTZ;
+
+-- Multiple reports of the same thread, comment or user are grouped into one case until it is resolved
+CREATE TABLE report_cases (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(10) NOT NULL CHECK (target_type IN ('thread', 'comment', 'user')),
+    thread_id INTEGER REFERENCES threads(id) ON DELETE SET NULL,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    target_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- The reported thread or comment as it was when the case was opened, so that it can be reviewed even after it is deleted
+    target_title TEXT NOT NULL DEFAULT '',
+    target_content TEXT NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'removed', 'warned', 'banned')),
+    report_count INTEGER NOT NULL DEFAULT 0,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolver_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolution_note TEXT NOT NULL DEFAULT '',
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_timestamp TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX report_cases_open_thread_idx ON report_cases (thread_id)
+WHERE status = 'open' AND target_type = 'thread';
+CREATE UNIQUE INDEX report_cases_open_comment_idx ON report_cases (comment_id)
+WHERE status = 'open' AND target_type = 'comment';
+CREATE UNIQUE INDEX report_cases_open_user_idx ON report_cases (target_user_id)
+WHERE status = 'open' AND target_type = 'user';
+CREATE INDEX report_cases_status_idx ON report_cases (status, created_timestamp);
+
+CREATE TABLE reports (
+    id SERIAL PRIMARY KEY,
+    case_id INTEGER NOT NULL REFERENCES report_cases(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'explicit', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (case_id, reporter_id)
+);
+
+-- Notifications about reports can be about users or deleted content, which have no thread
+ALTER TABLE notifications
+ALTER COLUMN thread_id DROP NOT NULL,
+ADD COLUMN case_id INTEGER REFERENCES report_cases(id) ON DELETE CASCADE,
+DROP CONSTRAINT notifications_type_check,
+ADD CONSTRAINT notifications_type_check CHECK (type IN ('mention', 'thread_reply', 'comment_reply', 'answer_accepted', 'report_resolved', 'warning'));
+
+-- +goose Down
+DELETE FROM notifications
+WHERE type IN ('report_resolved', 'warning') OR thread_id IS NULL;
+
+ALTER TABLE notifications
+DROP CONSTRAINT notifications_type_check,
+ADD CONSTRAINT notifications_type_check CHECK (type IN ('mention', 'thread_reply', 'comment_reply', 'answer_accepted')),
+DROP COLUMN case_id,
+ALTER COLUMN thread_id SET NOT NULL;
+
+DROP TABLE reports;
+
+DROP TABLE report_cases;
+
+ALTER TABLE users
+DROP COLUMN banned_until;
@@ -0,0 +1,63 @@
+-- +goose Up
+-- Existing users are backfilled with their first post, or as infinitely old if they never posted,
+-- so that they are not treated as new accounts
+ALTER TABLE users
+ADD COLUMN created_timestamp TIMESTAMPTZ;
+
+UPDATE users
+SET created_timestamp = COALESCE(LEAST(
+    (SELECT MIN(created_timestamp) FROM threads WHERE threads.creator_id = users.id),
+    (SELECT MIN(created_timestamp) FROM comments WHERE comments.creator_id = users.id)
+), '-infinity');
+
+ALTER TABLE users
+ALTER COLUMN created_timestamp SET DEFAULT CURRENT_TIMESTAMP,
+ALTER COLUMN created_timestamp SET NOT NULL;
+
+-- Held threads and comments are hidden until their report case is dismissed or resolved with a warning
+ALTER TABLE threads
+ADD COLUMN held BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE comments
+ADD COLUMN held BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE automod_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    pattern TEXT NOT NULL DEFAULT '',
+    words TEXT[] NOT NULL DEFAULT '{}',
+    max_links INTEGER,
+    max_account_age_hours INTEGER,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('reject', 'hold', 'replace', 'report')),
+    message VARCHAR(255) NOT NULL DEFAULT '',
+    replacement VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Reports created by auto-moderation have no reporter
+ALTER TABLE reports
+ALTER COLUMN reporter_id DROP NOT NULL,
+DROP CONSTRAINT reports_reason_check,
+ADD CONSTRAINT reports_reason_check CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'explicit', 'misinformation', 'other', 'automod'));
+
+-- +goose Down
+DELETE FROM reports
+WHERE reporter_id IS NULL OR reason = 'automod';
+
+ALTER TABLE reports
+DROP CONSTRAINT reports_reason_check,
+ADD CONSTRAINT reports_reason_check CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'explicit', 'misinformation', 'other')),
+ALTER COLUMN reporter_id SET NOT NULL;
+
+DROP TABLE automod_rules;
+
+ALTER TABLE comments
+DROP COLUMN held;
+
+ALTER TABLE threads
+DROP COLUMN held;
+
+ALTER TABLE users
+DROP COLUMN created_timestamp;
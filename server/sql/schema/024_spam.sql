@@ -0,0 +1,45 @@
+-- +goose Up
+-- The content that moderators marked as spam or ham, which the classifier can be retrained from.
+-- The content is copied, since spam is usually deleted afterwards
+CREATE TABLE spam_samples (
+    id SERIAL PRIMARY KEY,
+    thread_id INTEGER REFERENCES threads(id) ON DELETE SET NULL,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    spam BOOLEAN NOT NULL,
+    trainer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (thread_id IS NULL OR comment_id IS NULL)
+);
+
+-- Each thread or comment is only trained on once, with the latest label
+CREATE UNIQUE INDEX spam_samples_thread_id_idx ON spam_samples (thread_id);
+CREATE UNIQUE INDEX spam_samples_comment_id_idx ON spam_samples (comment_id);
+
+-- The number of spam and ham samples that have each token
+CREATE TABLE spam_tokens (
+    token VARCHAR(64) PRIMARY KEY,
+    spam_count INTEGER NOT NULL DEFAULT 0 CHECK (spam_count >= 0),
+    ham_count INTEGER NOT NULL DEFAULT 0 CHECK (ham_count >= 0)
+);
+
+-- The totals of the classifier, which are kept up to date when training so that classifying does not count every sample and token.
+-- There is always exactly one row
+CREATE TABLE spam_model (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    spam_samples BIGINT NOT NULL DEFAULT 0,
+    ham_samples BIGINT NOT NULL DEFAULT 0,
+    vocabulary BIGINT NOT NULL DEFAULT 0,
+    spam_token_count BIGINT NOT NULL DEFAULT 0,
+    ham_token_count BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO spam_model DEFAULT VALUES;
+
+-- +goose Down
+DROP TABLE spam_model;
+
+DROP TABLE spam_tokens;
+
+DROP TABLE spam_samples;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- The threads and comments that can be searched, using the generated search_vector columns of 007_search.sql.
+-- Held threads and comments are left out, as well as comments in held threads
+CREATE VIEW search_posts AS
+SELECT 'thread'::TEXT AS type, threads.id AS thread_id, NULL::INTEGER AS comment_id,
+threads.title, threads.content, threads.creator_id, threads.created_timestamp,
+threads.search_vector AS document
+FROM threads
+WHERE NOT threads.held
+UNION ALL
+SELECT 'comment'::TEXT AS type, comments.thread_id, comments.id AS comment_id,
+threads.title, comments.content, comments.creator_id, comments.created_timestamp,
+comments.search_vector AS document
+FROM comments
+JOIN threads ON threads.id = comments.thread_id
+WHERE NOT comments.held AND NOT threads.held;
+
+-- +goose Down
+DROP VIEW search_posts;
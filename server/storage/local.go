@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/*
+Stores the blobs as files in a directory on the local filesystem.
+The blobs are spread across subdirectories named after the first two characters of their keys,
+so that no single directory gets too large.
+*/
+type LocalStore struct {
+	dir string
+}
+
+/*
+This function creates a local store in the given directory, creating the directory if it does not exist.
+*/
+func NewLocalStore(dir string) (*LocalStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{dir: dir}, nil
+}
+
+/*
+This function writes the blob into a temporary file first and then renames it,
+so that a partially written blob is never read.
+*/
+func (store *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (store *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return file, err
+}
+
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+/*
+This function gets the path of the file for the key, rejecting anything that is not a valid key.
+*/
+func (store *LocalStore) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", errInvalidKey
+	}
+
+	return filepath.Join(store.dir, key[:2], key), nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The hash of an empty payload, which is signed for requests without a body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+/*
+Stores the blobs as objects in a bucket of an S3 compatible service.
+Requests are signed with AWS Signature Version 4 and use path-style URLs,
+so that services such as MinIO work without any DNS setup for the bucket.
+*/
+type S3Store struct {
+	endpoint *url.URL
+	bucket   string
+	signer   sigV4Signer
+	client   *http.Client
+}
+
+/*
+This function creates an S3 store for the bucket at the endpoint, such as 'https://s3.amazonaws.com'
+or 'http://localhost:9000' for a local MinIO. The bucket must already exist.
+*/
+func NewS3Store(endpoint, bucket, region, accessKey, secretKey string) (*S3Store, error) {
+	if endpoint == "" || bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint '%s'", endpoint)
+	}
+
+	return &S3Store{
+		endpoint: u,
+		bucket:   bucket,
+		signer: sigV4Signer{
+			accessKey: accessKey,
+			secretKey: secretKey,
+			region:    region,
+			service:   "s3",
+		},
+		client: &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (store *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	payloadHash := sha256.Sum256(data)
+
+	res, err := store.do(ctx, http.MethodPut, key, data, hex.EncodeToString(payloadHash[:]), contentType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+func (store *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := store.do(ctx, http.MethodGet, key, nil, emptyPayloadHash, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+
+	return res.Body, nil
+}
+
+func (store *S3Store) Delete(ctx context.Context, key string) error {
+	res, err := store.do(ctx, http.MethodDelete, key, nil, emptyPayloadHash, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+/*
+This function sends a signed request for the object with the key.
+*/
+func (store *S3Store) do(ctx context.Context, method, key string, body []byte, payloadHash, contentType string) (*http.Response, error) {
+	if !keyPattern.MatchString(key) {
+		return nil, errInvalidKey
+	}
+
+	u := *store.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + store.bucket + "/" + key
+	u.RawPath = ""
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	store.signer.sign(req, payloadHash, time.Now().UTC())
+
+	return store.client.Do(req)
+}
+
+/*
+This function creates an error from an unexpected response, including the start of the error document.
+*/
+func responseError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(body)))
+}
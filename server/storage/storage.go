@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/joho/godotenv"
+)
+
+// Keys are the hex encoded SHA-256 hashes of the content, so the same content is only stored once
+var keyPattern = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+var ErrNotFound = errors.New("blob not found")
+
+var errInvalidKey = errors.New("invalid blob key")
+
+/*
+A content-addressed store for the uploaded files, where each blob is stored under the hash of its content.
+Putting a blob that already exists replaces it with the same content, and deleting a blob that
+does not exist is not an error. Getting a blob that does not exist returns ErrNotFound.
+*/
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+/*
+This function creates the blob store specified by STORAGE_BACKEND in environment variables,
+which is either 'local' (the default) or 's3'.
+The local store keeps the blobs in the STORAGE_DIR directory, which defaults to 'uploads'.
+The S3 store uses the bucket S3_BUCKET at S3_ENDPOINT with the S3_ACCESS_KEY and S3_SECRET_KEY credentials,
+where S3_REGION defaults to 'us-east-1'. Any S3 compatible service such as MinIO can be used.
+*/
+func GetBlobStore() BlobStore {
+	godotenv.Load(".env")
+
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+
+		store, err := NewLocalStore(dir)
+		if err != nil {
+			log.Fatal("Cannot create local storage: ", err)
+		}
+		return store
+	case "s3":
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		store, err := NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_BUCKET"),
+			region,
+			os.Getenv("S3_ACCESS_KEY"),
+			os.Getenv("S3_SECRET_KEY"),
+		)
+		if err != nil {
+			log.Fatal("Cannot create S3 storage: ", err)
+		}
+		return store
+	default:
+		log.Fatalf("STORAGE_BACKEND must be 'local' or 's3', got '%s'", backend)
+		return nil
+	}
+}